@@ -59,6 +59,11 @@ class PenggunaModel {
      params.push(id);
 
      const [result] = await pool.query<ResultSetHeader>(query, params);
+
+     // Only employees (peran 4) approve as line managers
+     if (data.peran_id !== undefined && Number(data.peran_id) !== 4) {
+       await PenggunaModel.releaseManagerSteps(id);
+     }
      return result.affectedRows > 0;
   }
 
@@ -68,8 +73,21 @@ class PenggunaModel {
     
     const newStatus = !rows[0].aktif;
     await pool.query('UPDATE pengguna SET aktif = ? WHERE id = ?', [newStatus, id]);
+    if (!newStatus) {
+      await PenggunaModel.releaseManagerSteps(id);
+    }
     return true;
   }
+
+  // Leave requests waiting on a manager who can no longer approve go straight to HR
+  static async releaseManagerSteps(id: number): Promise<void> {
+    await pool.query(
+      `UPDATE persetujuan_cuti s JOIN pengajuan_cuti pc ON s.pengajuan_cuti_id = pc.id
+       SET s.status = 'dilewati', s.catatan = 'Atasan tidak lagi aktif', s.diproses_pada = NOW()
+       WHERE s.penyetuju_id = ? AND s.peran = 'atasan' AND s.status = 'menunggu' AND pc.status = 'menunggu'`,
+      [id]
+    );
+  }
   
   static async findById(id: number): Promise<Pengguna | null> {
     const [rows] = await pool.query<RowDataPacket[]>('SELECT * FROM pengguna WHERE id = ?', [id]);
@@ -7,11 +7,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/hris-system/api-golang/internal/database"
+	admsHandlers "github.com/hris-system/api-golang/internal/handlers/adms"
 	empHandler "github.com/hris-system/api-golang/internal/handlers/employee"
 	financeHandlers "github.com/hris-system/api-golang/internal/handlers/finance"
 	hrHandlers "github.com/hris-system/api-golang/internal/handlers/hr"
+	kioskHandlers "github.com/hris-system/api-golang/internal/handlers/kiosk"
 	seederHandlers "github.com/hris-system/api-golang/internal/handlers/seeder"
 	"github.com/hris-system/api-golang/internal/middleware"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+	"github.com/hris-system/api-golang/internal/services/leave"
+	"github.com/hris-system/api-golang/internal/storage"
 	"github.com/joho/godotenv"
 )
 
@@ -34,6 +39,13 @@ func main() {
 	}
 	defer database.Close()
 
+	// File storage for attendance photos and attachments
+	if err := storage.Init(); err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	attendance.StartPhotoRetention()
+	leave.StartBalanceAccrual()
+
 	// Initialize Gin router
 	router := gin.Default()
 
@@ -41,7 +53,7 @@ func main() {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", os.Getenv("CORS_ORIGIN"))
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Kiosk-Token")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -68,7 +80,7 @@ func main() {
 			"version": "1.0.0",
 			"endpoints": gin.H{
 				"health":          "/health",
-				"presensiMasuk":   "/api/presensi/masuk",
+				"presensiMassal":  "/api/hr/presensi/bulk",
 				"presensiPulang":  "/api/presensi/pulang",
 				"presensiHariIni": "/api/presensi/hari-ini",
 				"pengajuanCuti":   "/api/cuti",
@@ -80,29 +92,100 @@ func main() {
 		})
 	})
 
+	// ZKTeco ADMS push endpoints; terminals use these fixed paths
+	iclock := router.Group("/iclock")
+	{
+		iclock.GET("/cdata", admsHandlers.HandshakeHandler)
+		iclock.POST("/cdata", admsHandlers.PushHandler)
+		iclock.GET("/getrequest", admsHandlers.GetRequestHandler)
+		iclock.POST("/devicecmd", admsHandlers.DeviceCmdHandler)
+	}
+
 	// API route groups
 	api := router.Group("/api")
 	{
 		// Presensi endpoints
-		api.POST("/presensi/masuk", hrHandlers.CreatePresensi)
 		api.POST("/presensi/pulang", hrHandlers.UpdatePresensiPulang)
 		api.GET("/presensi/hari-ini", hrHandlers.GetPresensiHariIni)
 
 		// Cuti endpoints
 		api.POST("/cuti", hrHandlers.CreatePengajuanCuti)
 		api.GET("/cuti", hrHandlers.GetPengajuanCuti)
-		api.PUT("/cuti/:id/approve", hrHandlers.ApprovePengajuanCuti)
+		api.PUT("/cuti/:id/approve", middleware.AuthMiddleware(), middleware.RoleMiddleware(2), hrHandlers.ApprovePengajuanCuti)
+
+		// Kiosk tablet, authenticated with its own X-Kiosk-Token
+		api.GET("/kiosk/qr", kioskHandlers.GetQRCodeHandler)
 
 		// HR Endpoints
 		api.GET("/hr/dashboard", hrHandlers.GetHRDashboardStats)
 		api.GET("/hr/presensi", hrHandlers.GetPresensiMonitoring)
 		api.GET("/hr/cuti", hrHandlers.GetAllLeaveRequestsHandler)
-		api.PUT("/hr/cuti/:id/process", hrHandlers.ProcessLeaveRequestHandler)
 		api.GET("/hr/gaji/draft", hrHandlers.GetPayrollDraftsHandler)
 		api.GET("/hr/gaji/details", hrHandlers.GetPayrollDetailsHandler)
 		api.POST("/hr/gaji/send", hrHandlers.SendPayrollToFinanceHandler)
 		api.GET("/hr/gaji/history", hrHandlers.GetPayrollHistoryHandler)
 
+		// Live attendance feed; EventSource authenticates with a ticket from /hr/presensi/stream/tiket
+		api.GET("/hr/presensi/stream", middleware.StreamTicketMiddleware(), middleware.RoleMiddleware(2), hrHandlers.StreamAttendanceHandler)
+
+		// iCal feeds for calendar apps, authenticated with a revocable calendar token
+		api.GET("/hr/cuti/kalender/feed", middleware.CalendarFeedMiddleware(2), hrHandlers.GetLeaveCalendarHandler)
+		api.GET("/employee/leave/calendar/feed", middleware.CalendarFeedMiddleware(4), empHandler.GetLeaveCalendarHandler)
+
+		// HR Routes that act on behalf of the logged-in HR user
+		hrGroup := api.Group("/hr")
+		hrGroup.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware(2)) // 2 = HR
+		{
+			hrGroup.GET("/presensi/koreksi", hrHandlers.GetCorrectionRequestsHandler)
+			hrGroup.PUT("/presensi/koreksi/:id/process", hrHandlers.ProcessCorrectionHandler)
+			hrGroup.POST("/presensi/bulk", hrHandlers.BulkAttendanceHandler)
+			hrGroup.GET("/presensi/anomali", hrHandlers.GetFlaggedPunchesHandler)
+			hrGroup.PUT("/presensi/anomali/:id/review", hrHandlers.ReviewFlaggedPunchHandler)
+			hrGroup.GET("/presensi/offline", hrHandlers.GetOfflinePunchesHandler)
+			hrGroup.PUT("/presensi/offline/:id/process", hrHandlers.ProcessOfflinePunchHandler)
+			hrGroup.GET("/presensi/:id/foto/:jenis", hrHandlers.GetPresensiPhotoHandler)
+			hrGroup.GET("/kiosk", hrHandlers.GetKiosksHandler)
+			hrGroup.POST("/kiosk", hrHandlers.CreateKioskHandler)
+			hrGroup.PUT("/kiosk/:id", hrHandlers.UpdateKioskHandler)
+			hrGroup.POST("/kiosk/:id/rotate", hrHandlers.RotateKioskKeysHandler)
+			hrGroup.GET("/mesin", hrHandlers.GetMachinesHandler)
+			hrGroup.POST("/mesin", hrHandlers.SaveMachineHandler)
+			hrGroup.PUT("/karyawan/:id/pin-mesin", hrHandlers.SetEmployeePINHandler)
+			hrGroup.PUT("/karyawan/:id/kepegawaian", hrHandlers.SetEmploymentDataHandler)
+			hrGroup.GET("/karyawan/atasan", hrHandlers.GetReportingLinesHandler)
+			hrGroup.PUT("/karyawan/:id/atasan", hrHandlers.SetEmployeeManagerHandler)
+			hrGroup.POST("/presensi/impor-mesin", hrHandlers.ImportMachineLogHandler)
+			hrGroup.GET("/presensi/rekap", hrHandlers.GetAttendanceRecapHandler)
+			hrGroup.GET("/presensi/keterlambatan", hrHandlers.GetLatenessSummaryHandler)
+			hrGroup.GET("/presensi/tingkat-keterlambatan", hrHandlers.GetLatenessTiersHandler)
+			hrGroup.POST("/presensi/stream/tiket", hrHandlers.CreateStreamTicketHandler)
+			hrGroup.GET("/wfh", hrHandlers.GetRemoteWorkRequestsHandler)
+			hrGroup.PUT("/wfh/:id/process", hrHandlers.ProcessRemoteWorkHandler)
+			hrGroup.GET("/dinas", hrHandlers.GetBusinessTripsHandler)
+			hrGroup.PUT("/dinas/:id/process", hrHandlers.ProcessBusinessTripHandler)
+			hrGroup.POST("/gaji/tunjangan-dinas", hrHandlers.ApplyTripAllowancesHandler)
+			hrGroup.GET("/kalender", hrHandlers.GetHolidaysHandler)
+			hrGroup.POST("/kalender", hrHandlers.CreateHolidayHandler)
+			hrGroup.DELETE("/kalender/:id", hrHandlers.DeleteHolidayHandler)
+			hrGroup.POST("/kalender/impor", hrHandlers.ImportHolidaysHandler)
+			hrGroup.GET("/kalender/hari-kerja", hrHandlers.GetWorkingDaysHandler)
+			hrGroup.PUT("/cuti/:id/process", hrHandlers.ProcessLeaveRequestHandler)
+			hrGroup.GET("/cuti/:id/lampiran/:lampiran_id", hrHandlers.GetLeaveAttachmentHandler)
+			hrGroup.GET("/cuti/kalender", hrHandlers.GetLeaveCalendarHandler)
+			hrGroup.POST("/cuti/kalender/token", hrHandlers.CreateCalendarTokenHandler)
+			hrGroup.DELETE("/cuti/kalender/token", hrHandlers.RevokeCalendarTokenHandler)
+			hrGroup.GET("/cuti/penarikan", hrHandlers.GetLeaveWithdrawalsHandler)
+			hrGroup.PUT("/cuti/penarikan/:id/process", hrHandlers.ProcessLeaveWithdrawalHandler)
+			hrGroup.GET("/cuti/saldo", hrHandlers.GetLeaveBalancesHandler)
+			hrGroup.POST("/cuti/saldo/generate", hrHandlers.GenerateLeaveBalancesHandler)
+			hrGroup.POST("/cuti/saldo/rollover", hrHandlers.RolloverLeaveBalancesHandler)
+			hrGroup.GET("/cuti/saldo/mutasi", hrHandlers.GetLeaveLedgerHandler)
+			hrGroup.POST("/cuti/saldo/penyesuaian", hrHandlers.AdjustLeaveBalanceHandler)
+			hrGroup.GET("/jenis-cuti", hrHandlers.GetLeaveTypesHandler)
+			hrGroup.POST("/jenis-cuti", hrHandlers.CreateLeaveTypeHandler)
+			hrGroup.PUT("/jenis-cuti/:id", hrHandlers.UpdateLeaveTypeHandler)
+		}
+
 		// Employee Routes
 		emp := api.Group("/employee")
 		emp.Use(middleware.AuthMiddleware(), middleware.RoleMiddleware(4)) // 4 = Karyawan
@@ -115,11 +198,36 @@ func main() {
 			emp.GET("/attendance", empHandler.GetCombinedAttendanceDataHandler)
 			emp.POST("/attendance/clock-in", empHandler.ClockInHandler)
 			emp.POST("/attendance/clock-out", empHandler.ClockOutHandler)
+			emp.POST("/attendance/break-out", empHandler.BreakOutHandler)
+			emp.POST("/attendance/break-in", empHandler.BreakInHandler)
+			emp.GET("/attendance/corrections", empHandler.GetCorrectionHistoryHandler)
+			emp.POST("/attendance/corrections", empHandler.RequestCorrectionHandler)
+			emp.POST("/attendance/offline/register", empHandler.RegisterOfflineDeviceHandler)
+			emp.POST("/attendance/offline/sync", empHandler.SyncOfflinePunchesHandler)
+			emp.POST("/attendance/kiosk", empHandler.KioskPunchHandler)
+			emp.GET("/attendance/recap", empHandler.GetMonthlyRecapHandler)
+			emp.GET("/attendance/wfh", empHandler.GetRemoteWorkHistoryHandler)
+			emp.POST("/attendance/wfh", empHandler.RequestRemoteWorkHandler)
+			emp.GET("/business-trips", empHandler.GetBusinessTripHistoryHandler)
+			emp.POST("/business-trips", empHandler.RequestBusinessTripHandler)
 
 			// Leave Routes
 			emp.GET("/leave/balance", empHandler.GetLeaveBalanceHandler)
+			emp.GET("/leave/balance/ledger", empHandler.GetLeaveLedgerHandler)
+			emp.GET("/leave/types", empHandler.GetLeaveTypesHandler)
+			emp.GET("/leave/duration", empHandler.CalculateLeaveHandler)
 			emp.POST("/leave/request", empHandler.RequestLeaveHandler)
 			emp.GET("/leave/history", empHandler.GetLeaveHistoryHandler)
+			emp.GET("/leave/calendar", empHandler.GetLeaveCalendarHandler)
+			emp.POST("/leave/calendar/token", empHandler.CreateCalendarTokenHandler)
+			emp.DELETE("/leave/calendar/token", empHandler.RevokeCalendarTokenHandler)
+			emp.POST("/leave/:id/cancel", empHandler.CancelLeaveHandler)
+			emp.POST("/leave/:id/withdraw", empHandler.WithdrawLeaveHandler)
+			emp.GET("/leave/approvals", empHandler.GetLeaveApprovalsHandler)
+			emp.POST("/leave/approvals/:id/process", empHandler.ProcessLeaveApprovalHandler)
+			emp.GET("/leave/delegations", empHandler.GetLeaveDelegationsHandler)
+			emp.POST("/leave/delegations", empHandler.CreateLeaveDelegationHandler)
+			emp.DELETE("/leave/delegations/:id", empHandler.RevokeLeaveDelegationHandler)
 
 			// Salary Routes
 			emp.GET("/salary/history", empHandler.GetSalaryHistoryHandler)
@@ -0,0 +1,92 @@
+// Package adms implements the subset of the ZKTeco ADMS ("iclock") push protocol needed to
+// receive attendance logs from fingerprint terminals. Terminals speak plain text, not JSON.
+package adms
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+const maxPushBody = 10 << 20
+
+func authorize(c *gin.Context) (string, bool) {
+	sn := c.Query("SN")
+	ok, err := attendance.AuthorizeMachine(sn)
+	if err != nil {
+		log.Printf("[ADMS] Error authorizing terminal %s: %v", sn, err)
+		c.String(http.StatusInternalServerError, "ERROR")
+		return sn, false
+	}
+	if !ok {
+		c.String(http.StatusForbidden, "UNKNOWN DEVICE")
+		return sn, false
+	}
+	return sn, true
+}
+
+// HandshakeHandler answers GET /iclock/cdata, sent by the terminal on boot to fetch its push options
+func HandshakeHandler(c *gin.Context) {
+	sn, ok := authorize(c)
+	if !ok {
+		return
+	}
+	c.String(http.StatusOK, fmt.Sprintf(
+		"GET OPTION FROM: %s\nATTLOGStamp=None\nOPERLOGStamp=9999\nATTPHOTOStamp=None\n"+
+			"ErrorDelay=30\nDelay=10\nTransTimes=00:00;14:05\nTransInterval=1\n"+
+			"TransFlag=TransData AttLog\nRealtime=1\nEncrypt=None\n", sn,
+	))
+}
+
+// PushHandler answers POST /iclock/cdata. Only the ATTLOG table is imported;
+// other tables (OPERLOG, ATTPHOTO, ...) are acknowledged and dropped.
+func PushHandler(c *gin.Context) {
+	sn, ok := authorize(c)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPushBody))
+	if err != nil {
+		c.String(http.StatusBadRequest, "ERROR")
+		return
+	}
+	if c.Query("table") != "ATTLOG" {
+		c.String(http.StatusOK, "OK")
+		return
+	}
+
+	punches, err := attendance.ParseAttendanceLog(bytes.NewReader(body))
+	if err != nil {
+		c.String(http.StatusBadRequest, "ERROR")
+		return
+	}
+	result, err := attendance.ImportMachinePunches(sn, punches)
+	if err != nil {
+		// Not acknowledged, so the terminal keeps the records and retries
+		log.Printf("[ADMS] Error importing %d punches from %s: %v", len(punches), sn, err)
+		c.String(http.StatusInternalServerError, "ERROR")
+		return
+	}
+	if len(result.TanpaPengguna) > 0 {
+		log.Printf("[ADMS] Terminal %s sent punches for unmapped PINs %v", sn, result.TanpaPengguna)
+	}
+	c.String(http.StatusOK, fmt.Sprintf("OK: %d", len(punches)))
+}
+
+// GetRequestHandler answers GET /iclock/getrequest, the terminal's command poll. No commands are queued.
+func GetRequestHandler(c *gin.Context) {
+	if _, ok := authorize(c); !ok {
+		return
+	}
+	c.String(http.StatusOK, "OK")
+}
+
+// DeviceCmdHandler answers POST /iclock/devicecmd, the result of a command
+func DeviceCmdHandler(c *gin.Context) {
+	c.String(http.StatusOK, "OK")
+}
@@ -1,15 +1,92 @@
 package employee
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/attendance"
 	"github.com/hris-system/api-golang/internal/services/employee"
 )
 
 type AttendanceRequest struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Akurasi   *float64 `json:"akurasi"` // GPS accuracy in meters
+	Perangkat struct {
+		ID         string `json:"id"`
+		Model      string `json:"model"`
+		Platform   string `json:"platform"`
+		VersiApp   string `json:"versi_app"`
+		MockLokasi bool   `json:"mock_lokasi"`
+	} `json:"perangkat"`
+}
+
+func (r AttendanceRequest) toPunchInput() employee.PunchInput {
+	var parts []string
+	for _, p := range []string{r.Perangkat.Model, r.Perangkat.Platform, r.Perangkat.VersiApp} {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	info := strings.Join(parts, " / ")
+	return employee.PunchInput{
+		Latitude:      r.Latitude,
+		Longitude:     r.Longitude,
+		Akurasi:       r.Akurasi,
+		PerangkatID:   r.Perangkat.ID,
+		InfoPerangkat: info,
+		MockLokasi:    r.Perangkat.MockLokasi,
+	}
+}
+
+// bindPunch accepts either a JSON body or multipart/form-data carrying a "foto" selfie.
+// Multipart fields: latitude, longitude, akurasi, perangkat_id, perangkat_model,
+// perangkat_platform, perangkat_versi_app, mock_lokasi.
+func bindPunch(c *gin.Context) (employee.PunchInput, error) {
+	var req AttendanceRequest
+	if c.ContentType() != "multipart/form-data" {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return employee.PunchInput{}, errors.New("Invalid location data")
+		}
+		return req.toPunchInput(), nil
+	}
+
+	lat, errLat := strconv.ParseFloat(c.PostForm("latitude"), 64)
+	long, errLong := strconv.ParseFloat(c.PostForm("longitude"), 64)
+	if errLat != nil || errLong != nil {
+		return employee.PunchInput{}, errors.New("Invalid location data")
+	}
+	req.Latitude, req.Longitude = lat, long
+	if acc, err := strconv.ParseFloat(c.PostForm("akurasi"), 64); err == nil {
+		req.Akurasi = &acc
+	}
+	req.Perangkat.ID = c.PostForm("perangkat_id")
+	req.Perangkat.Model = c.PostForm("perangkat_model")
+	req.Perangkat.Platform = c.PostForm("perangkat_platform")
+	req.Perangkat.VersiApp = c.PostForm("perangkat_versi_app")
+	req.Perangkat.MockLokasi = c.PostForm("mock_lokasi") == "true"
+	in := req.toPunchInput()
+
+	file, err := c.FormFile("foto")
+	if err == http.ErrMissingFile {
+		return in, nil
+	}
+	if err != nil {
+		return in, errors.New("Invalid photo upload")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return in, err
+	}
+	defer f.Close()
+
+	in.Foto, err = attendance.ReadPhoto(f)
+	return in, err
 }
 
 func GetCombinedAttendanceDataHandler(c *gin.Context) {
@@ -31,29 +108,36 @@ func GetCombinedAttendanceDataHandler(c *gin.Context) {
 		return
 	}
 
+	breaks, err := service.GetTodayBreaks(uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to get breaks", "error": err.Error()})
+		return
+	}
+
 	// Also get config for UI radius display (bonus)
 	config, _ := service.GetActiveConfig()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"today":   today,
-			"history": history,
-			"office":  config, // Send office location so frontend can calculate distance
+			"today":     today,
+			"istirahat": breaks, // Today's breaks, null before clock-in
+			"history":   history,
+			"office":    config, // Send office location so frontend can calculate distance
 		},
 	})
 }
 
 func ClockInHandler(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	var req AttendanceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid location data"})
+	in, err := bindPunch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 
 	service := employee.NewAttendanceService()
-	err := service.ClockIn(int(userID.(float64)), req.Latitude, req.Longitude)
+	err = service.ClockIn(int(userID.(float64)), in)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
@@ -64,14 +148,14 @@ func ClockInHandler(c *gin.Context) {
 
 func ClockOutHandler(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	var req AttendanceRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid location data"})
+	in, err := bindPunch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 
 	service := employee.NewAttendanceService()
-	err := service.ClockOut(int(userID.(float64)), req.Latitude, req.Longitude)
+	err = service.ClockOut(int(userID.(float64)), in)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
@@ -79,3 +163,81 @@ func ClockOutHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Berhasil melakukan presensi pulang"})
 }
+
+// BreakOutHandler starts a break (istirahat) during the workday
+func BreakOutHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	service := employee.NewAttendanceService()
+	status, err := service.StartBreak(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Istirahat dimulai", "data": status})
+}
+
+// BreakInHandler ends the current break
+func BreakInHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	service := employee.NewAttendanceService()
+	status, err := service.EndBreak(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	message := "Istirahat selesai"
+	if status.MenitMelebihi > 0 {
+		message = fmt.Sprintf("Istirahat selesai, melebihi batas %d menit", status.MenitMelebihi)
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": message, "data": status})
+}
+
+// KioskPunchHandler clocks in / out by scanning the QR code on a site kiosk
+func KioskPunchHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req struct {
+		Kode      string `json:"kode" binding:"required"`  // Scanned QR payload
+		Jenis     string `json:"jenis" binding:"required"` // masuk / pulang
+		Perangkat struct {
+			ID string `json:"id"`
+		} `json:"perangkat"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+
+	service := employee.NewAttendanceService()
+	err := service.KioskPunch(int(userID.(float64)), req.Jenis, req.Kode, employee.PunchInput{PerangkatID: req.Perangkat.ID})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Berhasil melakukan presensi " + req.Jenis + " melalui kiosk"})
+}
+
+// GetMonthlyRecapHandler returns the employee's own timesheet for a month (bulan, tahun; default current)
+func GetMonthlyRecapHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	now := time.Now()
+	month, errMonth := strconv.Atoi(c.DefaultQuery("bulan", strconv.Itoa(int(now.Month()))))
+	year, errYear := strconv.Atoi(c.DefaultQuery("tahun", strconv.Itoa(now.Year())))
+	if errMonth != nil || errYear != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid month or year"})
+		return
+	}
+
+	service := employee.NewAttendanceService()
+	recap, err := service.GetMonthlyRecap(int(userID.(float64)), year, month)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": recap})
+}
@@ -0,0 +1,39 @@
+package employee
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/employee"
+)
+
+func RequestCorrectionHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req employee.CorrectionRequestInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+
+	service := employee.NewCorrectionService()
+	err := service.RequestCorrection(int(userID.(float64)), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pengajuan koreksi presensi berhasil dikirim"})
+}
+
+func GetCorrectionHistoryHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	service := employee.NewCorrectionService()
+
+	history, err := service.GetCorrectionHistory(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": history})
+}
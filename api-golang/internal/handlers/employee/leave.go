@@ -1,11 +1,14 @@
 package employee
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hris-system/api-golang/internal/services/employee"
+	"github.com/hris-system/api-golang/internal/services/leave"
 )
 
 func GetLeaveBalanceHandler(c *gin.Context) {
@@ -24,22 +27,85 @@ func GetLeaveBalanceHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": balance})
 }
 
+// GetLeaveLedgerHandler lists the entries behind the employee's balance (?tahun=, default this year)
+func GetLeaveLedgerHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	year, _ := strconv.Atoi(c.Query("tahun"))
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	service := employee.NewLeaveService()
+	entries, err := service.GetLedger(int(userID.(float64)), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": entries})
+}
+
+// RequestLeaveHandler accepts either a JSON body or multipart/form-data with the same fields
+// plus one or more "lampiran" files
 func RequestLeaveHandler(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	var req employee.LeaveRequestInput
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if c.ContentType() == "multipart/form-data" {
+		req.TipeCuti = c.PostForm("tipe_cuti")
+		req.TanggalMulai = c.PostForm("tanggal_mulai")
+		req.TanggalSelesai = c.PostForm("tanggal_selesai")
+		req.SetengahHari = c.PostForm("setengah_hari")
+		req.Alasan = c.PostForm("alasan")
+		if req.TipeCuti == "" || req.TanggalMulai == "" || req.TanggalSelesai == "" || req.Alasan == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+			return
+		}
+		files, err := readLeaveAttachments(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		req.Lampiran = files
+	} else if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
 		return
 	}
 
 	service := employee.NewLeaveService()
-	err := service.RequestLeave(int(userID.(float64)), req)
+	duration, err := service.RequestLeave(int(userID.(float64)), req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pengajuan cuti berhasil dikirim"})
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pengajuan cuti berhasil dikirim", "data": duration})
+}
+
+// GetLeaveTypesHandler lists the leave types the employee can request
+func GetLeaveTypesHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	service := employee.NewLeaveService()
+
+	types, err := service.GetLeaveTypes(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": types})
+}
+
+// CalculateLeaveHandler previews the working days a leave would take
+// (?tanggal_mulai=&tanggal_selesai=&setengah_hari=)
+func CalculateLeaveHandler(c *gin.Context) {
+	service := employee.NewLeaveService()
+	duration, err := service.CalculateLeave(c.Query("tanggal_mulai"), c.Query("tanggal_selesai"), c.Query("setengah_hari"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": duration})
 }
 
 func GetLeaveHistoryHandler(c *gin.Context) {
@@ -54,3 +120,70 @@ func GetLeaveHistoryHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": history})
 }
+
+// readLeaveAttachments validates the "lampiran" files of a multipart leave request
+func readLeaveAttachments(c *gin.Context) ([]*leave.Attachment, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, errors.New("Invalid attachment upload")
+	}
+	if err := leave.CheckAttachmentCount(len(form.File["lampiran"])); err != nil {
+		return nil, err
+	}
+	var files []*leave.Attachment
+	for _, fh := range form.File["lampiran"] {
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		a, err := leave.ReadAttachment(fh.Filename, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, a)
+	}
+	return files, nil
+}
+
+// CancelLeaveHandler cancels a pending leave request
+func CancelLeaveHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	service := employee.NewLeaveService()
+	if err := service.CancelLeave(int(userID.(float64)), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pengajuan cuti dibatalkan"})
+}
+
+// WithdrawLeaveHandler asks HR to withdraw an approved leave, fully or by shortening it
+func WithdrawLeaveHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+	var req employee.WithdrawLeaveInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+
+	service := employee.NewLeaveService()
+	withdrawal, err := service.WithdrawLeave(int(userID.(float64)), id, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Penarikan cuti dikirim ke HR", "data": withdrawal})
+}
@@ -0,0 +1,103 @@
+package employee
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/employee"
+)
+
+// GetLeaveApprovalsHandler lists the team leave requests waiting on the employee as line manager
+func GetLeaveApprovalsHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	service := employee.NewLeaveService()
+
+	approvals, err := service.GetApprovals(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": approvals})
+}
+
+// ProcessLeaveApprovalHandler approves (forwarding to HR) or rejects a team leave request
+func ProcessLeaveApprovalHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+	var req struct {
+		Status  string `json:"status" binding:"required"` // disetujui / ditolak
+		Catatan string `json:"catatan"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+
+	service := employee.NewLeaveService()
+	if err := service.ProcessApproval(int(userID.(float64)), id, req.Status, req.Catatan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	message := "Pengajuan cuti ditolak"
+	if req.Status == "disetujui" {
+		message = "Pengajuan cuti disetujui dan diteruskan ke HR"
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": message})
+}
+
+// GetLeaveDelegationsHandler lists the approval delegations the employee has made
+func GetLeaveDelegationsHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	service := employee.NewLeaveService()
+
+	delegations, err := service.GetDelegations(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": delegations})
+}
+
+// CreateLeaveDelegationHandler hands the employee's leave approvals to a colleague for a period
+func CreateLeaveDelegationHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req employee.DelegationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+
+	service := employee.NewLeaveService()
+	if err := service.CreateDelegation(int(userID.(float64)), req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Delegasi persetujuan berhasil dibuat"})
+}
+
+// RevokeLeaveDelegationHandler ends an approval delegation early
+func RevokeLeaveDelegationHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	service := employee.NewLeaveService()
+	if err := service.RevokeDelegation(int(userID.(float64)), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Delegasi persetujuan dicabut"})
+}
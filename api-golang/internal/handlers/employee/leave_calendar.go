@@ -0,0 +1,67 @@
+package employee
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/employee"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+// GetLeaveCalendarHandler returns who is off when in the employee's team or division.
+// Query: cakupan=tim|divisi, tanggal_mulai, tanggal_selesai (default this month), format=json|ics.
+// Calendar apps subscribe to the ics feed at /api/employee/leave/calendar/feed with the token from
+// CreateCalendarTokenHandler instead of a header.
+func GetLeaveCalendarHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	service := employee.NewLeaveService()
+
+	cal, err := service.GetTeamCalendar(int(userID.(float64)), c.Query("cakupan"), c.Query("tanggal_mulai"), c.Query("tanggal_selesai"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": cal})
+	case "ics":
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		c.Header("Content-Disposition", `inline; filename="kalender-cuti.ics"`)
+		if err := leave.WriteICS(c.Writer, cal); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format harus json atau ics"})
+	}
+}
+
+// CreateCalendarTokenHandler issues the employee's calendar feed token and returns the feed path.
+// A new token replaces the previous one, whose subscriptions stop working.
+func CreateCalendarTokenHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	token, err := employee.NewLeaveService().IssueCalendarToken(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"token": token,
+			"feed":  "/api/employee/leave/calendar/feed?format=ics&token=" + token,
+		},
+	})
+}
+
+// RevokeCalendarTokenHandler revokes the employee's calendar feed token
+func RevokeCalendarTokenHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	if err := employee.NewLeaveService().RevokeCalendarToken(int(userID.(float64))); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Token kalender berhasil dicabut"})
+}
@@ -0,0 +1,54 @@
+package employee
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/employee"
+)
+
+// RegisterOfflineDeviceHandler issues the signing key the app stores for offline punches
+func RegisterOfflineDeviceHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req struct {
+		PerangkatID string `json:"perangkat_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+
+	service := employee.NewOfflineService()
+	secret, err := service.RegisterDevice(int(userID.(float64)), req.PerangkatID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"perangkat_id":  req.PerangkatID,
+			"kunci_rahasia": secret,
+		},
+	})
+}
+
+// SyncOfflinePunchesHandler uploads punches queued while the device had no signal
+func SyncOfflinePunchesHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req employee.OfflineSyncInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data", "error": err.Error()})
+		return
+	}
+
+	service := employee.NewOfflineService()
+	results, err := service.SyncPunches(int(userID.(float64)), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": results})
+}
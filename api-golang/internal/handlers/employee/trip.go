@@ -0,0 +1,39 @@
+package employee
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/employee"
+)
+
+func RequestBusinessTripHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	var req employee.BusinessTripInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+
+	service := employee.NewBusinessTripService()
+	err := service.RequestTrip(int(userID.(float64)), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pengajuan perjalanan dinas berhasil dikirim"})
+}
+
+func GetBusinessTripHistoryHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	service := employee.NewBusinessTripService()
+
+	history, err := service.GetTripHistory(int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": history})
+}
@@ -0,0 +1,70 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetFlaggedPunchesHandler lists clock-ins/outs flagged as possible GPS spoofing
+func GetFlaggedPunchesHandler(c *gin.Context) {
+	status := c.Query("status")           // Optional: menunggu, valid, curang
+	startDate := c.Query("tanggal_mulai") // Optional: YYYY-MM-DD
+	endDate := c.Query("tanggal_selesai") // Optional: YYYY-MM-DD
+
+	service := hr.NewAnomalyService()
+	flags, err := service.GetFlaggedPunches(status, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil data presensi mencurigakan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    flags,
+	})
+}
+
+// ReviewFlaggedPunchHandler marks a flagged punch as valid or fraudulent
+func ReviewFlaggedPunchHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Status  string `json:"status" binding:"required"` // valid / curang
+		Catatan string `json:"catatan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewAnomalyService()
+	if err := service.ReviewFlag(id, input.Status, input.Catatan, int(userID.(float64))); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menyimpan hasil tinjauan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Hasil tinjauan berhasil disimpan",
+	})
+}
@@ -8,62 +8,6 @@ import (
 	"github.com/hris-system/api-golang/internal/database"
 )
 
-// CreatePresensi handles presensi masuk
-func CreatePresensi(c *gin.Context) {
-	var input struct {
-		PenggunaID     int     `json:"pengguna_id" binding:"required"`
-		LatitudeMasuk  float64 `json:"latitude_masuk" binding:"required"`
-		LongitudeMasuk float64 `json:"longitude_masuk" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "Data tidak valid",
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	// TODO: Validate GPS location against konfigurasi_presensi
-	// TODO: Calculate status (hadir/terlambat) based on time
-
-	now := time.Now()
-	query := `
-		INSERT INTO presensi 
-		(pengguna_id, tanggal, waktu_masuk, latitude_masuk, longitude_masuk, status) 
-		VALUES (?, ?, ?, ?, ?, ?)
-	`
-
-	result, err := database.DB.Exec(query,
-		input.PenggunaID,
-		now.Format("2006-01-02"),
-		now,
-		input.LatitudeMasuk,
-		input.LongitudeMasuk,
-		"hadir", // Default status, should be calculated
-	)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Gagal menyimpan presensi",
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	id, _ := result.LastInsertId()
-	c.JSON(http.StatusCreated, gin.H{
-		"success": true,
-		"message": "Presensi masuk berhasil",
-		"data": gin.H{
-			"id":          id,
-			"waktu_masuk": now,
-		},
-	})
-}
-
 // UpdatePresensiPulang handles presensi pulang
 func UpdatePresensiPulang(c *gin.Context) {
 	var input struct {
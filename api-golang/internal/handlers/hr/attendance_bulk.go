@@ -0,0 +1,48 @@
+package hr
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// BulkAttendanceHandler creates or updates presensi for many employees and dates at once
+func BulkAttendanceHandler(c *gin.Context) {
+	var input struct {
+		Data []hr.BulkAttendanceEntry `json:"data" binding:"required,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewBulkAttendanceService()
+	results, err := service.ApplyBulk(input.Data, int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menyimpan presensi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	summary := map[string]int{"dibuat": 0, "diperbarui": 0, "dilewati": 0, "gagal": 0}
+	for _, r := range results {
+		summary[r.Aksi]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Presensi berhasil diproses",
+		"data":    results,
+		"meta":    summary,
+	})
+}
@@ -0,0 +1,123 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+func GetMachinesHandler(c *gin.Context) {
+	service := hr.NewBiometricService()
+	machines, err := service.GetMachines()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil data mesin absensi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    machines,
+	})
+}
+
+// SaveMachineHandler registers or updates a fingerprint terminal by serial number
+func SaveMachineHandler(c *gin.Context) {
+	var input hr.MesinAbsensiInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	service := hr.NewBiometricService()
+	if err := service.SaveMachine(input); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal menyimpan mesin absensi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Mesin absensi berhasil disimpan",
+	})
+}
+
+// SetEmployeePINHandler maps a terminal user ID to an employee
+func SetEmployeePINHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		PinMesin string `json:"pin_mesin"` // Empty to remove the mapping
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	service := hr.NewBiometricService()
+	if err := service.SetEmployeePIN(id, input.PinMesin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menyimpan PIN mesin",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "PIN mesin berhasil disimpan",
+	})
+}
+
+// ImportMachineLogHandler imports an attendance log file ("berkas") exported from a terminal
+func ImportMachineLogHandler(c *gin.Context) {
+	file, err := c.FormFile("berkas")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Berkas log presensi harus diunggah"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Berkas tidak dapat dibaca"})
+		return
+	}
+	defer f.Close()
+
+	service := hr.NewBiometricService()
+	result, err := service.ImportLogFile(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal mengimpor log presensi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Log presensi berhasil diimpor",
+		"data":    result,
+	})
+}
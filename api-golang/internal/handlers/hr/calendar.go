@@ -0,0 +1,155 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetHolidaysHandler lists the holiday calendar of a year (?tahun=, default this year; ?jenis= optional)
+func GetHolidaysHandler(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("tahun"))
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	service := hr.NewCalendarService()
+	holidays, err := service.GetHolidays(year, c.Query("jenis"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil kalender libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    holidays,
+	})
+}
+
+// CreateHolidayHandler adds a national holiday, cuti bersama or company day off
+func CreateHolidayHandler(c *gin.Context) {
+	var input hr.HolidayInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewCalendarService()
+	if err := service.CreateHoliday(input, int(userID.(float64))); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menambahkan hari libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Hari libur berhasil ditambahkan",
+	})
+}
+
+// DeleteHolidayHandler removes a day from the holiday calendar
+func DeleteHolidayHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	service := hr.NewCalendarService()
+	if err := service.DeleteHoliday(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menghapus hari libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Hari libur berhasil dihapus",
+	})
+}
+
+// ImportHolidaysHandler imports an iCalendar file ("berkas"); ?jenis= sets the type of the imported days
+func ImportHolidaysHandler(c *gin.Context) {
+	file, err := c.FormFile("berkas")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Berkas iCal harus diunggah"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Berkas tidak dapat dibaca"})
+		return
+	}
+	defer f.Close()
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewCalendarService()
+	result, err := service.ImportICal(f, c.Query("jenis"), int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal mengimpor kalender libur",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Kalender libur berhasil diimpor",
+		"data":    result,
+	})
+}
+
+// GetWorkingDaysHandler returns the working days of a month (?bulan=&tahun=) after weekends and holidays
+func GetWorkingDaysHandler(c *gin.Context) {
+	month, _ := strconv.Atoi(c.Query("bulan"))
+	year, _ := strconv.Atoi(c.Query("tahun"))
+	if month == 0 || year == 0 {
+		now := time.Now()
+		month = int(now.Month())
+		year = now.Year()
+	}
+	if month < 1 || month > 12 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Bulan tidak valid"})
+		return
+	}
+
+	service := hr.NewPayrollService()
+	days, err := service.WorkingDays(month, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal menghitung hari kerja",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"bulan":      month,
+			"tahun":      year,
+			"hari_kerja": days,
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetCorrectionRequestsHandler fetches attendance correction requests
+func GetCorrectionRequestsHandler(c *gin.Context) {
+	status := c.Query("status") // Optional: menunggu, disetujui, ditolak
+
+	service := hr.NewCorrectionService()
+	requests, err := service.GetCorrectionRequests(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil data koreksi presensi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requests,
+	})
+}
+
+// ProcessCorrectionHandler approves or rejects an attendance correction
+func ProcessCorrectionHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Status             string `json:"status" binding:"required"` // disetujui / ditolak
+		CatatanPersetujuan string `json:"catatan_persetujuan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewCorrectionService()
+	err = service.ProcessCorrection(id, input.Status, input.CatatanPersetujuan, int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal memproses koreksi presensi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Koreksi presensi berhasil diproses",
+	})
+}
@@ -0,0 +1,145 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetLeaveBalancesHandler lists leave balances (?tahun=, default this year; ?divisi_id= optional)
+func GetLeaveBalancesHandler(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("tahun"))
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	divisiID, _ := strconv.Atoi(c.Query("divisi_id"))
+
+	service := hr.NewLeaveService()
+	balances, err := service.GetBalances(year, divisiID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil saldo cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    balances,
+	})
+}
+
+// GenerateLeaveBalancesHandler (re)generates the year's balances from konfigurasi_cuti (?tahun=, default this year)
+func GenerateLeaveBalancesHandler(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("tahun"))
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	service := hr.NewLeaveService()
+	result, err := service.GenerateBalances(year)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal membuat saldo cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Saldo cuti berhasil dibuat",
+		"data":    result,
+	})
+}
+
+// RolloverLeaveBalancesHandler carries unused leave of a closed year into the next (?tahun=, default last year)
+func RolloverLeaveBalancesHandler(c *gin.Context) {
+	year, _ := strconv.Atoi(c.Query("tahun"))
+	if year == 0 {
+		year = time.Now().Year() - 1
+	}
+
+	service := hr.NewLeaveService()
+	result, err := service.RolloverBalances(year)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal membawa sisa cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Sisa cuti berhasil dibawa ke tahun berikutnya",
+		"data":    result,
+	})
+}
+
+// GetLeaveLedgerHandler lists the ledger entries behind an employee's balance (?pengguna_id=, ?tahun= default this year)
+func GetLeaveLedgerHandler(c *gin.Context) {
+	penggunaID, err := strconv.Atoi(c.Query("pengguna_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "pengguna_id harus diisi"})
+		return
+	}
+	year, _ := strconv.Atoi(c.Query("tahun"))
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	service := hr.NewLeaveService()
+	entries, err := service.GetLedger(penggunaID, year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil mutasi saldo cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// AdjustLeaveBalanceHandler adds or deducts days on an employee's balance with a reason
+func AdjustLeaveBalanceHandler(c *gin.Context) {
+	var input hr.BalanceAdjustmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewLeaveService()
+	balance, err := service.AdjustBalance(input, int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menyesuaikan saldo cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Saldo cuti berhasil disesuaikan",
+		"data":    balance,
+	})
+}
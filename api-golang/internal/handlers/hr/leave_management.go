@@ -1,11 +1,13 @@
 package hr
 
 import (
+	"mime"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hris-system/api-golang/internal/services/hr"
+	"github.com/hris-system/api-golang/internal/storage"
 )
 
 // GetAllLeaveRequestsHandler fetches all leave requests
@@ -39,7 +41,6 @@ func ProcessLeaveRequestHandler(c *gin.Context) {
 
 	var input struct {
 		Status             string `json:"status" binding:"required"` // disetujui / ditolak
-		DisetujuiOleh      int    `json:"disetujui_oleh" binding:"required"`
 		CatatanPersetujuan string `json:"catatan_persetujuan"`
 	}
 
@@ -52,8 +53,10 @@ func ProcessLeaveRequestHandler(c *gin.Context) {
 		return
 	}
 
+	// The approver is the logged-in HR user, never a value from the body
+	userID, _ := c.Get("user_id")
 	service := hr.NewLeaveService()
-	err = service.ProcessLeaveRequest(id, input.Status, input.CatatanPersetujuan, input.DisetujuiOleh)
+	warnings, err := service.ProcessLeaveRequest(id, input.Status, input.CatatanPersetujuan, int(userID.(float64)))
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -64,8 +67,45 @@ func ProcessLeaveRequestHandler(c *gin.Context) {
 		return
 	}
 
+	// Short-staffed days do not block the approval, HR is only warned
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Pengajuan cuti berhasil diproses",
+		"data":    gin.H{"peringatan_staf": warnings},
+	})
+}
+
+// GetLeaveAttachmentHandler streams a supporting document of a leave request for preview
+func GetLeaveAttachmentHandler(c *gin.Context) {
+	id, errID := strconv.Atoi(c.Param("id"))
+	lampiranID, errLampiran := strconv.Atoi(c.Param("lampiran_id"))
+	if errID != nil || errLampiran != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	service := hr.NewLeaveService()
+	lampiran, key, err := service.GetAttachment(id, lampiranID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	file, err := storage.Store.Get(key)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == storage.ErrNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"success": false, "message": "Gagal mengambil lampiran", "error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	// inline so PDFs and images open in the browser; nosniff keeps the stored type authoritative
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, int64(lampiran.Ukuran), lampiran.TipeKonten, file, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("inline", map[string]string{"filename": lampiran.NamaBerkas}),
 	})
 }
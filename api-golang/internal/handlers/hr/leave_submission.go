@@ -2,10 +2,14 @@ package hr
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+	"github.com/hris-system/api-golang/internal/services/hr"
+	"github.com/hris-system/api-golang/internal/services/leave"
 )
 
 // CreatePengajuanCuti creates a new leave request
@@ -15,6 +19,7 @@ func CreatePengajuanCuti(c *gin.Context) {
 		TipeCuti       string `json:"tipe_cuti" binding:"required"`
 		TanggalMulai   string `json:"tanggal_mulai" binding:"required"`
 		TanggalSelesai string `json:"tanggal_selesai" binding:"required"`
+		SetengahHari   string `json:"setengah_hari"` // pagi / siang
 		Alasan         string `json:"alasan" binding:"required"`
 	}
 
@@ -27,44 +32,112 @@ func CreatePengajuanCuti(c *gin.Context) {
 		return
 	}
 
-	// Parse dates
-	startDate, _ := time.Parse("2006-01-02", input.TanggalMulai)
-	endDate, _ := time.Parse("2006-01-02", input.TanggalSelesai)
+	// Count working days only, as the employee leave request does
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengajukan cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+	duration, err := attendance.LeaveDurationFor(input.TanggalMulai, input.TanggalSelesai, input.SetengahHari, config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
 
-	// Calculate total days
-	totalHari := int(endDate.Sub(startDate).Hours()/24) + 1
+	// The leave type's eligibility rules and limits apply to HR-entered requests too
+	jenis, err := leave.TypeByCode(input.TipeCuti)
+	if err == nil {
+		start, _ := time.ParseInLocation("2006-01-02", input.TanggalMulai, time.Local)
+		err = leave.CheckEligibility(jenis, input.PenggunaID, start, duration.TotalHari)
+	}
+	if err == nil {
+		// This path takes no files, so types that need a document must be submitted by the employee
+		err = leave.CheckAttachments(jenis, duration.TotalHari, nil)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
 
 	query := `
 		INSERT INTO pengajuan_cuti 
-		(pengguna_id, tipe_cuti, tanggal_mulai, tanggal_selesai, total_hari, alasan, status) 
-		VALUES (?, ?, ?, ?, ?, ?, 'menunggu')
+		(pengguna_id, tipe_cuti, tanggal_mulai, tanggal_selesai, setengah_hari, total_hari, alasan, status) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'menunggu')
 	`
 
-	result, err := database.DB.Exec(query,
+	fail := func(err error) {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengajukan cuti",
+			"error":   err.Error(),
+		})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Overlaps are checked under the applicant's lock, as the employee leave request does
+	err = leave.LockApplicant(tx, input.PenggunaID)
+	if err == nil {
+		err = leave.CheckConflicts(tx, input.PenggunaID, duration)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	result, err := tx.Exec(query,
 		input.PenggunaID,
 		input.TipeCuti,
 		input.TanggalMulai,
 		input.TanggalSelesai,
-		totalHari,
+		duration.SetengahHari,
+		duration.TotalHari,
 		input.Alasan,
 	)
-
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "Gagal mengajukan cuti",
-			"error":   err.Error(),
-		})
+		fail(err)
 		return
 	}
-
 	id, _ := result.LastInsertId()
+
+	// The request is routed to the employee's line manager, then HR
+	if err := leave.CreateSteps(tx, int(id), input.PenggunaID); err != nil {
+		fail(err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		fail(err)
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
 		"message": "Pengajuan cuti berhasil",
 		"data": gin.H{
 			"id":         id,
-			"total_hari": totalHari,
+			"total_hari": duration.TotalHari,
+			"rincian":    duration.Rincian,
 			"status":     "menunggu",
 		},
 	})
@@ -114,7 +187,8 @@ func GetPengajuanCuti(c *gin.Context) {
 	var pengajuanList []map[string]interface{}
 	for rows.Next() {
 		var (
-			id, penggunaID, totalHari              int
+			id, penggunaID                         int
+			totalHari                              float64
 			tipeCuti, alasan, status, namaLengkap  string
 			tanggalMulai, tanggalSelesai           string
 			tanggalPersetujuan, catatanPersetujuan *string
@@ -152,13 +226,18 @@ func GetPengajuanCuti(c *gin.Context) {
 	})
 }
 
-// ApprovePengajuanCuti approves or rejects leave request
+// ApprovePengajuanCuti approves or rejects leave request on behalf of the logged-in HR user. It goes
+// through the same approval as /api/hr/cuti/:id/process, so the balance is consumed and posted to
+// the ledger.
 func ApprovePengajuanCuti(c *gin.Context) {
-	id := c.Param("id")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
 
 	var input struct {
 		Status             string  `json:"status" binding:"required"` // disetujui / ditolak
-		DisetujuiOleh      int     `json:"disetujui_oleh" binding:"required"`
 		CatatanPersetujuan *string `json:"catatan_persetujuan"`
 	}
 
@@ -171,13 +250,13 @@ func ApprovePengajuanCuti(c *gin.Context) {
 		return
 	}
 
-	query := `
-		UPDATE pengajuan_cuti 
-		SET status = ?, disetujui_oleh = ?, tanggal_persetujuan = NOW(), catatan_persetujuan = ?
-		WHERE id = ?
-	`
+	catatan := ""
+	if input.CatatanPersetujuan != nil {
+		catatan = *input.CatatanPersetujuan
+	}
 
-	result, err := database.DB.Exec(query, input.Status, input.DisetujuiOleh, input.CatatanPersetujuan, id)
+	userID, _ := c.Get("user_id")
+	warnings, err := hr.NewLeaveService().ProcessLeaveRequest(id, input.Status, catatan, int(userID.(float64)))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -187,17 +266,9 @@ func ApprovePengajuanCuti(c *gin.Context) {
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"success": false,
-			"message": "Pengajuan cuti tidak ditemukan",
-		})
-		return
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Pengajuan cuti berhasil diproses",
+		"data":    gin.H{"peringatan_staf": warnings},
 	})
 }
@@ -0,0 +1,90 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetLeaveTypesHandler lists the leave type catalogue
+func GetLeaveTypesHandler(c *gin.Context) {
+	service := hr.NewLeaveService()
+	types, err := service.GetLeaveTypes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil jenis cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    types,
+	})
+}
+
+// CreateLeaveTypeHandler adds a leave type to the catalogue
+func CreateLeaveTypeHandler(c *gin.Context) {
+	var input hr.LeaveTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	service := hr.NewLeaveService()
+	if err := service.CreateLeaveType(input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menambahkan jenis cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Jenis cuti berhasil ditambahkan",
+	})
+}
+
+// UpdateLeaveTypeHandler changes the settings of a leave type
+func UpdateLeaveTypeHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input hr.LeaveTypeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	service := hr.NewLeaveService()
+	if err := service.UpdateLeaveType(id, input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal memperbarui jenis cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Jenis cuti berhasil diperbarui",
+	})
+}
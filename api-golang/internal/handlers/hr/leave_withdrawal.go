@@ -0,0 +1,67 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetLeaveWithdrawalsHandler lists leave withdrawal requests (?status= optional)
+func GetLeaveWithdrawalsHandler(c *gin.Context) {
+	service := hr.NewLeaveService()
+	withdrawals, err := service.GetWithdrawals(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil penarikan cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    withdrawals,
+	})
+}
+
+// ProcessLeaveWithdrawalHandler acknowledges or rejects a leave withdrawal
+func ProcessLeaveWithdrawalHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Status             string `json:"status" binding:"required"` // disetujui / ditolak
+		CatatanPersetujuan string `json:"catatan_persetujuan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewLeaveService()
+	err = service.ProcessWithdrawal(id, input.Status, input.CatatanPersetujuan, int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal memproses penarikan cuti",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Penarikan cuti berhasil diproses",
+	})
+}
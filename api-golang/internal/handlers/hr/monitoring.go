@@ -2,20 +2,43 @@ package hr
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/attendance"
 	"github.com/hris-system/api-golang/internal/services/hr"
+	"github.com/hris-system/api-golang/internal/storage"
 )
 
-// GetPresensiMonitoring handles fetching attendance monitoring data
+// GetPresensiMonitoring handles fetching attendance monitoring data.
+// Query: tanggal_mulai, tanggal_selesai (or date for a single day), divisi_id, status (comma separated),
+// tanpa_pulang=true, mode_kerja (kantor / wfh / dinas), cari, urutkan, arah, halaman, per_halaman
 func GetPresensiMonitoring(c *gin.Context) {
-	date := c.Query("date") // Format: YYYY-MM-DD
+	filter := hr.MonitoringFilter{
+		TanggalMulai:   c.DefaultQuery("tanggal_mulai", c.Query("date")), // Format: YYYY-MM-DD
+		TanggalSelesai: c.DefaultQuery("tanggal_selesai", c.Query("date")),
+		TanpaPulang:    c.Query("tanpa_pulang") == "true",
+		ModeKerja:      c.Query("mode_kerja"),
+		Cari:           strings.TrimSpace(c.Query("cari")),
+		Urutkan:        c.Query("urutkan"),
+		Arah:           c.Query("arah"),
+	}
+	filter.DivisiID, _ = strconv.Atoi(c.Query("divisi_id"))
+	filter.Halaman, _ = strconv.Atoi(c.Query("halaman"))
+	filter.PerHalaman, _ = strconv.Atoi(c.Query("per_halaman"))
+	if status := c.Query("status"); status != "" {
+		filter.Status = strings.Split(status, ",")
+	}
+	if c.Query("terlambat") == "true" {
+		filter.Status = append(filter.Status, "terlambat")
+	}
 
 	service := hr.NewMonitoringService()
-	data, err := service.GetDailyMonitoring(date)
+	result, err := service.GetMonitoring(filter)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "Gagal mengambil data monitoring presensi",
 			"error":   err.Error(),
@@ -23,8 +46,46 @@ func GetPresensiMonitoring(c *gin.Context) {
 		return
 	}
 
+	totalPages := (result.Total + result.PerHalaman - 1) / result.PerHalaman
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"data":    data,
+		"success":   true,
+		"data":      result.Data,
+		"ringkasan": result.Ringkasan,
+		"meta": gin.H{
+			"total":         result.Total,
+			"halaman":       result.Halaman,
+			"per_halaman":   result.PerHalaman,
+			"total_halaman": totalPages,
+		},
 	})
 }
+
+// GetPresensiPhotoHandler streams the selfie taken with a clock-in or clock-out
+func GetPresensiPhotoHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	service := hr.NewMonitoringService()
+	key, err := service.GetPhotoKey(id, c.Param("jenis"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	file, err := storage.Store.Get(key)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == storage.ErrNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"success": false, "message": "Gagal mengambil foto presensi", "error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.DataFromReader(http.StatusOK, -1, attendance.PhotoContentType(key), file, nil)
+}
@@ -0,0 +1,68 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetOfflinePunchesHandler lists synced offline punches
+func GetOfflinePunchesHandler(c *gin.Context) {
+	status := c.Query("status") // Optional: diterima, menunggu, disetujui, ditolak
+
+	service := hr.NewOfflinePunchService()
+	items, err := service.GetOfflinePunches(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil data presensi offline",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    items,
+	})
+}
+
+// ProcessOfflinePunchHandler approves or rejects an offline punch held for review
+func ProcessOfflinePunchHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Status  string `json:"status" binding:"required"` // disetujui / ditolak
+		Catatan string `json:"catatan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewOfflinePunchService()
+	if err := service.ProcessOfflinePunch(id, input.Status, input.Catatan, int(userID.(float64))); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal memproses presensi offline",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Presensi offline berhasil diproses",
+	})
+}
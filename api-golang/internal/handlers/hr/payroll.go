@@ -126,3 +126,32 @@ func GetPayrollHistoryHandler(c *gin.Context) {
 		"data":    history,
 	})
 }
+
+// ApplyTripAllowancesHandler recomputes business trip per-diems on the month's payroll drafts
+func ApplyTripAllowancesHandler(c *gin.Context) {
+	var input struct {
+		Bulan int `json:"bulan" binding:"required"`
+		Tahun int `json:"tahun" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Data tidak valid"})
+		return
+	}
+
+	service := hr.NewPayrollService()
+	n, err := service.ApplyTripAllowances(input.Bulan, input.Tahun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal menghitung uang harian dinas",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Uang harian dinas berhasil dihitung",
+		"data":    gin.H{"jumlah_tunjangan": n},
+	})
+}
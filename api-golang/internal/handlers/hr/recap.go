@@ -0,0 +1,126 @@
+package hr
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/export"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetAttendanceRecapHandler returns the monthly timesheet of an employee or a division.
+// Query: bulan, tahun (default current month), pengguna_id or divisi_id, format=json|csv|xlsx
+func GetAttendanceRecapHandler(c *gin.Context) {
+	now := time.Now()
+	month, err := strconv.Atoi(c.DefaultQuery("bulan", strconv.Itoa(int(now.Month()))))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Bulan tidak valid"})
+		return
+	}
+	year, err := strconv.Atoi(c.DefaultQuery("tahun", strconv.Itoa(now.Year())))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tahun tidak valid"})
+		return
+	}
+	penggunaID, _ := strconv.Atoi(c.Query("pengguna_id"))
+	divisiID, _ := strconv.Atoi(c.Query("divisi_id"))
+	if penggunaID == 0 && divisiID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "pengguna_id atau divisi_id harus diisi"})
+		return
+	}
+
+	service := hr.NewRecapService()
+	recaps, err := service.GetMonthlyRecap(year, month, penggunaID, divisiID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil rekap presensi",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    recaps,
+		})
+		return
+	}
+
+	table := service.RecapTable(year, month, recaps)
+	filename := fmt.Sprintf("rekap-presensi-%04d-%02d", year, month)
+	switch format {
+	case "csv":
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		err = export.WriteCSV(c.Writer, table)
+	case "xlsx":
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.xlsx"`)
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		err = export.WriteXLSX(c.Writer, table)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format harus json, csv atau xlsx"})
+		return
+	}
+	if err != nil {
+		c.Error(err)
+	}
+}
+
+// GetLatenessSummaryHandler returns the monthly late days per employee grouped by tier.
+// Query: bulan, tahun (default current month), optional pengguna_id / divisi_id
+func GetLatenessSummaryHandler(c *gin.Context) {
+	now := time.Now()
+	month, err := strconv.Atoi(c.DefaultQuery("bulan", strconv.Itoa(int(now.Month()))))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Bulan tidak valid"})
+		return
+	}
+	year, err := strconv.Atoi(c.DefaultQuery("tahun", strconv.Itoa(now.Year())))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tahun tidak valid"})
+		return
+	}
+	penggunaID, _ := strconv.Atoi(c.Query("pengguna_id"))
+	divisiID, _ := strconv.Atoi(c.Query("divisi_id"))
+
+	service := hr.NewRecapService()
+	summaries, err := service.GetLatenessSummary(year, month, penggunaID, divisiID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil rekap keterlambatan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    summaries,
+	})
+}
+
+// GetLatenessTiersHandler lists the lateness tiers and the deduction rule each one maps to
+func GetLatenessTiersHandler(c *gin.Context) {
+	service := hr.NewRecapService()
+	tiers, err := service.GetLatenessTiers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil tingkat keterlambatan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tiers,
+	})
+}
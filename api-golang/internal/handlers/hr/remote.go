@@ -0,0 +1,70 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetRemoteWorkRequestsHandler fetches WFH requests
+func GetRemoteWorkRequestsHandler(c *gin.Context) {
+	status := c.Query("status") // Optional: menunggu, disetujui, ditolak
+
+	service := hr.NewRemoteWorkService()
+	requests, err := service.GetRemoteWorkRequests(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil data pengajuan WFH",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    requests,
+	})
+}
+
+// ProcessRemoteWorkHandler approves or rejects a WFH request
+func ProcessRemoteWorkHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Status             string `json:"status" binding:"required"` // disetujui / ditolak
+		CatatanPersetujuan string `json:"catatan_persetujuan"`
+		TanpaRadius        bool   `json:"tanpa_radius"` // Approve a request without a location, allowing clock-in from anywhere
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewRemoteWorkService()
+	err = service.ProcessRemoteWork(id, input.Status, input.CatatanPersetujuan, input.TanpaRadius, int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal memproses pengajuan WFH",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Pengajuan WFH berhasil diproses",
+	})
+}
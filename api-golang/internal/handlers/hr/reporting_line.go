@@ -0,0 +1,66 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetReportingLinesHandler lists employees with their line manager (?divisi_id= optional)
+func GetReportingLinesHandler(c *gin.Context) {
+	divisiID, _ := strconv.Atoi(c.Query("divisi_id"))
+
+	service := hr.NewReportingLineService()
+	lines, err := service.GetReportingLines(divisiID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil garis pelaporan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    lines,
+	})
+}
+
+// SetEmployeeManagerHandler sets the line manager who approves an employee's leave before HR
+func SetEmployeeManagerHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		AtasanID *int `json:"atasan_id"` // null to remove the manager
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	service := hr.NewReportingLineService()
+	if err := service.SetManager(id, input.AtasanID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal menyimpan atasan",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Atasan berhasil disimpan",
+	})
+}
@@ -0,0 +1,79 @@
+package hr
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/realtime"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// Keeps proxies and load balancers from closing an idle stream
+const streamHeartbeat = 25 * time.Second
+
+// StreamAttendanceHandler pushes clock-in (masuk), clock-out (pulang) and flagged-punch (tanda) events
+// as Server-Sent Events, followed by refreshed dashboard counters (statistik).
+// Browsers' EventSource cannot send headers, so the stream is opened with a ?tiket= from
+// CreateStreamTicketHandler instead of the session token.
+func StreamAttendanceHandler(c *gin.Context) {
+	stats, err := hr.NewDashboardService().GetStats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil data dashboard",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	events, unsubscribe := hr.NewStreamService().Subscribe()
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx response buffering
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.SSEvent(hr.EventStatistik, stats)
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(e.Jenis, e.Data)
+		case t := <-heartbeat.C:
+			c.SSEvent("ping", t.Unix())
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// CreateStreamTicketHandler issues a short-lived, single-use ticket for opening the live feed
+func CreateStreamTicketHandler(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	ticket, err := realtime.IssueTicket(userID, role)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal membuat tiket stream",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"tiket": ticket},
+	})
+}
@@ -0,0 +1,70 @@
+package hr
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/hr"
+)
+
+// GetBusinessTripsHandler fetches business trip requests
+func GetBusinessTripsHandler(c *gin.Context) {
+	status := c.Query("status") // Optional: menunggu, disetujui, ditolak
+
+	service := hr.NewBusinessTripService()
+	trips, err := service.GetTrips(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Gagal mengambil data perjalanan dinas",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    trips,
+	})
+}
+
+// ProcessBusinessTripHandler approves or rejects a business trip and sets its per-diem
+func ProcessBusinessTripHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid"})
+		return
+	}
+
+	var input struct {
+		Status             string  `json:"status" binding:"required"` // disetujui / ditolak
+		UangHarian         float64 `json:"uang_harian"`               // Per-diem per day
+		CatatanPersetujuan string  `json:"catatan_persetujuan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Data tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	service := hr.NewBusinessTripService()
+	err = service.ProcessTrip(id, input.Status, input.CatatanPersetujuan, input.UangHarian, int(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "Gagal memproses perjalanan dinas",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Perjalanan dinas berhasil diproses",
+	})
+}
@@ -0,0 +1,28 @@
+package kiosk
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+// GetQRCodeHandler returns the code the kiosk tablet renders as a QR image.
+// The tablet authenticates with the token issued by HR in the X-Kiosk-Token header
+// and polls again once berlaku_hingga has passed.
+func GetQRCodeHandler(c *gin.Context) {
+	k, err := attendance.KioskByToken(c.GetHeader("X-Kiosk-Token"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Token kiosk tidak valid"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"kiosk": gin.H{"id": k.ID, "nama": k.Nama, "lokasi": k.Lokasi},
+			"qr":    attendance.IssueKioskCode(k, time.Now()),
+		},
+	})
+}
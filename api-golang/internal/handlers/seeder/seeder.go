@@ -8,6 +8,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
 )
 
 // SeedPresensiData generates dummy attendance data
@@ -27,6 +28,12 @@ func SeedPresensiData(c *gin.Context) {
 		karyawanIDs = append(karyawanIDs, id)
 	}
 
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Generate for last 7 days including today
 	now := time.Now()
 	generatedCount := 0
@@ -35,6 +42,12 @@ func SeedPresensiData(c *gin.Context) {
 		date := now.AddDate(0, 0, -i)
 		dateStr := date.Format("2006-01-02")
 
+		// No attendance (and no absence) on weekends or holidays
+		workingDay, err := attendance.IsWorkingDay(date, config)
+		if err != nil || !workingDay {
+			continue
+		}
+
 		for _, uid := range karyawanIDs {
 			// Check if exists
 			var exists int
@@ -8,6 +8,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/hris-system/api-golang/internal/realtime"
+	"github.com/hris-system/api-golang/internal/services/leave"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -68,6 +70,45 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// StreamTicketMiddleware authenticates an EventSource with the single-use ?tiket= issued by
+// realtime.IssueTicket, since EventSource cannot send the Authorization header
+func StreamTicketMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ticket, ok := realtime.RedeemTicket(c.Query("tiket"))
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid or expired stream ticket"})
+			c.Abort()
+			return
+		}
+		c.Set("user_id", ticket.UserID)
+		c.Set("role", ticket.Role)
+		c.Next()
+	}
+}
+
+// CalendarFeedMiddleware authenticates a calendar app subscribed to an iCal feed with the
+// revocable ?token= from leave.IssueFeedToken. The token only opens format=ics, and only for users
+// with peranID, so it can never stand in for a session.
+func CalendarFeedMiddleware(peranID int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("format") != "ics" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Calendar feed is only available as format=ics"})
+			c.Abort()
+			return
+		}
+		userID, role, err := leave.FeedTokenUser(c.Query("token"))
+		if err != nil || role != peranID {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid calendar feed token"})
+			c.Abort()
+			return
+		}
+		// Same types as the JWT claims
+		c.Set("user_id", float64(userID))
+		c.Set("role", float64(role))
+		c.Next()
+	}
+}
+
 func RoleMiddleware(requiredRole int) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
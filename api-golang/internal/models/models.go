@@ -4,20 +4,24 @@ import "time"
 
 // Pengguna represents pengguna table
 type Pengguna struct {
-	ID                  int       `json:"id"`
-	Username            string    `json:"username"`
-	Email               string    `json:"email"`
-	Password            string    `json:"-"` // Hidden from JSON
-	NamaLengkap         string    `json:"nama_lengkap"`
-	Telepon             *string   `json:"telepon"`
-	PeranID             int       `json:"peran_id"`
-	DivisiID            *int      `json:"divisi_id"`
-	NamaBank            *string   `json:"nama_bank"`
-	NomorRekening       *string   `json:"nomor_rekening"`
-	NamaPemilikRekening *string   `json:"nama_pemilik_rekening"`
-	Aktif               bool      `json:"aktif"`
-	DibuatPada          time.Time `json:"dibuat_pada"`
-	DiperbaruiPada      time.Time `json:"diperbarui_pada"`
+	ID                  int        `json:"id"`
+	Username            string     `json:"username"`
+	Email               string     `json:"email"`
+	Password            string     `json:"-"` // Hidden from JSON
+	NamaLengkap         string     `json:"nama_lengkap"`
+	Telepon             *string    `json:"telepon"`
+	PeranID             int        `json:"peran_id"`
+	DivisiID            *int       `json:"divisi_id"`
+	NamaBank            *string    `json:"nama_bank"`
+	NomorRekening       *string    `json:"nomor_rekening"`
+	NamaPemilikRekening *string    `json:"nama_pemilik_rekening"`
+	PinMesin            *string    `json:"pin_mesin"`         // User ID enrolled on the fingerprint terminals
+	TanggalBergabung    *time.Time `json:"tanggal_bergabung"` // Start of service; nil = dibuat_pada
+	JenisKelamin        *string    `json:"jenis_kelamin"`     // L / P, for gender-specific leave types
+	AtasanID            *int       `json:"atasan_id"`         // Line manager, first leave approver
+	Aktif               bool       `json:"aktif"`
+	DibuatPada          time.Time  `json:"dibuat_pada"`
+	DiperbaruiPada      time.Time  `json:"diperbarui_pada"`
 }
 
 // Presensi represents presensi table
@@ -41,17 +45,21 @@ type Presensi struct {
 type PengajuanCuti struct {
 	ID                 int        `json:"id"`
 	PenggunaID         int        `json:"pengguna_id"`
-	TipeCuti           string     `json:"tipe_cuti"` // cuti, izin, sakit
+	TipeCuti           string     `json:"tipe_cuti"` // jenis_cuti.kode: cuti, izin, sakit, melahirkan, ...
 	TanggalMulai       time.Time  `json:"tanggal_mulai"`
 	TanggalSelesai     time.Time  `json:"tanggal_selesai"`
-	TotalHari          int        `json:"total_hari"`
+	SetengahHari       *string    `json:"setengah_hari"` // pagi / siang for a half-day leave
+	TotalHari          float64    `json:"total_hari"`    // Working days, 0.5 for a half day
 	Alasan             string     `json:"alasan"`
-	Status             string     `json:"status"` // menunggu, disetujui, ditolak
+	Status             string     `json:"status"` // menunggu, disetujui, ditolak, dibatalkan, ditarik
 	DisetujuiOleh      *int       `json:"disetujui_oleh"`
 	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
 	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
 	DibuatPada         time.Time  `json:"dibuat_pada"`
 	DiperbaruiPada     time.Time  `json:"diperbarui_pada"`
+
+	// Approval steps, line manager first then HR
+	Persetujuan []PersetujuanCuti `json:"persetujuan,omitempty"`
 }
 
 // Penggajian represents penggajian table
@@ -86,24 +94,187 @@ type Pembayaran struct {
 
 // SaldoCuti represents saldo_cuti table
 type SaldoCuti struct {
-	ID             int       `json:"id"`
-	PenggunaID     int       `json:"pengguna_id"`
-	Tahun          int       `json:"tahun"`
-	TotalHari      int       `json:"total_hari"`
-	HariTerpakai   int       `json:"hari_terpakai"`
-	SisaHari       int       `json:"sisa_hari"`
-	DiperbaruiPada time.Time `json:"diperbarui_pada"`
+	ID           int     `json:"id"`
+	PenggunaID   int     `json:"pengguna_id"`
+	Tahun        int     `json:"tahun"`
+	TotalHari    float64 `json:"total_hari"`
+	HariTerpakai float64 `json:"hari_terpakai"`
+	SisaHari     float64 `json:"sisa_hari"`
+	// Unused days carried over from last year, used before this year's own days until they expire
+	HariDibawa        float64    `json:"hari_dibawa"`
+	DibawaTerpakai    float64    `json:"dibawa_terpakai"`
+	HariHangus        float64    `json:"hari_hangus"` // Carried days forfeited at expiry
+	DibawaKedaluwarsa *time.Time `json:"dibawa_kedaluwarsa"`
+	SisaDibawa        float64    `json:"sisa_dibawa"` // Carried days still usable today
+	DiperbaruiPada    time.Time  `json:"diperbarui_pada"`
+
+	// Sum of HR's manual adjustments (mutasi_cuti penyesuaian)
+	HariPenyesuaian float64 `json:"hari_penyesuaian"`
+}
+
+// MutasiCuti represents mutasi_cuti table, one entry of the leave balance ledger
+type MutasiCuti struct {
+	ID              int       `json:"id"`
+	PenggunaID      int       `json:"pengguna_id"`
+	Tahun           int       `json:"tahun"`
+	Jenis           string    `json:"jenis"`         // akrual, pemakaian, pengembalian, penyesuaian, bawaan, hangus
+	Hari            float64   `json:"hari"`          // Signed: positive adds to the balance
+	HariDibawa      float64   `json:"hari_dibawa"`   // Part of hari taken from / returned to carried days
+	SaldoSetelah    float64   `json:"saldo_setelah"` // sisa_hari after this entry
+	PengajuanCutiID *int      `json:"pengajuan_cuti_id"`
+	Keterangan      *string   `json:"keterangan"`
+	DibuatOleh      *int      `json:"dibuat_oleh"`  // nil for system entries
+	NamaPembuat     *string   `json:"nama_pembuat"` // Joined from pengguna
+	DibuatPada      time.Time `json:"dibuat_pada"`
 }
 
 // KonfigurasiPresensi represents konfigurasi_presensi table
 type KonfigurasiPresensi struct {
-	ID               int       `json:"id"`
-	JamMasukMaksimal string    `json:"jam_masuk_maksimal"`
-	JamPulangMinimal string    `json:"jam_pulang_minimal"`
-	LatitudeKantor   float64   `json:"latitude_kantor"`
-	LongitudeKantor  float64   `json:"longitude_kantor"`
-	RadiusMeter      int       `json:"radius_meter"`
-	Aktif            bool      `json:"aktif"`
-	DibuatPada       time.Time `json:"dibuat_pada"`
-	DiperbaruiPada   time.Time `json:"diperbarui_pada"`
+	ID                 int       `json:"id"`
+	JamMasukMaksimal   string    `json:"jam_masuk_maksimal"`
+	JamPulangMinimal   string    `json:"jam_pulang_minimal"`
+	LatitudeKantor     float64   `json:"latitude_kantor"`
+	LongitudeKantor    float64   `json:"longitude_kantor"`
+	RadiusMeter        int       `json:"radius_meter"`
+	HariKerja          string    `json:"hari_kerja"`                // ISO weekdays, e.g. "1,2,3,4,5" (Senin-Jumat)
+	ToleransiMenit     int       `json:"toleransi_terlambat_menit"` // Grace period after JamMasukMaksimal
+	MaksIstirahatMenit int       `json:"maks_istirahat_menit"`      // Total break allowed per day, 0 = no limit
+	Aktif              bool      `json:"aktif"`
+	DibuatPada         time.Time `json:"dibuat_pada"`
+	DiperbaruiPada     time.Time `json:"diperbarui_pada"`
+}
+
+// KoreksiPresensi represents koreksi_presensi table
+type KoreksiPresensi struct {
+	ID                 int        `json:"id"`
+	PenggunaID         int        `json:"pengguna_id"`
+	PresensiID         *int       `json:"presensi_id"`
+	Tanggal            time.Time  `json:"tanggal"`
+	WaktuMasukUsulan   *time.Time `json:"waktu_masuk_usulan"`
+	WaktuPulangUsulan  *time.Time `json:"waktu_pulang_usulan"`
+	Alasan             string     `json:"alasan"`
+	Bukti              *string    `json:"bukti"`
+	Status             string     `json:"status"` // menunggu, disetujui, ditolak
+	DiprosesOleh       *int       `json:"diproses_oleh"`
+	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
+	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
+	DibuatPada         time.Time  `json:"dibuat_pada"`
+	DiperbaruiPada     time.Time  `json:"diperbarui_pada"`
+}
+
+// Kiosk is a shared tablet at a site entrance showing a rotating attendance QR code
+type Kiosk struct {
+	ID             int       `json:"id"`
+	Nama           string    `json:"nama"`
+	Lokasi         string    `json:"lokasi"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	KunciRahasia   string    `json:"-"`
+	TokenAkses     string    `json:"-"`
+	Aktif          bool      `json:"aktif"`
+	DibuatPada     time.Time `json:"dibuat_pada"`
+	DiperbaruiPada time.Time `json:"diperbarui_pada"`
+}
+
+// PengajuanWFH represents pengajuan_wfh table
+type PengajuanWFH struct {
+	ID                 int        `json:"id"`
+	PenggunaID         int        `json:"pengguna_id"`
+	TanggalMulai       time.Time  `json:"tanggal_mulai"`
+	TanggalSelesai     time.Time  `json:"tanggal_selesai"`
+	Alasan             string     `json:"alasan"`
+	Alamat             *string    `json:"alamat"`
+	Latitude           *float64   `json:"latitude"`
+	Longitude          *float64   `json:"longitude"`
+	TanpaRadius        bool       `json:"tanpa_radius"` // Approved without a location: clock-in from anywhere
+	Status             string     `json:"status"`       // menunggu, disetujui, ditolak
+	DiprosesOleh       *int       `json:"diproses_oleh"`
+	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
+	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
+	DibuatPada         time.Time  `json:"dibuat_pada"`
+}
+
+// JenisCuti represents jenis_cuti table, the leave type catalogue
+type JenisCuti struct {
+	ID                    int      `json:"id"`
+	Kode                  string   `json:"kode"` // Stored in pengajuan_cuti.tipe_cuti
+	Nama                  string   `json:"nama"`
+	PotongSaldo           bool     `json:"potong_saldo"` // Taken from saldo_cuti on approval
+	Dibayar               bool     `json:"dibayar"`
+	MaksHariPerPengajuan  *float64 `json:"maks_hari_per_pengajuan"` // nil = no limit
+	MaksHariPerTahun      *float64 `json:"maks_hari_per_tahun"`     // nil = no limit
+	JenisKelamin          *string  `json:"jenis_kelamin"`           // L / P, nil = everyone
+	MasaKerjaMinimalBulan int      `json:"masa_kerja_minimal_bulan"`
+	WajibDokumen          bool     `json:"wajib_dokumen"`
+	// With wajib_dokumen, a document is only required from this many days on (0 = always)
+	WajibDokumenMinimalHari float64 `json:"wajib_dokumen_minimal_hari"`
+	Aktif                   bool    `json:"aktif"`
+}
+
+// LampiranCuti represents lampiran_cuti table, a supporting document of a leave request
+type LampiranCuti struct {
+	ID              int       `json:"id"`
+	PengajuanCutiID int       `json:"pengajuan_cuti_id"`
+	NamaBerkas      string    `json:"nama_berkas"`
+	TipeKonten      string    `json:"tipe_konten"`
+	Ukuran          int       `json:"ukuran"` // Bytes
+	DibuatPada      time.Time `json:"dibuat_pada"`
+}
+
+// PersetujuanCuti represents persetujuan_cuti table, one approval step of a leave request
+type PersetujuanCuti struct {
+	ID              int        `json:"id"`
+	PengajuanCutiID int        `json:"pengajuan_cuti_id"`
+	Tahap           int        `json:"tahap"`
+	Peran           string     `json:"peran"`          // atasan, hr
+	PenyetujuID     *int       `json:"penyetuju_id"`   // Line manager; nil for the HR step
+	NamaPenyetuju   *string    `json:"nama_penyetuju"` // Joined from pengguna
+	Status          string     `json:"status"`         // menunggu, disetujui, ditolak, dilewati
+	DiprosesOleh    *int       `json:"diproses_oleh"`  // The approver or their delegate
+	Catatan         *string    `json:"catatan"`
+	DiprosesPada    *time.Time `json:"diproses_pada"`
+}
+
+// DelegasiPersetujuan represents delegasi_persetujuan table
+type DelegasiPersetujuan struct {
+	ID             int       `json:"id"`
+	PenggunaID     int       `json:"pengguna_id"` // Manager handing over their approvals
+	DelegasiID     int       `json:"delegasi_id"`
+	NamaDelegasi   string    `json:"nama_delegasi"` // Joined from pengguna
+	TanggalMulai   time.Time `json:"tanggal_mulai"`
+	TanggalSelesai time.Time `json:"tanggal_selesai"`
+	Alasan         *string   `json:"alasan"`
+	Aktif          bool      `json:"aktif"`
+	DibuatPada     time.Time `json:"dibuat_pada"`
+}
+
+// HariLibur represents hari_libur table
+type HariLibur struct {
+	ID         int       `json:"id"`
+	Tanggal    time.Time `json:"tanggal"`
+	Nama       string    `json:"nama"`
+	Jenis      string    `json:"jenis"`  // nasional, cuti_bersama, perusahaan
+	Sumber     string    `json:"sumber"` // manual, ical
+	UIDIcal    *string   `json:"uid_ical"`
+	DibuatOleh *int      `json:"dibuat_oleh"`
+	DibuatPada time.Time `json:"dibuat_pada"`
+}
+
+// PerjalananDinas represents perjalanan_dinas table
+type PerjalananDinas struct {
+	ID                 int        `json:"id"`
+	PenggunaID         int        `json:"pengguna_id"`
+	TanggalMulai       time.Time  `json:"tanggal_mulai"`
+	TanggalSelesai     time.Time  `json:"tanggal_selesai"`
+	Tujuan             string     `json:"tujuan"`
+	Keperluan          string     `json:"keperluan"`
+	LatitudeTujuan     *float64   `json:"latitude_tujuan"`
+	LongitudeTujuan    *float64   `json:"longitude_tujuan"`
+	RadiusKm           int        `json:"radius_km"`
+	UangHarian         float64    `json:"uang_harian"`
+	Status             string     `json:"status"` // menunggu, disetujui, ditolak
+	DisetujuiOleh      *int       `json:"disetujui_oleh"`
+	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
+	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
+	DibuatPada         time.Time  `json:"dibuat_pada"`
 }
@@ -2,10 +2,12 @@ package models
 
 type DashboardStats struct {
 	Kehadiran struct {
-		Hadir         int `json:"hadir"`
-		Terlambat     int `json:"terlambat"`
-		TidakHadir    int `json:"tidak_hadir"`
-		TotalKaryawan int `json:"total_karyawan"`
+		Hadir         int     `json:"hadir"`
+		Terlambat     int     `json:"terlambat"`
+		Dinas         int     `json:"dinas"`
+		TidakHadir    int     `json:"tidak_hadir"`
+		TotalKaryawan int     `json:"total_karyawan"`
+		HariLibur     *string `json:"hari_libur"` // Name of today's holiday, if any
 	} `json:"kehadiran"`
 	Pengajuan struct {
 		Menunggu int `json:"menunggu"`
@@ -0,0 +1,63 @@
+package realtime
+
+import "sync"
+
+// Buffered events per subscriber; a client that falls further behind misses events instead of
+// blocking the publisher
+const subscriberBuffer = 64
+
+// Event is one message pushed to subscribers. Jenis becomes the SSE event name.
+type Event struct {
+	Jenis string
+	Data  interface{}
+}
+
+// Broker fans events out to every subscriber in this process
+type Broker struct {
+	mu   sync.RWMutex
+	subs map[chan Event]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[chan Event]struct{}{}}
+}
+
+// Attendance carries clock-in, clock-out and flagged-punch events to the HR live feed
+var Attendance = NewBroker()
+
+// Subscribe registers a new listener. The returned func must be called when the listener goes away.
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish delivers the event to every subscriber without waiting on slow ones
+func (b *Broker) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribers is the number of active listeners, so publishers can skip building unused payloads
+func (b *Broker) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
@@ -0,0 +1,56 @@
+package realtime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// How long a stream ticket can wait before the EventSource redeems it
+const ticketTTL = 30 * time.Second
+
+// Ticket is who a stream ticket was issued to
+type Ticket struct {
+	UserID  interface{}
+	Role    interface{}
+	expires time.Time
+}
+
+var (
+	ticketsMu sync.Mutex
+	tickets   = map[string]Ticket{}
+)
+
+// IssueTicket returns a single-use ticket for opening a stream. EventSource cannot send the
+// Authorization header, and a ticket in the URL is harmless once used or expired, unlike the JWT.
+func IssueTicket(userID, role interface{}) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := hex.EncodeToString(b)
+
+	ticketsMu.Lock()
+	defer ticketsMu.Unlock()
+	now := time.Now()
+	for k, t := range tickets {
+		if now.After(t.expires) {
+			delete(tickets, k)
+		}
+	}
+	tickets[code] = Ticket{UserID: userID, Role: role, expires: now.Add(ticketTTL)}
+	return code, nil
+}
+
+// RedeemTicket consumes a ticket, reporting false when it is unknown, used or expired
+func RedeemTicket(code string) (Ticket, bool) {
+	ticketsMu.Lock()
+	defer ticketsMu.Unlock()
+	t, ok := tickets[code]
+	if !ok {
+		return Ticket{}, false
+	}
+	delete(tickets, code)
+	return t, time.Now().Before(t.expires)
+}
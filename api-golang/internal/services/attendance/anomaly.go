@@ -0,0 +1,228 @@
+package attendance
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+// Heuristic thresholds for spoofing detection
+const (
+	maxTravelSpeedKmh     = 250.0 // Faster than this between two punches is treated as impossible travel
+	minTravelDistanceM    = 1000.0
+	identicalCoordDays    = 30 // Look-back window for repeated coordinates
+	identicalCoordMinHits = 2  // Same exact coordinates on this many other days is suspicious
+	minRealisticAccuracyM = 1.0
+	sameAccuracyMinHits   = 3 // Same whole-number accuracy on this many previous punches is suspicious
+	sharedDeviceDays      = 30
+	sharedDeviceMaxUsers  = 2 // More distinct users than this on one device is suspicious
+)
+
+// Flag types stored in tanda_presensi.jenis
+const (
+	FlagImpossibleTravel = "perjalanan_mustahil"
+	FlagIdenticalCoords  = "koordinat_identik"
+	FlagPerfectAccuracy  = "akurasi_mencurigakan"
+	FlagSharedDevice     = "perangkat_bersama"
+	FlagMockLocation     = "lokasi_palsu"
+)
+
+// Punch is a single clock-in / clock-out as seen by the anomaly detector
+type Punch struct {
+	PresensiID  int
+	PenggunaID  int
+	Jenis       string // masuk / pulang
+	Waktu       time.Time
+	Latitude    float64
+	Longitude   float64
+	Akurasi     *float64
+	PerangkatID string
+	MockLokasi  bool // Reported by the client OS (e.g. Android isFromMockProvider)
+}
+
+// Anomaly is one heuristic hit for a punch
+type Anomaly struct {
+	Jenis  string
+	Detail string
+}
+
+// DetectAnomalies runs the server-side spoofing heuristics against a punch that has already been stored
+func DetectAnomalies(p Punch) ([]Anomaly, error) {
+	var found []Anomaly
+
+	if p.MockLokasi {
+		found = append(found, Anomaly{FlagMockLocation, "perangkat melaporkan penggunaan lokasi tiruan (mock location)"})
+	}
+
+	if a, err := checkImpossibleTravel(p); err != nil {
+		return nil, err
+	} else if a != nil {
+		found = append(found, *a)
+	}
+
+	if a, err := checkIdenticalCoordinates(p); err != nil {
+		return nil, err
+	} else if a != nil {
+		found = append(found, *a)
+	}
+
+	if a, err := checkAccuracy(p); err != nil {
+		return nil, err
+	} else if a != nil {
+		found = append(found, *a)
+	}
+
+	if a, err := checkSharedDevice(p); err != nil {
+		return nil, err
+	} else if a != nil {
+		found = append(found, *a)
+	}
+
+	return found, nil
+}
+
+// FlagPunch detects anomalies and stores them in tanda_presensi for HR review.
+// Detection problems are logged and never block the punch itself.
+func FlagPunch(p Punch) []Anomaly {
+	anomalies, err := DetectAnomalies(p)
+	if err != nil {
+		log.Printf("[FlagPunch] Error detecting anomalies for presensi %d: %v", p.PresensiID, err)
+		return nil
+	}
+
+	for _, a := range anomalies {
+		_, err := database.DB.Exec(`
+			INSERT INTO tanda_presensi (presensi_id, pengguna_id, jenis_presensi, jenis, detail, status, dibuat_pada)
+			VALUES (?, ?, ?, ?, ?, 'menunggu', NOW())
+		`, p.PresensiID, p.PenggunaID, p.Jenis, a.Jenis, a.Detail)
+		if err != nil {
+			log.Printf("[FlagPunch] Error storing flag %s for presensi %d: %v", a.Jenis, p.PresensiID, err)
+		}
+	}
+	publishFlags(p, anomalies)
+	return anomalies
+}
+
+// checkImpossibleTravel compares the punch with the user's previous punch
+func checkImpossibleTravel(p Punch) (*Anomaly, error) {
+	query := `
+		SELECT waktu, lat, lng FROM (
+			SELECT waktu_masuk AS waktu, latitude_masuk AS lat, longitude_masuk AS lng
+			FROM presensi
+			WHERE pengguna_id = ? AND waktu_masuk < ? AND latitude_masuk IS NOT NULL
+			UNION ALL
+			SELECT waktu_pulang, latitude_pulang, longitude_pulang
+			FROM presensi
+			WHERE pengguna_id = ? AND waktu_pulang < ? AND latitude_pulang IS NOT NULL
+		) punches
+		ORDER BY waktu DESC
+		LIMIT 1
+	`
+	var prevTime time.Time
+	var prevLat, prevLng float64
+	err := database.DB.QueryRow(query, p.PenggunaID, p.Waktu, p.PenggunaID, p.Waktu).Scan(&prevTime, &prevLat, &prevLng)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	distance := DistanceMeters(prevLat, prevLng, p.Latitude, p.Longitude)
+	if distance < minTravelDistanceM {
+		return nil, nil
+	}
+	hours := p.Waktu.Sub(prevTime).Hours()
+	speed := math.Inf(1)
+	if hours > 0 {
+		speed = (distance / 1000) / hours
+	}
+	if speed <= maxTravelSpeedKmh {
+		return nil, nil
+	}
+
+	return &Anomaly{FlagImpossibleTravel, fmt.Sprintf(
+		"berpindah %.1f km dalam %s sejak presensi sebelumnya (%s), kecepatan %.0f km/jam",
+		distance/1000, p.Waktu.Sub(prevTime).Round(time.Minute), prevTime.Format("2006-01-02 15:04"), speed,
+	)}, nil
+}
+
+// checkIdenticalCoordinates looks for the exact same coordinates on previous days.
+// Real GPS fixes jitter in the last decimals, so exact repeats point to a fixed fake location.
+func checkIdenticalCoordinates(p Punch) (*Anomaly, error) {
+	var hits int
+	err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM presensi
+		WHERE pengguna_id = ? AND id <> ?
+		AND tanggal >= DATE_SUB(CURDATE(), INTERVAL ? DAY)
+		AND ((latitude_masuk = ? AND longitude_masuk = ?) OR (latitude_pulang = ? AND longitude_pulang = ?))
+	`, p.PenggunaID, p.PresensiID, identicalCoordDays, p.Latitude, p.Longitude, p.Latitude, p.Longitude).Scan(&hits)
+	if err != nil {
+		return nil, err
+	}
+	if hits < identicalCoordMinHits {
+		return nil, nil
+	}
+	return &Anomaly{FlagIdenticalCoords, fmt.Sprintf(
+		"koordinat %.8f, %.8f persis sama dengan %d presensi lain dalam %d hari terakhir",
+		p.Latitude, p.Longitude, hits, identicalCoordDays,
+	)}, nil
+}
+
+// checkAccuracy flags accuracy values that real receivers practically never report
+func checkAccuracy(p Punch) (*Anomaly, error) {
+	if p.Akurasi == nil {
+		return nil, nil
+	}
+	acc := *p.Akurasi
+	if acc < minRealisticAccuracyM {
+		return &Anomaly{FlagPerfectAccuracy, fmt.Sprintf("akurasi GPS %.2f meter tidak realistis", acc)}, nil
+	}
+	if acc != math.Trunc(acc) {
+		return nil, nil
+	}
+
+	var hits int
+	err := database.DB.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT akurasi_masuk FROM presensi
+			WHERE pengguna_id = ? AND id <> ? AND akurasi_masuk IS NOT NULL
+			ORDER BY tanggal DESC LIMIT ?
+		) recent
+		WHERE akurasi_masuk = ?
+	`, p.PenggunaID, p.PresensiID, sameAccuracyMinHits, acc).Scan(&hits)
+	if err != nil {
+		return nil, err
+	}
+	if hits < sameAccuracyMinHits {
+		return nil, nil
+	}
+	return &Anomaly{FlagPerfectAccuracy, fmt.Sprintf(
+		"akurasi GPS selalu tepat %.0f meter pada %d presensi terakhir", acc, hits,
+	)}, nil
+}
+
+// checkSharedDevice flags devices used by several employees
+func checkSharedDevice(p Punch) (*Anomaly, error) {
+	if p.PerangkatID == "" {
+		return nil, nil
+	}
+	var users int
+	err := database.DB.QueryRow(`
+		SELECT COUNT(DISTINCT pengguna_id) FROM presensi
+		WHERE perangkat_id = ? AND tanggal >= DATE_SUB(CURDATE(), INTERVAL ? DAY)
+	`, p.PerangkatID, sharedDeviceDays).Scan(&users)
+	if err != nil {
+		return nil, err
+	}
+	if users <= sharedDeviceMaxUsers {
+		return nil, nil
+	}
+	return &Anomaly{FlagSharedDevice, fmt.Sprintf(
+		"perangkat %s dipakai oleh %d karyawan berbeda dalam %d hari terakhir", p.PerangkatID, users, sharedDeviceDays,
+	)}, nil
+}
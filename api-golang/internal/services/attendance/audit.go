@@ -0,0 +1,43 @@
+package attendance
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Change describes a single modification of a presensi row for the audit trail
+type Change struct {
+	PresensiID  int
+	PenggunaID  int
+	Tanggal     time.Time
+	Sumber      string // koreksi, hr_manual, ...
+	ReferensiID *int   // e.g. koreksi_presensi.id
+	MasukLama   *time.Time
+	PulangLama  *time.Time
+	StatusLama  *string
+	MasukBaru   *time.Time
+	PulangBaru  *time.Time
+	StatusBaru  string
+	Alasan      string
+	DiubahOleh  int
+}
+
+// RecordChange writes the before/after values of a presensi row to riwayat_perubahan_presensi.
+// It must run inside the same transaction as the change itself.
+func RecordChange(tx *sql.Tx, c Change) error {
+	query := `
+		INSERT INTO riwayat_perubahan_presensi
+		(presensi_id, pengguna_id, tanggal, sumber, referensi_id,
+		 waktu_masuk_lama, waktu_pulang_lama, status_lama,
+		 waktu_masuk_baru, waktu_pulang_baru, status_baru,
+		 alasan, diubah_oleh, dibuat_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW())
+	`
+	_, err := tx.Exec(query,
+		c.PresensiID, c.PenggunaID, c.Tanggal.Format("2006-01-02"), c.Sumber, c.ReferensiID,
+		c.MasukLama, c.PulangLama, c.StatusLama,
+		c.MasukBaru, c.PulangBaru, c.StatusBaru,
+		c.Alasan, c.DiubahOleh,
+	)
+	return err
+}
@@ -0,0 +1,265 @@
+package attendance
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// SumberMesin marks presensi rows written from fingerprint terminal logs
+const SumberMesin = "mesin"
+
+// Two machine punches closer than this are a double scan, not a clock-in / clock-out pair
+const minMachinePairGap = 5 * time.Minute
+
+// RawPunch is one line of a fingerprint terminal attendance log
+type RawPunch struct {
+	PIN   string // User ID enrolled on the terminal, mapped to pengguna.pin_mesin
+	Waktu time.Time
+}
+
+// MachineImportResult summarises one log upload or ADMS push
+type MachineImportResult struct {
+	Total         int      `json:"total"`
+	Baru          int      `json:"baru"`
+	Duplikat      int      `json:"duplikat"`
+	TanpaPengguna []string `json:"tanpa_pengguna"` // PINs not mapped to any pengguna
+	HariDiproses  int      `json:"hari_diproses"`
+	Gagal         []string `json:"gagal"`
+}
+
+// ParseAttendanceLog reads ZKTeco attendance exports: the ATTLOG .dat file from a USB drive,
+// CSV exports, and the ATTLOG body of an ADMS push. Every format starts with the PIN followed by
+// "YYYY-MM-DD HH:MM:SS"; the remaining columns (status, verify mode, work code) are ignored.
+// Header or malformed lines are skipped.
+func ParseAttendanceLog(r io.Reader) ([]RawPunch, error) {
+	var punches []RawPunch
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		sep := "\t"
+		if !strings.Contains(line, "\t") {
+			sep = ","
+			if strings.Count(line, ";") > strings.Count(line, ",") {
+				sep = ";"
+			}
+		}
+		fields := strings.Split(line, sep)
+		if len(fields) < 2 {
+			continue
+		}
+		pin := strings.Trim(strings.TrimSpace(fields[0]), `"`)
+		waktu, err := time.ParseInLocation("2006-01-02 15:04:05", strings.Trim(strings.TrimSpace(fields[1]), `"`), time.Local)
+		if err != nil || pin == "" {
+			continue
+		}
+		punches = append(punches, RawPunch{PIN: pin, Waktu: waktu})
+	}
+	return punches, scanner.Err()
+}
+
+type machineDay struct {
+	penggunaID int
+	tanggal    string
+}
+
+// ImportMachinePunches stores raw terminal punches, ignoring ones already imported, then re-pairs
+// every affected employee-day into presensi. nomorSeri identifies the terminal (empty for file uploads).
+func ImportMachinePunches(nomorSeri string, punches []RawPunch) (*MachineImportResult, error) {
+	result := &MachineImportResult{Total: len(punches), TanpaPengguna: []string{}, Gagal: []string{}}
+
+	pins := map[string]*int{}
+	days := map[machineDay]bool{}
+	unmapped := map[string]bool{}
+	for _, p := range punches {
+		penggunaID, ok := pins[p.PIN]
+		if !ok {
+			var id int
+			err := database.DB.QueryRow("SELECT id FROM pengguna WHERE pin_mesin = ? AND aktif = TRUE", p.PIN).Scan(&id)
+			if err != nil && err != sql.ErrNoRows {
+				return nil, err
+			}
+			if err == nil {
+				penggunaID = &id
+			}
+			pins[p.PIN] = penggunaID
+		}
+
+		res, err := database.DB.Exec(`
+			INSERT IGNORE INTO log_mesin_absensi (nomor_seri, pin, waktu, pengguna_id, dibuat_pada)
+			VALUES (?, ?, ?, ?, NOW())
+		`, nomorSeri, p.PIN, p.Waktu, penggunaID)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			result.Duplikat++
+			continue
+		}
+		result.Baru++
+
+		if penggunaID == nil {
+			if !unmapped[p.PIN] {
+				unmapped[p.PIN] = true
+				result.TanpaPengguna = append(result.TanpaPengguna, p.PIN)
+			}
+			continue
+		}
+		days[machineDay{*penggunaID, p.Waktu.Format("2006-01-02")}] = true
+	}
+
+	if len(days) == 0 {
+		return result, nil
+	}
+	config, err := ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	for d := range days {
+		if err := pairMachineDay(d.penggunaID, d.tanggal, config); err != nil {
+			log.Printf("[ImportMachinePunches] Error pairing user %d on %s: %v", d.penggunaID, d.tanggal, err)
+			result.Gagal = append(result.Gagal, fmt.Sprintf("pengguna %d tanggal %s: %v", d.penggunaID, d.tanggal, err))
+			continue
+		}
+		result.HariDiproses++
+	}
+	return result, nil
+}
+
+// RepairMachineDays re-pairs already imported punches of a PIN, e.g. after HR maps it to an employee
+func RepairMachineDays(pin string, penggunaID int) error {
+	if _, err := database.DB.Exec(
+		"UPDATE log_mesin_absensi SET pengguna_id = ? WHERE pin = ? AND pengguna_id IS NULL", penggunaID, pin,
+	); err != nil {
+		return err
+	}
+
+	rows, err := database.DB.Query(
+		"SELECT DISTINCT DATE_FORMAT(waktu, '%Y-%m-%d') FROM log_mesin_absensi WHERE pengguna_id = ?", penggunaID,
+	)
+	if err != nil {
+		return err
+	}
+	var dates []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			rows.Close()
+			return err
+		}
+		dates = append(dates, d)
+	}
+	rows.Close()
+	if len(dates) == 0 {
+		return nil
+	}
+
+	config, err := ActiveConfig()
+	if err != nil {
+		return err
+	}
+	for _, d := range dates {
+		if err := pairMachineDay(penggunaID, d, config); err != nil {
+			log.Printf("[RepairMachineDays] Error pairing user %d on %s: %v", penggunaID, d, err)
+		}
+	}
+	return nil
+}
+
+// pairMachineDay turns the day's raw punches into presensi: the first punch is masuk and the last
+// one, if far enough from the first, is pulang. Rows written by the machine are recomputed on every
+// import; rows from another source (GPS, kiosk, HR) only get a missing pulang filled in.
+// Shifts crossing midnight are not paired.
+func pairMachineDay(penggunaID int, tanggal string, config *models.KonfigurasiPresensi) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var first, last time.Time
+	err = tx.QueryRow(`
+		SELECT MIN(waktu), MAX(waktu) FROM log_mesin_absensi
+		WHERE pengguna_id = ? AND waktu >= ? AND waktu < DATE_ADD(?, INTERVAL 1 DAY)
+	`, penggunaID, tanggal, tanggal).Scan(&first, &last)
+	if err != nil {
+		return err
+	}
+
+	var sumber string
+	var masuk, pulang sql.NullTime
+	err = tx.QueryRow(
+		"SELECT sumber, waktu_masuk, waktu_pulang FROM presensi WHERE pengguna_id = ? AND tanggal = ? FOR UPDATE",
+		penggunaID, tanggal,
+	).Scan(&sumber, &masuk, &pulang)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	exists := err == nil
+	recompute := !exists || sumber == SumberMesin
+
+	punch := PunchRecord{PenggunaID: penggunaID, Sumber: SumberMesin}
+	if !recompute {
+		// Clocked in elsewhere: the last scan on the terminal is the way out
+		if pulang.Valid || !masuk.Valid || last.Sub(masuk.Time) < minMachinePairGap {
+			return tx.Commit()
+		}
+		punch.Jenis, punch.Waktu = "pulang", last
+		if _, _, err := WritePunch(tx, punch, config, false); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	punch.Jenis, punch.Waktu = "masuk", first
+	if _, _, err := WritePunch(tx, punch, config, true); err != nil {
+		return err
+	}
+	if last.Sub(first) >= minMachinePairGap {
+		punch.Jenis, punch.Waktu = "pulang", last
+		if _, _, err := WritePunch(tx, punch, config, true); err != nil {
+			return err
+		}
+	} else {
+		// A stray double scan must not leave an earlier computed pulang behind
+		if _, err := tx.Exec(`
+			UPDATE presensi SET waktu_pulang = NULL, diperbarui_pada = NOW()
+			WHERE pengguna_id = ? AND tanggal = ? AND sumber = ?
+		`, penggunaID, tanggal, SumberMesin); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AuthorizeMachine checks that a terminal pushing over ADMS is registered and active,
+// and records when it was last seen. Registration is looked up rather than read from the
+// update's affected rows, which are 0 for a second request within the same second.
+func AuthorizeMachine(nomorSeri string) (bool, error) {
+	if nomorSeri == "" {
+		return false, nil
+	}
+	var id int
+	err := database.DB.QueryRow("SELECT id FROM mesin_absensi WHERE nomor_seri = ? AND aktif = TRUE", nomorSeri).Scan(&id)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if _, err := database.DB.Exec("UPDATE mesin_absensi SET terakhir_terhubung = NOW() WHERE id = ?", id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,204 @@
+package attendance
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// BreakPeriod is one istirahat_presensi row of a presensi day
+type BreakPeriod struct {
+	ID              int        `json:"id"`
+	WaktuMulai      time.Time  `json:"waktu_mulai"`
+	WaktuSelesai    *time.Time `json:"waktu_selesai"` // nil while the break is ongoing
+	Menit           int        `json:"menit"`
+	DitutupOtomatis bool       `json:"ditutup_otomatis"` // Closed by the clock-out instead of a break-in punch
+}
+
+// BreakStatus is the break state of a day after a break punch
+type BreakStatus struct {
+	Istirahat       []BreakPeriod `json:"istirahat"`
+	TotalMenit      int           `json:"total_menit"`
+	BatasMenit      int           `json:"batas_menit"` // maks_istirahat_menit, 0 = no limit
+	MenitMelebihi   int           `json:"menit_melebihi"`
+	SedangIstirahat bool          `json:"sedang_istirahat"`
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// StartBreak opens a break (break-out punch) on the user's presensi of the day of t. A new break
+// is refused while one is open, outside the clocked-in period, and once the day's breaks have
+// used up maks_istirahat_menit.
+func StartBreak(penggunaID int, t time.Time, config *models.KonfigurasiPresensi) (*BreakStatus, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	presensiID, err := lockOpenDay(tx, penggunaID, t)
+	if err != nil {
+		return nil, err
+	}
+	status, err := breakStatus(tx, presensiID, t, config)
+	if err != nil {
+		return nil, err
+	}
+	if status.SedangIstirahat {
+		return nil, errors.New("anda sedang istirahat")
+	}
+	if config.MaksIstirahatMenit > 0 && status.TotalMenit >= config.MaksIstirahatMenit {
+		return nil, fmt.Errorf("jatah istirahat hari ini (%d menit) sudah habis", config.MaksIstirahatMenit)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO istirahat_presensi (presensi_id, waktu_mulai, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, NOW(), NOW())
+	`, presensiID, t)
+	if err != nil {
+		return nil, err
+	}
+	status, err = breakStatus(tx, presensiID, t, config)
+	if err != nil {
+		return nil, err
+	}
+	return status, tx.Commit()
+}
+
+// EndBreak closes the open break (break-in punch). Time beyond maks_istirahat_menit is reported
+// in MenitMelebihi and shows up in the monthly recap.
+func EndBreak(penggunaID int, t time.Time, config *models.KonfigurasiPresensi) (*BreakStatus, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	presensiID, err := lockOpenDay(tx, penggunaID, t)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.Exec(`
+		UPDATE istirahat_presensi SET waktu_selesai = GREATEST(?, waktu_mulai), diperbarui_pada = NOW()
+		WHERE presensi_id = ? AND waktu_selesai IS NULL
+	`, t, presensiID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, errors.New("anda belum memulai istirahat")
+	}
+	status, err := breakStatus(tx, presensiID, t, config)
+	if err != nil {
+		return nil, err
+	}
+	return status, tx.Commit()
+}
+
+// CloseOpenBreak ends a break still open at clock-out at the clock-out time
+func CloseOpenBreak(db execer, presensiID int, pulang time.Time) error {
+	_, err := db.Exec(`
+		UPDATE istirahat_presensi SET waktu_selesai = GREATEST(?, waktu_mulai), ditutup_otomatis = TRUE, diperbarui_pada = NOW()
+		WHERE presensi_id = ? AND waktu_selesai IS NULL
+	`, pulang, presensiID)
+	return err
+}
+
+// TodayBreaks returns the break state of the user's presensi today, or nil before clock-in
+func TodayBreaks(penggunaID int, config *models.KonfigurasiPresensi) (*BreakStatus, error) {
+	now := time.Now()
+	var presensiID int
+	err := database.DB.QueryRow("SELECT id FROM presensi WHERE pengguna_id = ? AND tanggal = ?", penggunaID, now.Format("2006-01-02")).Scan(&presensiID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return breakStatus(database.DB, presensiID, now, config)
+}
+
+// lockOpenDay locks the user's presensi of the day, which must be clocked in and not yet clocked out
+func lockOpenDay(tx *sql.Tx, penggunaID int, t time.Time) (int, error) {
+	var presensiID int
+	var masuk, pulang sql.NullTime
+	err := tx.QueryRow(`
+		SELECT id, waktu_masuk, waktu_pulang FROM presensi
+		WHERE pengguna_id = ? AND tanggal = ? FOR UPDATE
+	`, penggunaID, t.Format("2006-01-02")).Scan(&presensiID, &masuk, &pulang)
+	if err == sql.ErrNoRows || (err == nil && !masuk.Valid) {
+		return 0, errors.New("anda belum melakukan presensi masuk")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if pulang.Valid {
+		return 0, errors.New("anda sudah melakukan presensi pulang hari ini")
+	}
+	return presensiID, nil
+}
+
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// breakStatus lists the breaks of a presensi; an open break counts up to now
+func breakStatus(db queryer, presensiID int, now time.Time, config *models.KonfigurasiPresensi) (*BreakStatus, error) {
+	rows, err := db.Query(`
+		SELECT id, waktu_mulai, waktu_selesai, ditutup_otomatis
+		FROM istirahat_presensi WHERE presensi_id = ? ORDER BY waktu_mulai ASC
+	`, presensiID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	status := &BreakStatus{Istirahat: []BreakPeriod{}, BatasMenit: config.MaksIstirahatMenit}
+	for rows.Next() {
+		var b BreakPeriod
+		var selesai sql.NullTime
+		if err := rows.Scan(&b.ID, &b.WaktuMulai, &selesai, &b.DitutupOtomatis); err != nil {
+			return nil, err
+		}
+		end := now
+		if selesai.Valid {
+			b.WaktuSelesai = &selesai.Time
+			end = selesai.Time
+		} else {
+			status.SedangIstirahat = true
+		}
+		if end.After(b.WaktuMulai) {
+			b.Menit = int(math.Round(end.Sub(b.WaktuMulai).Minutes()))
+		}
+		status.TotalMenit += b.Menit
+		status.Istirahat = append(status.Istirahat, b)
+	}
+	status.MenitMelebihi = BreakOverrun(status.TotalMenit, config)
+	return status, rows.Err()
+}
+
+// BreakOverrun is how far the day's break minutes exceed maks_istirahat_menit
+func BreakOverrun(menit int, config *models.KonfigurasiPresensi) int {
+	if config.MaksIstirahatMenit <= 0 || menit <= config.MaksIstirahatMenit {
+		return 0
+	}
+	return menit - config.MaksIstirahatMenit
+}
+
+// ScheduledWorkMinutes is the net working time of a regular day: from jam_masuk_maksimal to
+// jam_pulang_minimal less the allowed break. Worked time beyond it is overtime.
+func ScheduledWorkMinutes(day time.Time, config *models.KonfigurasiPresensi) int {
+	menit := int(EndMinimum(day, config).Sub(StartDeadline(day, config)).Minutes()) - config.MaksIstirahatMenit
+	if menit < 0 {
+		return 0
+	}
+	return menit
+}
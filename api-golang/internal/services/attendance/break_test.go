@@ -0,0 +1,31 @@
+package attendance
+
+import (
+	"testing"
+
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+func TestBreakOverrun(t *testing.T) {
+	tests := []struct {
+		name  string
+		maks  int
+		menit int
+		want  int
+	}{
+		{"no limit", 0, 180, 0},
+		{"negative limit means none", -1, 180, 0},
+		{"no break taken", 60, 0, 0},
+		{"within the limit", 60, 45, 0},
+		{"exactly the limit", 60, 60, 0},
+		{"over the limit", 60, 75, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.KonfigurasiPresensi{MaksIstirahatMenit: tt.maks}
+			if got := BreakOverrun(tt.menit, config); got != tt.want {
+				t.Errorf("BreakOverrun(%d) with maks %d = %d, want %d", tt.menit, tt.maks, got, tt.want)
+			}
+		})
+	}
+}
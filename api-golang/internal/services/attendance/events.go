@@ -0,0 +1,94 @@
+package attendance
+
+import (
+	"log"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/realtime"
+)
+
+// Event names on the HR live feed
+const (
+	EventMasuk  = "masuk"
+	EventPulang = "pulang"
+	EventTanda  = "tanda"
+)
+
+// PunchEvent is pushed to the HR live feed after a clock-in or clock-out is committed
+type PunchEvent struct {
+	PresensiID     int        `json:"presensi_id"`
+	PenggunaID     int        `json:"pengguna_id"`
+	NamaLengkap    string     `json:"nama_lengkap"`
+	Divisi         *string    `json:"divisi"`
+	Tanggal        string     `json:"tanggal"`
+	Jenis          string     `json:"jenis"` // masuk / pulang
+	WaktuMasuk     *time.Time `json:"waktu_masuk"`
+	WaktuPulang    *time.Time `json:"waktu_pulang"`
+	Status         string     `json:"status"`
+	MenitTerlambat int        `json:"menit_terlambat"`
+	Sumber         string     `json:"sumber"`
+	ModeKerja      string     `json:"mode_kerja"`
+}
+
+// FlagEvent is pushed to the HR live feed when a punch is flagged for review
+type FlagEvent struct {
+	PresensiID    int       `json:"presensi_id"`
+	PenggunaID    int       `json:"pengguna_id"`
+	NamaLengkap   string    `json:"nama_lengkap"`
+	JenisPresensi string    `json:"jenis_presensi"`
+	Jenis         string    `json:"jenis"`
+	Detail        string    `json:"detail"`
+	Waktu         time.Time `json:"waktu"`
+}
+
+// PublishPunch announces a committed punch on the live feed. Call it only after the transaction
+// that wrote the punch has committed; nothing is loaded when no HR client is listening.
+func PublishPunch(presensiID int, jenis string) {
+	if realtime.Attendance.Subscribers() == 0 {
+		return
+	}
+	e := PunchEvent{PresensiID: presensiID, Jenis: jenis}
+	err := database.DB.QueryRow(`
+		SELECT pr.pengguna_id, p.nama_lengkap, d.nama, DATE_FORMAT(pr.tanggal, '%Y-%m-%d'),
+		       pr.waktu_masuk, pr.waktu_pulang, pr.status, pr.menit_terlambat, pr.sumber, pr.mode_kerja
+		FROM presensi pr
+		JOIN pengguna p ON pr.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE pr.id = ?
+	`, presensiID).Scan(&e.PenggunaID, &e.NamaLengkap, &e.Divisi, &e.Tanggal,
+		&e.WaktuMasuk, &e.WaktuPulang, &e.Status, &e.MenitTerlambat, &e.Sumber, &e.ModeKerja)
+	if err != nil {
+		log.Printf("[PublishPunch] Error loading presensi %d: %v", presensiID, err)
+		return
+	}
+
+	name := EventMasuk
+	if jenis == "pulang" {
+		name = EventPulang
+	}
+	realtime.Attendance.Publish(realtime.Event{Jenis: name, Data: e})
+}
+
+// publishFlags announces the anomalies stored for a punch on the live feed
+func publishFlags(p Punch, anomalies []Anomaly) {
+	if len(anomalies) == 0 || realtime.Attendance.Subscribers() == 0 {
+		return
+	}
+	var nama string
+	if err := database.DB.QueryRow("SELECT nama_lengkap FROM pengguna WHERE id = ?", p.PenggunaID).Scan(&nama); err != nil {
+		log.Printf("[publishFlags] Error loading user %d: %v", p.PenggunaID, err)
+		return
+	}
+	for _, a := range anomalies {
+		realtime.Attendance.Publish(realtime.Event{Jenis: EventTanda, Data: FlagEvent{
+			PresensiID:    p.PresensiID,
+			PenggunaID:    p.PenggunaID,
+			NamaLengkap:   nama,
+			JenisPresensi: p.Jenis,
+			Jenis:         a.Jenis,
+			Detail:        a.Detail,
+			Waktu:         p.Waktu,
+		}})
+	}
+}
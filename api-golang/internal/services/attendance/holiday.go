@@ -0,0 +1,88 @@
+package attendance
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// Values of hari_libur.jenis
+const (
+	LiburNasional    = "nasional"
+	LiburCutiBersama = "cuti_bersama"
+	LiburPerusahaan  = "perusahaan"
+)
+
+// StatusLibur marks a day off, either outside the working weekdays or on a hari_libur date
+const StatusLibur = "libur"
+
+// Holiday is one hari_libur date
+type Holiday struct {
+	Tanggal string `json:"tanggal"` // YYYY-MM-DD
+	Nama    string `json:"nama"`
+	Jenis   string `json:"jenis"`
+}
+
+// HolidayOn returns the holiday falling on the day, or nil when it is a regular day
+func HolidayOn(tanggal time.Time) (*Holiday, error) {
+	h := Holiday{Tanggal: tanggal.Format("2006-01-02")}
+	err := database.DB.QueryRow("SELECT nama, jenis FROM hari_libur WHERE tanggal = ?", h.Tanggal).Scan(&h.Nama, &h.Jenis)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// HolidaysBetween returns the holidays from start to end (both inclusive) keyed by YYYY-MM-DD
+func HolidaysBetween(start, end time.Time) (map[string]Holiday, error) {
+	rows, err := database.DB.Query(`
+		SELECT DATE_FORMAT(tanggal, '%Y-%m-%d'), nama, jenis FROM hari_libur
+		WHERE tanggal BETWEEN ? AND ?
+	`, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := map[string]Holiday{}
+	for rows.Next() {
+		var h Holiday
+		if err := rows.Scan(&h.Tanggal, &h.Nama, &h.Jenis); err != nil {
+			return nil, err
+		}
+		holidays[h.Tanggal] = h
+	}
+	return holidays, rows.Err()
+}
+
+// IsWorkingDay reports whether the day is a configured working weekday and not a holiday
+func IsWorkingDay(date time.Time, config *models.KonfigurasiPresensi) (bool, error) {
+	if !IsWorkday(date, config) {
+		return false, nil
+	}
+	h, err := HolidayOn(date)
+	if err != nil {
+		return false, err
+	}
+	return h == nil, nil
+}
+
+// WorkingDays counts the working weekdays from start to end (both inclusive) that are not holidays
+func WorkingDays(start, end time.Time, config *models.KonfigurasiPresensi) (int, error) {
+	holidays, err := HolidaysBetween(start, end)
+	if err != nil {
+		return 0, err
+	}
+	days := 0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if _, libur := holidays[day.Format("2006-01-02")]; !libur && IsWorkday(day, config) {
+			days++
+		}
+	}
+	return days, nil
+}
@@ -0,0 +1,205 @@
+package attendance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+// An all-day event longer than this is not a holiday (e.g. a school term in a shared calendar)
+const maxHolidayEventDays = 31
+
+// CalendarEvent is one VEVENT of an iCalendar file, as whole days
+type CalendarEvent struct {
+	UID     string
+	Nama    string
+	Mulai   time.Time
+	Selesai time.Time // Inclusive
+}
+
+// HolidayImportResult summarises one iCal upload
+type HolidayImportResult struct {
+	Total    int      `json:"total"` // Days covered by the events read
+	Baru     int      `json:"baru"`
+	Duplikat int      `json:"duplikat"` // Dates already on the calendar
+	Dilewati []string `json:"dilewati"` // Events ignored, with the reason
+}
+
+// ParseICal reads the VEVENTs of an iCalendar (.ics) file such as the public holiday calendars
+// published by Google or the government. DTEND is exclusive as in RFC 5545; events without one last
+// a single day. Cancelled events and Google "Observance" entries (commemorations that are not days
+// off) are skipped.
+func ParseICal(r io.Reader) ([]CalendarEvent, []string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// Folded lines continue the previous one after a leading space or tab
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var events []CalendarEvent
+	var skipped []string
+	var props map[string]icalProp
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			props = map[string]icalProp{}
+		case line == "END:VEVENT" && props != nil:
+			e, reason := icalEvent(props)
+			if reason != "" {
+				skipped = append(skipped, reason)
+			} else {
+				events = append(events, e)
+			}
+			props = nil
+		case props != nil:
+			name, p, ok := parseICalLine(line)
+			if ok {
+				props[name] = p
+			}
+		}
+	}
+	return events, skipped, nil
+}
+
+type icalProp struct {
+	params map[string]string
+	value  string
+}
+
+// parseICalLine splits "NAME;PARAM=X:VALUE" into its parts
+func parseICalLine(line string) (string, icalProp, bool) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return "", icalProp{}, false
+	}
+	head := strings.Split(line[:colon], ";")
+	p := icalProp{params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range head[1:] {
+		if kv := strings.SplitN(param, "=", 2); len(kv) == 2 {
+			p.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(head[0]), p, true
+}
+
+// icalEvent converts the properties of one VEVENT, or returns why it was skipped
+func icalEvent(props map[string]icalProp) (CalendarEvent, string) {
+	e := CalendarEvent{UID: props["UID"].value, Nama: unescapeICal(props["SUMMARY"].value)}
+	label := e.Nama
+	if label == "" {
+		label = e.UID
+	}
+	if strings.EqualFold(props["STATUS"].value, "CANCELLED") {
+		return e, label + ": dibatalkan"
+	}
+	if strings.HasPrefix(unescapeICal(props["DESCRIPTION"].value), "Observance") {
+		return e, label + ": hari peringatan, bukan hari libur"
+	}
+	if e.Nama == "" {
+		return e, label + ": tanpa SUMMARY"
+	}
+
+	start, allDay, ok := parseICalTime(props["DTSTART"])
+	if !ok {
+		return e, label + ": DTSTART tidak valid"
+	}
+	e.Mulai, e.Selesai = start, start
+	if p, has := props["DTEND"]; has {
+		end, _, ok := parseICalTime(p)
+		if !ok {
+			return e, label + ": DTEND tidak valid"
+		}
+		if allDay {
+			end = end.AddDate(0, 0, -1)
+		}
+		if end.After(start) {
+			e.Selesai = end
+		}
+	}
+	if int(e.Selesai.Sub(e.Mulai).Hours()/24)+1 > maxHolidayEventDays {
+		return e, fmt.Sprintf("%s: lebih dari %d hari", label, maxHolidayEventDays)
+	}
+	return e, ""
+}
+
+// parseICalTime reads a DATE or DATE-TIME value as a local calendar day and reports whether it
+// was a DATE (all-day) value
+func parseICalTime(p icalProp) (time.Time, bool, bool) {
+	v := strings.TrimSpace(p.value)
+	if len(v) == 8 || p.params["VALUE"] == "DATE" {
+		t, err := time.ParseInLocation("20060102", v, time.Local)
+		return t, true, err == nil
+	}
+	loc := time.Local
+	if tz, err := time.LoadLocation(p.params["TZID"]); err == nil && p.params["TZID"] != "" {
+		loc = tz
+	}
+	var t time.Time
+	var err error
+	if strings.HasSuffix(v, "Z") {
+		t, err = time.Parse("20060102T150405Z", v)
+	} else {
+		t, err = time.ParseInLocation("20060102T150405", v, loc)
+	}
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local), false, true
+}
+
+func unescapeICal(v string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(strings.TrimSpace(v))
+}
+
+// ImportHolidays adds every day of the events to hari_libur as the given jenis. Events whose
+// name mentions "cuti bersama" are stored as cuti_bersama. Dates already on the calendar are kept.
+// The import is all or nothing.
+func ImportHolidays(events []CalendarEvent, jenis string, importedBy int) (*HolidayImportResult, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &HolidayImportResult{Dilewati: []string{}}
+	for _, e := range events {
+		j := jenis
+		if strings.Contains(strings.ToLower(e.Nama), "cuti bersama") {
+			j = LiburCutiBersama
+		}
+		for day := e.Mulai; !day.After(e.Selesai); day = day.AddDate(0, 0, 1) {
+			result.Total++
+			res, err := tx.Exec(`
+				INSERT IGNORE INTO hari_libur (tanggal, nama, jenis, sumber, uid_ical, dibuat_oleh, dibuat_pada, diperbarui_pada)
+				VALUES (?, ?, ?, 'ical', ?, ?, NOW(), NOW())
+			`, day.Format("2006-01-02"), e.Nama, j, e.UID, importedBy)
+			if err != nil {
+				return nil, err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				result.Baru++
+			} else {
+				result.Duplikat++
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
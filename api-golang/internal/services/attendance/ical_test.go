@@ -0,0 +1,99 @@
+package attendance
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseICal(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:tahun-baru@example",
+		"DTSTART;VALUE=DATE:20240101",
+		"DTEND;VALUE=DATE:20240102",
+		"SUMMARY:Tahun Baru Masehi",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:lebaran@example",
+		"DTSTART;VALUE=DATE:20240410",
+		"DTEND;VALUE=DATE:20240412",
+		"SUMMARY:Hari Raya Idul Fitri 1445\\, ",
+		" Hijriah",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:cuti-bersama@example",
+		"DTSTART:20240408",
+		"SUMMARY:Cuti Bersama Idul Fitri",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:kemerdekaan@example",
+		"DTSTART;TZID=Asia/Jakarta:20240817T080000",
+		"DTEND;TZID=Asia/Jakarta:20240817T100000",
+		"SUMMARY:Hari Kemerdekaan",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:batal@example",
+		"DTSTART;VALUE=DATE:20240501",
+		"SUMMARY:Hari Buruh",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:kartini@example",
+		"DTSTART;VALUE=DATE:20240421",
+		"SUMMARY:Hari Kartini",
+		"DESCRIPTION:Observance\\nTo hide observances, go to Google Calendar Settings",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:tanpa-nama@example",
+		"DTSTART;VALUE=DATE:20240601",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:semester@example",
+		"DTSTART;VALUE=DATE:20240701",
+		"DTEND;VALUE=DATE:20241001",
+		"SUMMARY:Semester Ganjil",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:rusak@example",
+		"DTSTART;VALUE=DATE:2024-12-25",
+		"SUMMARY:Natal",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, skipped, err := ParseICal(strings.NewReader(ics))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []struct {
+		uid, nama, mulai, selesai string
+	}{
+		{"tahun-baru@example", "Tahun Baru Masehi", "2024-01-01", "2024-01-01"},
+		{"lebaran@example", "Hari Raya Idul Fitri 1445, Hijriah", "2024-04-10", "2024-04-11"},
+		{"cuti-bersama@example", "Cuti Bersama Idul Fitri", "2024-04-08", "2024-04-08"},
+		{"kemerdekaan@example", "Hari Kemerdekaan", "2024-08-17", "2024-08-17"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.UID != w.uid || e.Nama != w.nama || e.Mulai.Format("2006-01-02") != w.mulai || e.Selesai.Format("2006-01-02") != w.selesai {
+			t.Errorf("event %d = %s %q %s..%s, want %s %q %s..%s", i, e.UID, e.Nama, e.Mulai.Format("2006-01-02"),
+				e.Selesai.Format("2006-01-02"), w.uid, w.nama, w.mulai, w.selesai)
+		}
+	}
+
+	wantSkipped := []string{"Hari Buruh: ", "Hari Kartini: ", "tanpa-nama@example: ", "Semester Ganjil: ", "Natal: "}
+	if len(skipped) != len(wantSkipped) {
+		t.Fatalf("got %d skipped, want %d: %v", len(skipped), len(wantSkipped), skipped)
+	}
+	for i, prefix := range wantSkipped {
+		if !strings.HasPrefix(skipped[i], prefix) {
+			t.Errorf("skipped[%d] = %q, want prefix %q", i, skipped[i], prefix)
+		}
+	}
+}
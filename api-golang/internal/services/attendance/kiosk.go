@@ -0,0 +1,116 @@
+package attendance
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+const (
+	kioskCodePrefix        = "HRISKIOSK"
+	defaultKioskPeriodSecs = 30
+)
+
+// ErrInvalidKioskCode covers forged, expired and deactivated kiosk QR codes
+var ErrInvalidKioskCode = errors.New("kode QR kiosk tidak valid atau sudah kedaluwarsa")
+
+// KioskCode is the QR payload a kiosk displays during one time window
+type KioskCode struct {
+	Kode          string    `json:"kode"`
+	BerlakuHingga time.Time `json:"berlaku_hingga"`
+	PeriodeDetik  int       `json:"periode_detik"`
+}
+
+// KioskPeriod is how long one QR code is shown, from KIOSK_QR_PERIODE_DETIK (default 30)
+func KioskPeriod() time.Duration {
+	secs, err := strconv.Atoi(os.Getenv("KIOSK_QR_PERIODE_DETIK"))
+	if err != nil || secs <= 0 {
+		secs = defaultKioskPeriodSecs
+	}
+	return time.Duration(secs) * time.Second
+}
+
+func kioskSignature(secret string, kioskID int, window int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d|%d", kioskID, window)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+const kioskColumns = `id, nama, lokasi, latitude, longitude, kunci_rahasia, token_akses, aktif, dibuat_pada, diperbarui_pada`
+
+func scanKiosk(row *sql.Row) (*models.Kiosk, error) {
+	var k models.Kiosk
+	err := row.Scan(&k.ID, &k.Nama, &k.Lokasi, &k.Latitude, &k.Longitude, &k.KunciRahasia, &k.TokenAkses,
+		&k.Aktif, &k.DibuatPada, &k.DiperbaruiPada)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// KioskByToken authenticates a kiosk tablet by its access token
+func KioskByToken(token string) (*models.Kiosk, error) {
+	if token == "" {
+		return nil, sql.ErrNoRows
+	}
+	return scanKiosk(database.DB.QueryRow(
+		"SELECT "+kioskColumns+" FROM kiosk WHERE token_akses = ? AND aktif = TRUE", token,
+	))
+}
+
+// IssueKioskCode returns the signed code for the current time window
+func IssueKioskCode(k *models.Kiosk, now time.Time) KioskCode {
+	period := KioskPeriod()
+	window := now.Unix() / int64(period.Seconds())
+	return KioskCode{
+		Kode:          fmt.Sprintf("%s:%d:%d:%s", kioskCodePrefix, k.ID, window, kioskSignature(k.KunciRahasia, k.ID, window)),
+		BerlakuHingga: time.Unix((window+1)*int64(period.Seconds()), 0).In(time.Local),
+		PeriodeDetik:  int(period.Seconds()),
+	}
+}
+
+// VerifyKioskCode checks a scanned code against its kiosk and the time window.
+// The previous window is still accepted so a code scanned just before it rotates is not rejected.
+func VerifyKioskCode(code string, now time.Time) (*models.Kiosk, error) {
+	parts := strings.Split(strings.TrimSpace(code), ":")
+	if len(parts) != 4 || parts[0] != kioskCodePrefix {
+		return nil, ErrInvalidKioskCode
+	}
+	kioskID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, ErrInvalidKioskCode
+	}
+	window, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidKioskCode
+	}
+
+	current := now.Unix() / int64(KioskPeriod().Seconds())
+	if window != current && window != current-1 {
+		return nil, ErrInvalidKioskCode
+	}
+
+	k, err := scanKiosk(database.DB.QueryRow(
+		"SELECT "+kioskColumns+" FROM kiosk WHERE id = ? AND aktif = TRUE", kioskID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidKioskCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(kioskSignature(k.KunciRahasia, k.ID, window)), []byte(parts[3])) {
+		return nil, ErrInvalidKioskCode
+	}
+	return k, nil
+}
@@ -0,0 +1,152 @@
+package attendance
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+// LatenessTier is a row of tingkat_keterlambatan
+type LatenessTier struct {
+	ID               int     `json:"id"`
+	Nama             string  `json:"nama"`
+	MenitDari        int     `json:"menit_dari"`
+	MenitSampai      *int    `json:"menit_sampai"`
+	SetengahHari     bool    `json:"setengah_hari"`
+	AturanPotonganID *int    `json:"aturan_potongan_id"`
+	NamaPotongan     *string `json:"nama_potongan"`
+	PoinPeringatan   int     `json:"poin_peringatan"`
+	Aktif            bool    `json:"aktif"`
+}
+
+// TierCount is how often an employee fell into one tier within the period
+type TierCount struct {
+	TingkatID        int     `json:"tingkat_id"`
+	Nama             string  `json:"nama"`
+	Jumlah           int     `json:"jumlah"`
+	TotalMenit       int     `json:"total_menit"`
+	SetengahHari     bool    `json:"setengah_hari"`
+	AturanPotonganID *int    `json:"aturan_potongan_id"`
+	NamaPotongan     *string `json:"nama_potongan"`
+	PoinPeringatan   int     `json:"poin_peringatan"` // Points of the tier times jumlah
+}
+
+// LatenessSummary is the monthly lateness of one employee, as consumed by payroll deductions
+// and warning letters
+type LatenessSummary struct {
+	PenggunaID       int         `json:"pengguna_id"`
+	NamaLengkap      string      `json:"nama_lengkap"`
+	Divisi           *string     `json:"divisi"`
+	JumlahTerlambat  int         `json:"jumlah_terlambat"`
+	TotalMenit       int         `json:"total_menit"`
+	HariSetengahHari int         `json:"hari_setengah_hari"`
+	TotalPoin        int         `json:"total_poin"`
+	Tingkat          []TierCount `json:"tingkat"`
+}
+
+// LatenessTiers lists the configured tiers, lowest first
+func LatenessTiers() ([]LatenessTier, error) {
+	rows, err := database.DB.Query(`
+		SELECT t.id, t.nama, t.menit_dari, t.menit_sampai, t.setengah_hari, t.aturan_potongan_id, a.nama,
+		       t.poin_peringatan, t.aktif
+		FROM tingkat_keterlambatan t
+		LEFT JOIN aturan_potongan a ON t.aturan_potongan_id = a.id
+		ORDER BY t.menit_dari ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tiers := []LatenessTier{}
+	for rows.Next() {
+		var t LatenessTier
+		if err := rows.Scan(&t.ID, &t.Nama, &t.MenitDari, &t.MenitSampai, &t.SetengahHari, &t.AturanPotonganID,
+			&t.NamaPotongan, &t.PoinPeringatan, &t.Aktif); err != nil {
+			return nil, err
+		}
+		tiers = append(tiers, t)
+	}
+	return tiers, nil
+}
+
+// MonthlyLateness groups the late days of the month per employee and tier (penggunaID / divisiID, 0 = no filter).
+// Employees without any late day are left out.
+func MonthlyLateness(year, month, penggunaID, divisiID int) ([]LatenessSummary, error) {
+	if month < 1 || month > 12 || year < 2000 {
+		return nil, errors.New("bulan atau tahun tidak valid")
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+
+	query := `
+		SELECT p.id, p.nama_lengkap, d.nama, t.id, t.nama, t.setengah_hari, t.aturan_potongan_id, a.nama,
+		       t.poin_peringatan, COUNT(*), SUM(pr.menit_terlambat)
+		FROM presensi pr
+		JOIN pengguna p ON pr.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		LEFT JOIN tingkat_keterlambatan t ON pr.tingkat_keterlambatan_id = t.id
+		LEFT JOIN aturan_potongan a ON t.aturan_potongan_id = a.id
+		WHERE pr.status = 'terlambat' AND pr.tanggal >= ? AND pr.tanggal < ?
+	`
+	args := []interface{}{start.Format("2006-01-02"), end.Format("2006-01-02")}
+	if penggunaID != 0 {
+		query += " AND p.id = ?"
+		args = append(args, penggunaID)
+	}
+	if divisiID != 0 {
+		query += " AND p.divisi_id = ?"
+		args = append(args, divisiID)
+	}
+	query += `
+		GROUP BY p.id, p.nama_lengkap, d.nama, t.id, t.nama, t.menit_dari, t.setengah_hari, t.aturan_potongan_id, a.nama, t.poin_peringatan
+		ORDER BY p.nama_lengkap ASC, t.menit_dari ASC
+	`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []LatenessSummary{}
+	index := map[int]int{}
+	for rows.Next() {
+		var s LatenessSummary
+		var tingkatID, poin *int
+		var nama *string
+		var setengahHari *bool
+		var tc TierCount
+		if err := rows.Scan(&s.PenggunaID, &s.NamaLengkap, &s.Divisi, &tingkatID, &nama, &setengahHari,
+			&tc.AturanPotonganID, &tc.NamaPotongan, &poin, &tc.Jumlah, &tc.TotalMenit); err != nil {
+			return nil, err
+		}
+		i, ok := index[s.PenggunaID]
+		if !ok {
+			s.Tingkat = []TierCount{}
+			i = len(summaries)
+			index[s.PenggunaID] = i
+			summaries = append(summaries, s)
+		}
+		sum := &summaries[i]
+		sum.JumlahTerlambat += tc.Jumlah
+		sum.TotalMenit += tc.TotalMenit
+
+		// Late days recorded before the tiers existed (or whose tier was deleted) only count in the totals
+		if tingkatID == nil {
+			continue
+		}
+		tc.TingkatID, tc.Nama = *tingkatID, *nama
+		tc.SetengahHari = setengahHari != nil && *setengahHari
+		if poin != nil {
+			tc.PoinPeringatan = *poin * tc.Jumlah
+		}
+		if tc.SetengahHari {
+			sum.HariSetengahHari += tc.Jumlah
+		}
+		sum.TotalPoin += tc.PoinPeringatan
+		sum.Tingkat = append(sum.Tingkat, tc)
+	}
+	return summaries, nil
+}
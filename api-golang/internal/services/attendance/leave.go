@@ -0,0 +1,103 @@
+package attendance
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// Values of pengajuan_cuti.setengah_hari
+const (
+	SetengahHariPagi  = "pagi"
+	SetengahHariSiang = "siang"
+)
+
+// maxLeaveDays bounds the date range of one request so a typo cannot walk years of calendar
+const maxLeaveDays = 366
+
+// LeaveDay is one calendar day of a leave request and how much of it is taken from the balance
+type LeaveDay struct {
+	Tanggal    string  `json:"tanggal"`
+	Hari       string  `json:"hari"`
+	Dihitung   float64 `json:"dihitung"`   // 1, 0.5 or 0
+	Keterangan string  `json:"keterangan"` // hari kerja, setengah hari (pagi), bukan hari kerja, or the holiday name
+}
+
+// LeaveDuration is the working-day breakdown of a leave request
+type LeaveDuration struct {
+	TanggalMulai   string     `json:"tanggal_mulai"`
+	TanggalSelesai string     `json:"tanggal_selesai"`
+	SetengahHari   *string    `json:"setengah_hari"`
+	HariKalender   int        `json:"hari_kalender"`
+	HariLibur      int        `json:"hari_libur"` // Weekends and holidays inside the range
+	TotalHari      float64    `json:"total_hari"` // Days taken from the leave balance
+	Rincian        []LeaveDay `json:"rincian"`
+}
+
+// LeaveDurationFor counts the working days of a leave from tanggalMulai to tanggalSelesai (YYYY-MM-DD),
+// skipping days outside the configured working weekdays and hari_libur dates. setengahHari
+// (pagi / siang, "" = full days) makes a single-day leave count as half a day.
+func LeaveDurationFor(tanggalMulai, tanggalSelesai, setengahHari string, config *models.KonfigurasiPresensi) (*LeaveDuration, error) {
+	start, err := time.ParseInLocation("2006-01-02", tanggalMulai, time.Local)
+	if err != nil {
+		return nil, errors.New("format tanggal mulai tidak valid")
+	}
+	end, err := time.ParseInLocation("2006-01-02", tanggalSelesai, time.Local)
+	if err != nil {
+		return nil, errors.New("format tanggal selesai tidak valid")
+	}
+	if end.Before(start) {
+		return nil, errors.New("tanggal selesai tidak boleh lebih awal dari tanggal mulai")
+	}
+	if int(end.Sub(start).Hours()/24)+1 > maxLeaveDays {
+		return nil, fmt.Errorf("rentang cuti maksimal %d hari", maxLeaveDays)
+	}
+	if setengahHari != "" {
+		if setengahHari != SetengahHariPagi && setengahHari != SetengahHariSiang {
+			return nil, errors.New("setengah_hari harus 'pagi' atau 'siang'")
+		}
+		if !start.Equal(end) {
+			return nil, errors.New("cuti setengah hari hanya untuk satu tanggal")
+		}
+	}
+
+	holidays, err := HolidaysBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return countLeaveDays(start, end, setengahHari, holidays, config)
+}
+
+// countLeaveDays builds the day-by-day breakdown of a validated leave range against the holidays
+// of the range
+func countLeaveDays(start, end time.Time, setengahHari string, holidays map[string]Holiday, config *models.KonfigurasiPresensi) (*LeaveDuration, error) {
+	d := &LeaveDuration{TanggalMulai: start.Format("2006-01-02"), TanggalSelesai: end.Format("2006-01-02"), Rincian: []LeaveDay{}}
+	if setengahHari != "" {
+		d.SetengahHari = &setengahHari
+	}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		ld := LeaveDay{Tanggal: day.Format("2006-01-02"), Hari: namaHari[day.Weekday()]}
+		h, libur := holidays[ld.Tanggal]
+		switch {
+		case libur:
+			ld.Keterangan = h.Nama
+			d.HariLibur++
+		case !IsWorkday(day, config):
+			ld.Keterangan = "bukan hari kerja"
+			d.HariLibur++
+		case setengahHari != "":
+			ld.Dihitung, ld.Keterangan = 0.5, "setengah hari ("+setengahHari+")"
+		default:
+			ld.Dihitung, ld.Keterangan = 1, "hari kerja"
+		}
+		d.HariKalender++
+		d.TotalHari += ld.Dihitung
+		d.Rincian = append(d.Rincian, ld)
+	}
+	if d.TotalHari == 0 {
+		return nil, errors.New("rentang tanggal tidak mencakup hari kerja")
+	}
+	return d, nil
+}
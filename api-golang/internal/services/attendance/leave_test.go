@@ -0,0 +1,88 @@
+package attendance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+func TestLeaveDurationForRejectsInvalidRanges(t *testing.T) {
+	config := &models.KonfigurasiPresensi{HariKerja: "1,2,3,4,5"}
+	tests := []struct {
+		name                     string
+		mulai, selesai, setengah string
+	}{
+		{"bad start", "10-06-2024", "2024-06-10", ""},
+		{"bad end", "2024-06-10", "2024/06/11", ""},
+		{"end before start", "2024-06-11", "2024-06-10", ""},
+		{"longer than a year", "2024-01-01", "2025-01-01", ""},
+		{"unknown half day", "2024-06-10", "2024-06-10", "sore"},
+		{"half day over several days", "2024-06-10", "2024-06-11", SetengahHariPagi},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LeaveDurationFor(tt.mulai, tt.selesai, tt.setengah, config); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestCountLeaveDays(t *testing.T) {
+	config := &models.KonfigurasiPresensi{HariKerja: "1,2,3,4,5"}
+	date := func(s string) time.Time {
+		d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+		return d
+	}
+	holidays := map[string]Holiday{
+		"2024-06-12": {Tanggal: "2024-06-12", Nama: "Libur Nasional", Jenis: LiburNasional},
+	}
+
+	tests := []struct {
+		name           string
+		mulai, selesai string
+		setengah       string
+		total          float64
+		kalender       int
+		libur          int
+		wantErr        bool
+	}{
+		{"working week", "2024-06-03", "2024-06-07", "", 5, 5, 0, false},
+		{"across a weekend", "2024-06-06", "2024-06-10", "", 3, 5, 2, false},
+		{"holiday inside the range", "2024-06-10", "2024-06-14", "", 4, 5, 1, false},
+		{"half day", "2024-06-11", "2024-06-11", SetengahHariSiang, 0.5, 1, 0, false},
+		{"weekend only", "2024-06-08", "2024-06-09", "", 0, 0, 0, true},
+		{"half day on a holiday", "2024-06-12", "2024-06-12", SetengahHariPagi, 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := countLeaveDays(date(tt.mulai), date(tt.selesai), tt.setengah, holidays, config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d.TotalHari != tt.total || d.HariKalender != tt.kalender || d.HariLibur != tt.libur {
+				t.Errorf("got total %.1f, kalender %d, libur %d; want %.1f, %d, %d",
+					d.TotalHari, d.HariKalender, d.HariLibur, tt.total, tt.kalender, tt.libur)
+			}
+			if len(d.Rincian) != tt.kalender {
+				t.Errorf("got %d rincian rows, want %d", len(d.Rincian), tt.kalender)
+			}
+		})
+	}
+
+	d, _ := countLeaveDays(date("2024-06-12"), date("2024-06-12"), "", map[string]Holiday{}, config)
+	if d.Rincian[0].Hari != "Rabu" || d.Rincian[0].Keterangan != "hari kerja" {
+		t.Errorf("unexpected breakdown %+v", d.Rincian[0])
+	}
+	d, _ = countLeaveDays(date("2024-06-11"), date("2024-06-13"), "", holidays, config)
+	if d.Rincian[1].Keterangan != "Libur Nasional" || d.Rincian[1].Dihitung != 0 {
+		t.Errorf("holiday not named in breakdown: %+v", d.Rincian[1])
+	}
+}
@@ -0,0 +1,149 @@
+package attendance
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/storage"
+)
+
+const (
+	maxPhotoBytes        = 5 << 20 // 5 MB
+	defaultRetentionDays = 90
+)
+
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// Photo is a validated selfie taken with a clock-in / clock-out
+type Photo struct {
+	Data        []byte
+	ContentType string
+}
+
+// ReadPhoto reads an uploaded image, enforcing the size limit and sniffing the real content type
+func ReadPhoto(r io.Reader) (*Photo, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPhotoBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("file foto kosong")
+	}
+	if len(data) > maxPhotoBytes {
+		return nil, fmt.Errorf("ukuran foto maksimal %d MB", maxPhotoBytes>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if _, ok := photoExtensions[contentType]; !ok {
+		return nil, errors.New("format foto harus JPEG, PNG, atau WEBP")
+	}
+	return &Photo{Data: data, ContentType: contentType}, nil
+}
+
+// PhotoRequired reports whether clock-in / clock-out must include a selfie (PRESENSI_WAJIB_FOTO=true)
+func PhotoRequired() bool {
+	return os.Getenv("PRESENSI_WAJIB_FOTO") == "true"
+}
+
+// StorePhoto saves the selfie in the configured storage backend and returns its key
+func StorePhoto(userID int, jenis string, t time.Time, p *Photo) (string, error) {
+	key := fmt.Sprintf("presensi/%s/%d-%s-%d%s",
+		t.Format("2006/01/02"), userID, jenis, t.UnixNano(), photoExtensions[p.ContentType])
+	if err := storage.Store.Put(key, bytes.NewReader(p.Data), int64(len(p.Data)), p.ContentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// DeletePhoto removes a stored selfie, logging instead of failing
+func DeletePhoto(key string) {
+	if err := storage.Store.Delete(key); err != nil {
+		log.Printf("[DeletePhoto] Error deleting %s: %v", key, err)
+	}
+}
+
+// PhotoContentType guesses the content type of a stored selfie from its key
+func PhotoContentType(key string) string {
+	for ct, ext := range photoExtensions {
+		if strings.HasSuffix(key, ext) {
+			return ct
+		}
+	}
+	return "application/octet-stream"
+}
+
+func retentionDays() int {
+	days, err := strconv.Atoi(os.Getenv("FOTO_PRESENSI_RETENSI_HARI"))
+	if err != nil || days <= 0 {
+		return defaultRetentionDays
+	}
+	return days
+}
+
+// PurgeExpiredPhotos deletes selfies older than the retention period and clears their references
+func PurgeExpiredPhotos() (int, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, foto_masuk, foto_pulang FROM presensi
+		WHERE tanggal < DATE_SUB(CURDATE(), INTERVAL ? DAY)
+		AND (foto_masuk IS NOT NULL OR foto_pulang IS NOT NULL)
+	`, retentionDays())
+	if err != nil {
+		return 0, err
+	}
+
+	type expired struct {
+		id            int
+		masuk, pulang *string
+	}
+	var list []expired
+	for rows.Next() {
+		var e expired
+		if err := rows.Scan(&e.id, &e.masuk, &e.pulang); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		list = append(list, e)
+	}
+	rows.Close()
+
+	purged := 0
+	for _, e := range list {
+		for _, key := range []*string{e.masuk, e.pulang} {
+			if key != nil {
+				DeletePhoto(*key)
+				purged++
+			}
+		}
+		if _, err := database.DB.Exec("UPDATE presensi SET foto_masuk = NULL, foto_pulang = NULL WHERE id = ?", e.id); err != nil {
+			return purged, err
+		}
+	}
+	return purged, nil
+}
+
+// StartPhotoRetention runs PurgeExpiredPhotos once a day in the background
+func StartPhotoRetention() {
+	go func() {
+		for {
+			purged, err := PurgeExpiredPhotos()
+			if err != nil {
+				log.Printf("[PhotoRetention] Error: %v", err)
+			} else if purged > 0 {
+				log.Printf("[PhotoRetention] Deleted %d expired attendance photos", purged)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
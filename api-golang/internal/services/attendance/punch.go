@@ -0,0 +1,136 @@
+package attendance
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// ErrPunchConflict is returned by WritePunch when the day already has a conflicting value
+var ErrPunchConflict = errors.New("presensi pada tanggal tersebut bertentangan dengan data yang sudah ada")
+
+// PunchRecord is a clock-in / clock-out captured outside the live GPS flow
+// (offline sync, kiosk, fingerprint machine, ...)
+type PunchRecord struct {
+	PenggunaID  int
+	Jenis       string // masuk / pulang
+	Waktu       time.Time
+	Latitude    *float64
+	Longitude   *float64
+	Akurasi     *float64
+	PerangkatID *string
+	Sumber      string // gps, offline, ...
+	ModeKerja   string // kantor / wfh, empty = kantor
+}
+
+// WritePunch stores a punch in the presensi row of its day using the same status rules as ClockIn.
+// Without overwrite an existing masuk / pulang is reported as ErrPunchConflict.
+// The returned Change holds the before/after values so callers can write the audit trail.
+func WritePunch(tx *sql.Tx, p PunchRecord, config *models.KonfigurasiPresensi, overwrite bool) (int, Change, error) {
+	tanggal := p.Waktu.Format("2006-01-02")
+	change := Change{PenggunaID: p.PenggunaID, Tanggal: p.Waktu, Sumber: p.Sumber}
+
+	var presensiID int
+	var masukLama, pulangLama sql.NullTime
+	var statusLama sql.NullString
+	err := tx.QueryRow(`
+		SELECT id, waktu_masuk, waktu_pulang, status
+		FROM presensi WHERE pengguna_id = ? AND tanggal = ? FOR UPDATE
+	`, p.PenggunaID, tanggal).Scan(&presensiID, &masukLama, &pulangLama, &statusLama)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, change, err
+	}
+	exists := err == nil
+	if masukLama.Valid {
+		change.MasukLama = &masukLama.Time
+	}
+	if pulangLama.Valid {
+		change.PulangLama = &pulangLama.Time
+	}
+	if statusLama.Valid {
+		change.StatusLama = &statusLama.String
+	}
+	change.MasukBaru, change.PulangBaru = change.MasukLama, change.PulangLama
+
+	switch p.Jenis {
+	case "masuk":
+		if masukLama.Valid && !overwrite {
+			return 0, change, ErrPunchConflict
+		}
+		if pulangLama.Valid && !pulangLama.Time.After(p.Waktu) {
+			return 0, change, errors.New("waktu masuk harus sebelum waktu pulang")
+		}
+		late, err := LatenessFor(p.Waktu, config)
+		if err != nil {
+			return 0, change, err
+		}
+		mode := p.ModeKerja
+		if mode == "" {
+			mode = ModeKantor
+		}
+		if mode == ModeDinas {
+			// Trip days count as present whatever the time
+			late = Lateness{Status: StatusDinas}
+		}
+		change.MasukBaru = &p.Waktu
+		change.StatusBaru = late.Status
+
+		if !exists {
+			result, err := tx.Exec(`
+				INSERT INTO presensi (pengguna_id, tanggal, waktu_masuk, latitude_masuk, longitude_masuk, akurasi_masuk,
+				                      perangkat_id, status, menit_terlambat, tingkat_keterlambatan_id, sumber, mode_kerja,
+				                      dibuat_pada, diperbarui_pada)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+			`, p.PenggunaID, tanggal, p.Waktu, p.Latitude, p.Longitude, p.Akurasi, p.PerangkatID,
+				late.Status, late.Menit, late.TingkatID, p.Sumber, mode)
+			if err != nil {
+				return 0, change, err
+			}
+			newID, _ := result.LastInsertId()
+			change.PresensiID = int(newID)
+			return change.PresensiID, change, nil
+		}
+
+		_, err = tx.Exec(`
+			UPDATE presensi
+			SET waktu_masuk = ?, latitude_masuk = ?, longitude_masuk = ?, akurasi_masuk = ?,
+			    perangkat_id = COALESCE(?, perangkat_id), status = ?, menit_terlambat = ?, tingkat_keterlambatan_id = ?,
+			    sumber = ?, mode_kerja = ?, diperbarui_pada = NOW()
+			WHERE id = ?
+		`, p.Waktu, p.Latitude, p.Longitude, p.Akurasi, p.PerangkatID, late.Status, late.Menit, late.TingkatID,
+			p.Sumber, mode, presensiID)
+
+	case "pulang":
+		if !masukLama.Valid {
+			return 0, change, errors.New("belum ada presensi masuk pada tanggal tersebut")
+		}
+		if pulangLama.Valid && !overwrite {
+			return 0, change, ErrPunchConflict
+		}
+		if !p.Waktu.After(masukLama.Time) {
+			return 0, change, errors.New("waktu pulang harus setelah waktu masuk")
+		}
+		change.PulangBaru = &p.Waktu
+		change.StatusBaru = statusLama.String
+
+		_, err = tx.Exec(`
+			UPDATE presensi
+			SET waktu_pulang = ?, latitude_pulang = ?, longitude_pulang = ?, akurasi_pulang = ?, diperbarui_pada = NOW()
+			WHERE id = ?
+		`, p.Waktu, p.Latitude, p.Longitude, p.Akurasi, presensiID)
+		if err == nil {
+			err = CloseOpenBreak(tx, presensiID, p.Waktu)
+		}
+
+	default:
+		return 0, change, errors.New("jenis presensi harus 'masuk' atau 'pulang'")
+	}
+	if err != nil {
+		return 0, change, err
+	}
+
+	change.PresensiID = presensiID
+	return presensiID, change, nil
+}
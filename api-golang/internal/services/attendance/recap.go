@@ -0,0 +1,340 @@
+package attendance
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+var namaHari = [...]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+// RecapDay is one calendar day of an employee's monthly timesheet
+type RecapDay struct {
+	Tanggal          string     `json:"tanggal"`
+	Hari             string     `json:"hari"`
+	Status           string     `json:"status"` // hadir, terlambat, tidak_hadir, izin, cuti, dinas, libur, or "" for future days
+	WaktuMasuk       *time.Time `json:"waktu_masuk"`
+	WaktuPulang      *time.Time `json:"waktu_pulang"`
+	MenitTerlambat   int        `json:"menit_terlambat"`
+	TingkatTerlambat *string    `json:"tingkat_terlambat"`
+	MenitPulangCepat int        `json:"menit_pulang_cepat"`
+	MenitIstirahat   int        `json:"menit_istirahat"`
+	IstirahatLebih   int        `json:"istirahat_lebih"` // Break minutes beyond maks_istirahat_menit
+	JamKerja         float64    `json:"jam_kerja"`       // Clocked time less breaks
+	MenitLembur      int        `json:"menit_lembur"`    // Worked minutes beyond the scheduled day; all of them on a day off
+	ModeKerja        *string    `json:"mode_kerja"`      // kantor / wfh on days with presensi
+	JenisCuti        *string    `json:"jenis_cuti"`      // tipe_cuti of an approved leave covering the day
+	TujuanDinas      *string    `json:"tujuan_dinas"`    // Destination of an approved business trip covering the day
+	Libur            bool       `json:"libur"`           // Not a configured working day, or a holiday
+	NamaLibur        *string    `json:"nama_libur"`      // Name of the hari_libur falling on the day
+	Keterangan       *string    `json:"keterangan"`
+}
+
+// RecapTotal sums a monthly timesheet. Counts only cover days up to today.
+type RecapTotal struct {
+	HariKerja             int     `json:"hari_kerja"`
+	Hadir                 int     `json:"hadir"`
+	Terlambat             int     `json:"terlambat"`
+	TidakHadir            int     `json:"tidak_hadir"`
+	Izin                  int     `json:"izin"`
+	Cuti                  int     `json:"cuti"`
+	Dinas                 int     `json:"dinas"` // Also counted in hadir
+	Libur                 int     `json:"libur"`
+	TanpaPresensiPulang   int     `json:"tanpa_presensi_pulang"`
+	HariWFH               int     `json:"hari_wfh"`
+	TotalMenitTerlambat   int     `json:"total_menit_terlambat"`
+	TerlambatSetengahHari int     `json:"terlambat_setengah_hari"` // Late days in a tier counted as half a day
+	TotalMenitPulangCepat int     `json:"total_menit_pulang_cepat"`
+	TotalMenitIstirahat   int     `json:"total_menit_istirahat"`
+	TotalIstirahatLebih   int     `json:"total_istirahat_lebih"`
+	TotalJamKerja         float64 `json:"total_jam_kerja"`
+	TotalMenitLembur      int     `json:"total_menit_lembur"`
+}
+
+// EmployeeRecap is the monthly timesheet of one employee
+type EmployeeRecap struct {
+	PenggunaID  int        `json:"pengguna_id"`
+	NamaLengkap string     `json:"nama_lengkap"`
+	Divisi      *string    `json:"divisi"`
+	Bulan       int        `json:"bulan"`
+	Tahun       int        `json:"tahun"`
+	Hari        []RecapDay `json:"hari"`
+	Total       RecapTotal `json:"total"`
+}
+
+type recapPresensi struct {
+	masuk, pulang *time.Time
+	status        string
+	mode          string
+	menit         int
+	tingkat       *string
+	setengahHari  bool
+	istirahat     int
+	catatan       *string
+}
+
+// recapLeave is a date range of an approved leave (tipe = tipe_cuti) or business trip (tipe = tujuan)
+type recapLeave struct {
+	mulai, selesai string
+	tipe           string
+}
+
+// MonthlyRecap builds the day-by-day timesheet of every active employee matching the filter
+// (penggunaID / divisiID, 0 = no filter) for the given month
+func MonthlyRecap(year, month, penggunaID, divisiID int) ([]EmployeeRecap, error) {
+	if month < 1 || month > 12 || year < 2000 {
+		return nil, errors.New("bulan atau tahun tidak valid")
+	}
+	config, err := ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 1, 0)
+
+	query := `
+		SELECT p.id, p.nama_lengkap, d.nama
+		FROM pengguna p
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE p.peran_id = 4 AND p.aktif = TRUE
+	`
+	args := []interface{}{}
+	if penggunaID != 0 {
+		query += " AND p.id = ?"
+		args = append(args, penggunaID)
+	}
+	if divisiID != 0 {
+		query += " AND p.divisi_id = ?"
+		args = append(args, divisiID)
+	}
+	query += " ORDER BY p.nama_lengkap ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var recaps []EmployeeRecap
+	index := map[int]int{}
+	for rows.Next() {
+		r := EmployeeRecap{Bulan: month, Tahun: year}
+		if err := rows.Scan(&r.PenggunaID, &r.NamaLengkap, &r.Divisi); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[r.PenggunaID] = len(recaps)
+		recaps = append(recaps, r)
+	}
+	rows.Close()
+	if len(recaps) == 0 {
+		return recaps, nil
+	}
+
+	presensi := map[int]map[string]recapPresensi{}
+	rows, err = database.DB.Query(`
+		SELECT pr.pengguna_id, DATE_FORMAT(pr.tanggal, '%Y-%m-%d'), pr.waktu_masuk, pr.waktu_pulang, pr.status, pr.mode_kerja,
+		       pr.menit_terlambat, t.nama, COALESCE(t.setengah_hari, FALSE),
+		       (SELECT COALESCE(SUM(TIMESTAMPDIFF(MINUTE, i.waktu_mulai, COALESCE(i.waktu_selesai, pr.waktu_pulang, i.waktu_mulai))), 0)
+		        FROM istirahat_presensi i WHERE i.presensi_id = pr.id),
+		       pr.catatan
+		FROM presensi pr
+		LEFT JOIN tingkat_keterlambatan t ON pr.tingkat_keterlambatan_id = t.id
+		WHERE pr.tanggal >= ? AND pr.tanggal < ?
+	`, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var uid int
+		var tanggal string
+		var p recapPresensi
+		if err := rows.Scan(&uid, &tanggal, &p.masuk, &p.pulang, &p.status, &p.mode, &p.menit, &p.tingkat, &p.setengahHari, &p.istirahat, &p.catatan); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if _, ok := index[uid]; !ok {
+			continue
+		}
+		if presensi[uid] == nil {
+			presensi[uid] = map[string]recapPresensi{}
+		}
+		presensi[uid][tanggal] = p
+	}
+	rows.Close()
+
+	leaves := map[int][]recapLeave{}
+	rows, err = database.DB.Query(`
+		SELECT pengguna_id, DATE_FORMAT(tanggal_mulai, '%Y-%m-%d'), DATE_FORMAT(tanggal_selesai, '%Y-%m-%d'), tipe_cuti
+		FROM pengajuan_cuti
+		WHERE status = 'disetujui' AND tanggal_mulai < ? AND tanggal_selesai >= ?
+	`, end.Format("2006-01-02"), start.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var uid int
+		var l recapLeave
+		if err := rows.Scan(&uid, &l.mulai, &l.selesai, &l.tipe); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		leaves[uid] = append(leaves[uid], l)
+	}
+	rows.Close()
+
+	trips := map[int][]recapLeave{}
+	rows, err = database.DB.Query(`
+		SELECT pengguna_id, DATE_FORMAT(tanggal_mulai, '%Y-%m-%d'), DATE_FORMAT(tanggal_selesai, '%Y-%m-%d'), tujuan
+		FROM perjalanan_dinas
+		WHERE status = 'disetujui' AND tanggal_mulai < ? AND tanggal_selesai >= ?
+	`, end.Format("2006-01-02"), start.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var uid int
+		var t recapLeave
+		if err := rows.Scan(&uid, &t.mulai, &t.selesai, &t.tipe); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		trips[uid] = append(trips[uid], t)
+	}
+	rows.Close()
+
+	holidays, err := HolidaysBetween(start, end.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for i := range recaps {
+		r := &recaps[i]
+		for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+			tanggal := day.Format("2006-01-02")
+			d := RecapDay{Tanggal: tanggal, Hari: namaHari[day.Weekday()], Libur: !IsWorkday(day, config)}
+			if h, ok := holidays[tanggal]; ok {
+				nama := h.Nama
+				d.Libur, d.NamaLibur = true, &nama
+			}
+			for _, l := range leaves[r.PenggunaID] {
+				if tanggal >= l.mulai && tanggal <= l.selesai {
+					tipe := l.tipe
+					d.JenisCuti = &tipe
+					break
+				}
+			}
+
+			for _, t := range trips[r.PenggunaID] {
+				if tanggal >= t.mulai && tanggal <= t.selesai {
+					tujuan := t.tipe
+					d.TujuanDinas = &tujuan
+					break
+				}
+			}
+
+			p, hasPresensi := presensi[r.PenggunaID][tanggal]
+			switch {
+			case hasPresensi:
+				d.Status = p.status
+				d.WaktuMasuk, d.WaktuPulang, d.Keterangan = p.masuk, p.pulang, p.catatan
+				d.MenitTerlambat, d.TingkatTerlambat = p.menit, p.tingkat
+				d.MenitIstirahat, d.IstirahatLebih = p.istirahat, BreakOverrun(p.istirahat, config)
+				mode := p.mode
+				d.ModeKerja = &mode
+				fillDurations(&d, day, config)
+				if p.setengahHari && tanggal <= today {
+					r.Total.TerlambatSetengahHari++
+				}
+			case d.JenisCuti != nil && !d.Libur:
+				d.Status = leaveStatus(*d.JenisCuti)
+			case d.TujuanDinas != nil && !d.Libur:
+				d.Status = StatusDinas
+			case d.Libur:
+				d.Status = StatusLibur
+			case tanggal <= today:
+				d.Status = "tidak_hadir"
+			}
+
+			if tanggal <= today {
+				addToTotal(&r.Total, d, tanggal < today)
+			}
+			r.Hari = append(r.Hari, d)
+		}
+		r.Total.TotalJamKerja = math.Round(r.Total.TotalJamKerja*100) / 100
+	}
+	return recaps, nil
+}
+
+// leaveStatus maps pengajuan_cuti.tipe_cuti to the presensi status used for the day: permission
+// and sick leave are izin, every other jenis_cuti (annual, maternity, marriage, ...) is cuti
+func leaveStatus(tipe string) string {
+	if tipe == "izin" || tipe == "sakit" {
+		return "izin"
+	}
+	return "cuti"
+}
+
+// fillDurations derives early-leave minutes, worked hours net of breaks and overtime; late minutes
+// come from presensi itself
+func fillDurations(d *RecapDay, day time.Time, config *models.KonfigurasiPresensi) {
+	if d.WaktuPulang != nil {
+		if early := EndMinimum(day, config).Sub(*d.WaktuPulang); early > 0 {
+			d.MenitPulangCepat = int(early.Minutes())
+		}
+		if d.WaktuMasuk != nil && d.WaktuPulang.After(*d.WaktuMasuk) {
+			worked := d.WaktuPulang.Sub(*d.WaktuMasuk) - time.Duration(d.MenitIstirahat)*time.Minute
+			if worked < 0 {
+				worked = 0
+			}
+			d.JamKerja = math.Round(worked.Hours()*100) / 100
+			d.MenitLembur = int(worked.Minutes())
+			if !d.Libur {
+				d.MenitLembur -= ScheduledWorkMinutes(day, config)
+			}
+			if d.MenitLembur < 0 {
+				d.MenitLembur = 0
+			}
+		}
+	}
+}
+
+// addToTotal counts a day; a missing clock-out only counts once the day is over
+func addToTotal(t *RecapTotal, d RecapDay, dayOver bool) {
+	if !d.Libur {
+		t.HariKerja++
+	}
+	switch d.Status {
+	case "hadir":
+		t.Hadir++
+	case "terlambat":
+		t.Hadir++
+		t.Terlambat++
+	case "tidak_hadir":
+		t.TidakHadir++
+	case "izin":
+		t.Izin++
+	case "cuti":
+		t.Cuti++
+	case StatusDinas:
+		t.Hadir++
+		t.Dinas++
+	case StatusLibur:
+		t.Libur++
+	}
+	if (d.Status == "hadir" || d.Status == "terlambat") && d.WaktuMasuk != nil && d.WaktuPulang == nil && dayOver {
+		t.TanpaPresensiPulang++
+	}
+	if d.ModeKerja != nil && *d.ModeKerja == ModeWFH {
+		t.HariWFH++
+	}
+	t.TotalMenitTerlambat += d.MenitTerlambat
+	t.TotalMenitIstirahat += d.MenitIstirahat
+	t.TotalIstirahatLebih += d.IstirahatLebih
+	t.TotalMenitLembur += d.MenitLembur
+	t.TotalMenitPulangCepat += d.MenitPulangCepat
+	t.TotalJamKerja += d.JamKerja
+}
@@ -0,0 +1,87 @@
+package attendance
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// Values of presensi.mode_kerja
+const (
+	ModeKantor = "kantor"
+	ModeWFH    = "wfh"
+	ModeDinas  = "dinas"
+)
+
+// RemoteApproval is an approved pengajuan_wfh covering a day
+type RemoteApproval struct {
+	ID          int
+	Latitude    *float64 // Registered remote location
+	Longitude   *float64
+	TanpaRadius bool // HR approved it without a location, so any position is accepted
+}
+
+// ApprovedRemote returns the approved WFH request covering the day, or nil when there is none
+func ApprovedRemote(penggunaID int, tanggal time.Time) (*RemoteApproval, error) {
+	var r RemoteApproval
+	err := database.DB.QueryRow(`
+		SELECT id, latitude, longitude, tanpa_radius FROM pengajuan_wfh
+		WHERE pengguna_id = ? AND status = 'disetujui' AND tanggal_mulai <= ? AND tanggal_selesai >= ?
+		ORDER BY id DESC LIMIT 1
+	`, penggunaID, tanggal.Format("2006-01-02"), tanggal.Format("2006-01-02")).Scan(&r.ID, &r.Latitude, &r.Longitude, &r.TanpaRadius)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// WorkModeFor checks a GPS punch against the office radius and returns the work mode of the punch.
+// Outside the office it falls back to an approved business trip for the day, checked against the
+// destination region, then to an approved WFH request, checked against the registered remote
+// location unless HR approved it without one.
+func WorkModeFor(penggunaID int, latitude, longitude float64, t time.Time, config *models.KonfigurasiPresensi) (string, error) {
+	distance := DistanceMeters(latitude, longitude, config.LatitudeKantor, config.LongitudeKantor)
+	if distance <= float64(config.RadiusMeter) {
+		return ModeKantor, nil
+	}
+
+	trip, err := ApprovedTrip(penggunaID, t)
+	if err != nil {
+		return "", err
+	}
+	if trip != nil {
+		if trip.Latitude != nil && trip.Longitude != nil {
+			distance = DistanceMeters(latitude, longitude, *trip.Latitude, *trip.Longitude)
+			if distance > float64(trip.RadiusKm)*1000 {
+				return "", fmt.Errorf("anda berada di luar wilayah tujuan dinas %s (%d km). jarak anda: %.2f km", trip.Tujuan, trip.RadiusKm, distance/1000)
+			}
+		}
+		return ModeDinas, nil
+	}
+
+	remote, err := ApprovedRemote(penggunaID, t)
+	if err != nil {
+		return "", err
+	}
+	if remote == nil {
+		return "", fmt.Errorf("anda berada di luar radius kantor (%d meter). jarak anda: %.2f meter", config.RadiusMeter, distance)
+	}
+	if remote.Latitude == nil || remote.Longitude == nil {
+		if !remote.TanpaRadius {
+			return "", errors.New("pengajuan WFH yang disetujui tidak memiliki lokasi kerja remote")
+		}
+	} else {
+		distance = DistanceMeters(latitude, longitude, *remote.Latitude, *remote.Longitude)
+		if distance > float64(config.RadiusMeter) {
+			return "", fmt.Errorf("anda berada di luar radius lokasi WFH yang disetujui (%d meter). jarak anda: %.2f meter", config.RadiusMeter, distance)
+		}
+	}
+	return ModeWFH, nil
+}
@@ -0,0 +1,138 @@
+package attendance
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// ActiveConfig returns the currently active konfigurasi_presensi row
+func ActiveConfig() (*models.KonfigurasiPresensi, error) {
+	query := `
+		SELECT id, jam_masuk_maksimal, jam_pulang_minimal, 
+		       latitude_kantor, longitude_kantor, radius_meter, hari_kerja, toleransi_terlambat_menit, maks_istirahat_menit
+		FROM konfigurasi_presensi 
+		WHERE aktif = TRUE 
+		ORDER BY id DESC LIMIT 1
+	`
+	var config models.KonfigurasiPresensi
+	err := database.DB.QueryRow(query).Scan(
+		&config.ID, &config.JamMasukMaksimal, &config.JamPulangMinimal,
+		&config.LatitudeKantor, &config.LongitudeKantor, &config.RadiusMeter, &config.HariKerja,
+		&config.ToleransiMenit, &config.MaksIstirahatMenit,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("konfigurasi presensi belum diatur")
+		}
+		return nil, err
+	}
+	return &config, nil
+}
+
+// DistanceMeters uses the Haversine formula to calculate distance between two points in meters
+func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const R = 6371000 // Earth radius in meters
+
+	dLat := (lat2 - lat1) * (math.Pi / 180.0)
+	dLon := (lon2 - lon1) * (math.Pi / 180.0)
+
+	lat1Rad := lat1 * (math.Pi / 180.0)
+	lat2Rad := lat2 * (math.Pi / 180.0)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(dLon/2)*math.Sin(dLon/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return R * c
+}
+
+// clockOn places a "HH:MM:SS" config value on the calendar day of t
+func clockOn(t time.Time, hhmmss string) time.Time {
+	parsed, _ := time.Parse("15:04:05", hhmmss)
+	return time.Date(t.Year(), t.Month(), t.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, t.Location())
+}
+
+// ParseClock combines a date with an "HH:MM" (or "HH:MM:SS") string in local time
+func ParseClock(date time.Time, value string) (time.Time, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		t, err = time.Parse("15:04:05", value)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+}
+
+// Lateness is the outcome of a clock-in against the schedule
+type Lateness struct {
+	Status    string // hadir / terlambat
+	Menit     int    // Minutes after jam_masuk_maksimal, 0 when on time or within the grace period
+	TingkatID *int   // Matching tingkat_keterlambatan, if any
+}
+
+// LatenessFor determines status, late minutes and penalty tier for a clock-in time.
+// A clock-in within the grace period is on time; beyond it the minutes count from the deadline itself.
+// Work on a day off (weekend or hari_libur) is never late.
+func LatenessFor(masuk time.Time, config *models.KonfigurasiPresensi) (Lateness, error) {
+	workingDay, err := IsWorkingDay(masuk, config)
+	if err != nil {
+		return Lateness{}, err
+	}
+	if !workingDay {
+		return Lateness{Status: "hadir"}, nil
+	}
+
+	deadline := StartDeadline(masuk, config)
+	late := masuk.Sub(deadline)
+	if late <= time.Duration(config.ToleransiMenit)*time.Minute || late <= 0 {
+		return Lateness{Status: "hadir"}, nil
+	}
+
+	l := Lateness{Status: "terlambat", Menit: int(math.Ceil(late.Minutes()))}
+	var tierID int
+	err = database.DB.QueryRow(`
+		SELECT id FROM tingkat_keterlambatan
+		WHERE aktif = TRUE AND menit_dari <= ? AND (menit_sampai IS NULL OR menit_sampai >= ?)
+		ORDER BY menit_dari DESC LIMIT 1
+	`, l.Menit, l.Menit).Scan(&tierID)
+	if err != nil && err != sql.ErrNoRows {
+		return l, err
+	}
+	if err == nil {
+		l.TingkatID = &tierID
+	}
+	return l, nil
+}
+
+// IsWorkday reports whether date falls on one of the configured working weekdays
+func IsWorkday(date time.Time, config *models.KonfigurasiPresensi) bool {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		weekday = 7 // ISO: Minggu = 7
+	}
+	for _, d := range strings.Split(config.HariKerja, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(d)); err == nil && n == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// StartDeadline returns the latest on-time clock-in for the day of t
+func StartDeadline(t time.Time, config *models.KonfigurasiPresensi) time.Time {
+	return clockOn(t, config.JamMasukMaksimal)
+}
+
+// EndMinimum returns the earliest regular clock-out for the day of t
+func EndMinimum(t time.Time, config *models.KonfigurasiPresensi) time.Time {
+	return clockOn(t, config.JamPulangMinimal)
+}
@@ -0,0 +1,37 @@
+package attendance
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+// StatusDinas marks presensi of a day covered by an approved business trip; it counts as present
+const StatusDinas = "dinas"
+
+// TripApproval is an approved perjalanan_dinas covering a day
+type TripApproval struct {
+	ID        int
+	Tujuan    string
+	Latitude  *float64 // Centre of the destination region; nil means clock-in is allowed anywhere
+	Longitude *float64
+	RadiusKm  int
+}
+
+// ApprovedTrip returns the approved business trip covering the day, or nil when there is none
+func ApprovedTrip(penggunaID int, tanggal time.Time) (*TripApproval, error) {
+	var t TripApproval
+	err := database.DB.QueryRow(`
+		SELECT id, tujuan, latitude_tujuan, longitude_tujuan, radius_km FROM perjalanan_dinas
+		WHERE pengguna_id = ? AND status = 'disetujui' AND tanggal_mulai <= ? AND tanggal_selesai >= ?
+		ORDER BY id DESC LIMIT 1
+	`, penggunaID, tanggal.Format("2006-01-02"), tanggal.Format("2006-01-02")).Scan(&t.ID, &t.Tujuan, &t.Latitude, &t.Longitude, &t.RadiusKm)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -4,11 +4,11 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"math"
 	"time"
 
 	"github.com/hris-system/api-golang/internal/database"
 	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
 )
 
 type AttendanceService struct{}
@@ -17,44 +17,8 @@ func NewAttendanceService() *AttendanceService {
 	return &AttendanceService{}
 }
 
-// Haversine formula to calculate distance between two points in meters
-func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371000 // Earth radius in meters
-
-	dLat := (lat2 - lat1) * (math.Pi / 180.0)
-	dLon := (lon2 - lon1) * (math.Pi / 180.0)
-
-	lat1Rad := lat1 * (math.Pi / 180.0)
-	lat2Rad := lat2 * (math.Pi / 180.0)
-
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return R * c
-}
-
 func (s *AttendanceService) GetActiveConfig() (*models.KonfigurasiPresensi, error) {
-	query := `
-		SELECT id, jam_masuk_maksimal, jam_pulang_minimal, 
-		       latitude_kantor, longitude_kantor, radius_meter
-		FROM konfigurasi_presensi 
-		WHERE aktif = TRUE 
-		ORDER BY id DESC LIMIT 1
-	`
-	var config models.KonfigurasiPresensi
-	err := database.DB.QueryRow(query).Scan(
-		&config.ID, &config.JamMasukMaksimal, &config.JamPulangMinimal,
-		&config.LatitudeKantor, &config.LongitudeKantor, &config.RadiusMeter,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("konfigurasi presensi belum diatur")
-		}
-		return nil, err
-	}
-	return &config, nil
+	return attendance.ActiveConfig()
 }
 
 func (s *AttendanceService) GetTodayStatus(userID int) (*models.Presensi, error) {
@@ -86,7 +50,54 @@ func (s *AttendanceService) GetTodayStatus(userID int) (*models.Presensi, error)
 	return &presensi, nil
 }
 
-func (s *AttendanceService) ClockIn(userID int, lat, long float64) error {
+// PunchInput is what the mobile app sends with a clock-in / clock-out
+type PunchInput struct {
+	Latitude      float64
+	Longitude     float64
+	Akurasi       *float64 // GPS accuracy in meters
+	PerangkatID   string   // Stable device identifier
+	InfoPerangkat string   // Model / OS / app version, free text
+	MockLokasi    bool     // Client-side mock location detection
+	Foto          *attendance.Photo
+}
+
+func (in PunchInput) punch(presensiID, userID int, jenis string, waktu time.Time) attendance.Punch {
+	return attendance.Punch{
+		PresensiID:  presensiID,
+		PenggunaID:  userID,
+		Jenis:       jenis,
+		Waktu:       waktu,
+		Latitude:    in.Latitude,
+		Longitude:   in.Longitude,
+		Akurasi:     in.Akurasi,
+		PerangkatID: in.PerangkatID,
+		MockLokasi:  in.MockLokasi,
+	}
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// storePhoto uploads the selfie if one was sent; nil key means no photo
+func (s *AttendanceService) storePhoto(userID int, jenis string, t time.Time, foto *attendance.Photo) (*string, error) {
+	if foto == nil {
+		if attendance.PhotoRequired() {
+			return nil, errors.New("foto selfie wajib dilampirkan")
+		}
+		return nil, nil
+	}
+	key, err := attendance.StorePhoto(userID, jenis, t, foto)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menyimpan foto: %w", err)
+	}
+	return &key, nil
+}
+
+func (s *AttendanceService) ClockIn(userID int, in PunchInput) error {
 	// 1. Check if already clocked in
 	today, err := s.GetTodayStatus(userID)
 	if err != nil {
@@ -96,41 +107,58 @@ func (s *AttendanceService) ClockIn(userID int, lat, long float64) error {
 		return errors.New("anda sudah melakukan presensi masuk hari ini")
 	}
 
-	// 2. Get Config & Validate Location
+	// 2. Get Config & Validate Location (office radius, or the approved WFH location)
 	config, err := s.GetActiveConfig()
 	if err != nil {
 		return err
 	}
 
-	distance := calculateDistance(lat, long, config.LatitudeKantor, config.LongitudeKantor)
-	if distance > float64(config.RadiusMeter) {
-		return fmt.Errorf("anda berada di luar radius kantor (%d meter). jarak anda: %.2f meter", config.RadiusMeter, distance)
+	now := time.Now()
+	mode, err := attendance.WorkModeFor(userID, in.Latitude, in.Longitude, now, config)
+	if err != nil {
+		return err
 	}
 
 	// 3. Determine Status (Hadir / Terlambat)
-	now := time.Now()
-	status := "Hadir"
-
-	// Parse JamMasukMaksimal (string "HH:MM:SS") to today's time
-	layout := "15:04:05"
-	maxTime, _ := time.Parse(layout, config.JamMasukMaksimal)
-	// Create Date components from now
-	deadline := time.Date(now.Year(), now.Month(), now.Day(), maxTime.Hour(), maxTime.Minute(), maxTime.Second(), 0, now.Location())
+	late, err := attendance.LatenessFor(now, config)
+	if err != nil {
+		return err
+	}
+	if mode == attendance.ModeDinas {
+		// Trip days count as present whatever the time
+		late = attendance.Lateness{Status: attendance.StatusDinas}
+	}
 
-	if now.After(deadline) {
-		status = "Terlambat"
+	// 4. Selfie
+	fotoKey, err := s.storePhoto(userID, "masuk", now, in.Foto)
+	if err != nil {
+		return err
 	}
 
-	// 4. Insert
+	// 5. Insert
 	query := `
-		INSERT INTO presensi (pengguna_id, tanggal, waktu_masuk, latitude_masuk, longitude_masuk, status, dibuat_pada, diperbarui_pada)
-		VALUES (?, DATE(NOW()), NOW(), ?, ?, ?, NOW(), NOW())
+		INSERT INTO presensi (pengguna_id, tanggal, waktu_masuk, latitude_masuk, longitude_masuk, akurasi_masuk,
+		                      perangkat_id, info_perangkat, foto_masuk, status, menit_terlambat, tingkat_keterlambatan_id,
+		                      mode_kerja, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
-	_, err = database.DB.Exec(query, userID, lat, long, status)
-	return err
+	result, err := database.DB.Exec(query, userID, now.Format("2006-01-02"), now, in.Latitude, in.Longitude, in.Akurasi,
+		nullIfEmpty(in.PerangkatID), nullIfEmpty(in.InfoPerangkat), fotoKey, late.Status, late.Menit, late.TingkatID, mode)
+	if err != nil {
+		if fotoKey != nil {
+			attendance.DeletePhoto(*fotoKey)
+		}
+		return err
+	}
+
+	// 6. Spoofing heuristics, flagged for HR review without blocking the punch
+	presensiID, _ := result.LastInsertId()
+	attendance.PublishPunch(int(presensiID), "masuk")
+	attendance.FlagPunch(in.punch(int(presensiID), userID, "masuk", now))
+	return nil
 }
 
-func (s *AttendanceService) ClockOut(userID int, lat, long float64) error {
+func (s *AttendanceService) ClockOut(userID int, in PunchInput) error {
 	// 1. Check if clocked in
 	today, err := s.GetTodayStatus(userID)
 	if err != nil {
@@ -149,19 +177,48 @@ func (s *AttendanceService) ClockOut(userID int, lat, long float64) error {
 		return err
 	}
 
-	distance := calculateDistance(lat, long, config.LatitudeKantor, config.LongitudeKantor)
-	if distance > float64(config.RadiusMeter) {
-		return fmt.Errorf("anda berada di luar radius kantor (%d meter). jarak anda: %.2f meter", config.RadiusMeter, distance)
+	now := time.Now()
+	if _, err := attendance.WorkModeFor(userID, in.Latitude, in.Longitude, now, config); err != nil {
+		return err
 	}
 
-	// 3. Update
-	query := `
-		UPDATE presensi 
-		SET waktu_pulang = NOW(), latitude_pulang = ?, longitude_pulang = ?, diperbarui_pada = NOW()
-		WHERE id = ?
-	`
-	_, err = database.DB.Exec(query, lat, long, today.ID)
-	return err
+	// 3. Selfie
+	fotoKey, err := s.storePhoto(userID, "pulang", now, in.Foto)
+	if err != nil {
+		return err
+	}
+
+	// 4. Update, closing a break left open in the same transaction
+	err = func() error {
+		tx, err := database.DB.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		query := `
+			UPDATE presensi 
+			SET waktu_pulang = ?, latitude_pulang = ?, longitude_pulang = ?, akurasi_pulang = ?, foto_pulang = ?, diperbarui_pada = NOW()
+			WHERE id = ?
+		`
+		if _, err := tx.Exec(query, now, in.Latitude, in.Longitude, in.Akurasi, fotoKey, today.ID); err != nil {
+			return err
+		}
+		if err := attendance.CloseOpenBreak(tx, today.ID, now); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		if fotoKey != nil {
+			attendance.DeletePhoto(*fotoKey)
+		}
+		return err
+	}
+
+	attendance.PublishPunch(today.ID, "pulang")
+	attendance.FlagPunch(in.punch(today.ID, userID, "pulang", now))
+	return nil
 }
 
 func (s *AttendanceService) GetAttendanceHistory(userID int, limit int) ([]models.Presensi, error) {
@@ -196,3 +253,42 @@ func (s *AttendanceService) GetAttendanceHistory(userID int, limit int) ([]model
 	}
 	return history, nil
 }
+
+// StartBreak records a break-out punch on today's presensi
+func (s *AttendanceService) StartBreak(userID int) (*attendance.BreakStatus, error) {
+	config, err := s.GetActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	return attendance.StartBreak(userID, time.Now(), config)
+}
+
+// EndBreak records a break-in punch, closing today's open break
+func (s *AttendanceService) EndBreak(userID int) (*attendance.BreakStatus, error) {
+	config, err := s.GetActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	return attendance.EndBreak(userID, time.Now(), config)
+}
+
+// GetTodayBreaks returns today's breaks, or nil before clock-in
+func (s *AttendanceService) GetTodayBreaks(userID int) (*attendance.BreakStatus, error) {
+	config, err := s.GetActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	return attendance.TodayBreaks(userID, config)
+}
+
+// GetMonthlyRecap returns the day-by-day timesheet of the user for a month
+func (s *AttendanceService) GetMonthlyRecap(userID, year, month int) (*attendance.EmployeeRecap, error) {
+	recaps, err := attendance.MonthlyRecap(year, month, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(recaps) == 0 {
+		return nil, errors.New("data karyawan tidak ditemukan")
+	}
+	return &recaps[0], nil
+}
@@ -0,0 +1,138 @@
+package employee
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+type CorrectionService struct{}
+
+func NewCorrectionService() *CorrectionService {
+	return &CorrectionService{}
+}
+
+type CorrectionRequestInput struct {
+	Tanggal     string  `json:"tanggal" binding:"required"` // YYYY-MM-DD
+	WaktuMasuk  *string `json:"waktu_masuk"`                // HH:MM
+	WaktuPulang *string `json:"waktu_pulang"`               // HH:MM
+	Alasan      string  `json:"alasan" binding:"required"`
+	Bukti       *string `json:"bukti"` // Optional link / keterangan bukti
+}
+
+// RequestCorrection submits a correction of a past presensi record for HR approval
+func (s *CorrectionService) RequestCorrection(userID int, req CorrectionRequestInput) error {
+	tanggal, err := time.ParseInLocation("2006-01-02", req.Tanggal, time.Local)
+	if err != nil {
+		return errors.New("format tanggal tidak valid")
+	}
+	if tanggal.After(time.Now()) {
+		return errors.New("tidak dapat mengajukan koreksi untuk tanggal yang akan datang")
+	}
+	if req.WaktuMasuk == nil && req.WaktuPulang == nil {
+		return errors.New("waktu masuk atau waktu pulang harus diisi")
+	}
+
+	// Existing record (if any) to validate the proposed times against
+	var presensiID *int
+	var masukLama, pulangLama sql.NullTime
+	var id int
+	err = database.DB.QueryRow(
+		"SELECT id, waktu_masuk, waktu_pulang FROM presensi WHERE pengguna_id = ? AND tanggal = ?",
+		userID, req.Tanggal,
+	).Scan(&id, &masukLama, &pulangLama)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		presensiID = &id
+	}
+
+	var masuk, pulang *time.Time
+	if req.WaktuMasuk != nil {
+		t, err := attendance.ParseClock(tanggal, *req.WaktuMasuk)
+		if err != nil {
+			return errors.New("format waktu masuk tidak valid")
+		}
+		masuk = &t
+	}
+	if req.WaktuPulang != nil {
+		t, err := attendance.ParseClock(tanggal, *req.WaktuPulang)
+		if err != nil {
+			return errors.New("format waktu pulang tidak valid")
+		}
+		pulang = &t
+	}
+
+	// Compare against the effective values after correction
+	effMasuk, effPulang := masuk, pulang
+	if effMasuk == nil && masukLama.Valid {
+		effMasuk = &masukLama.Time
+	}
+	if effPulang == nil && pulangLama.Valid {
+		effPulang = &pulangLama.Time
+	}
+	if effMasuk == nil {
+		return errors.New("belum ada presensi masuk pada tanggal tersebut, waktu masuk harus diisi")
+	}
+	if effPulang != nil && !effPulang.After(*effMasuk) {
+		return errors.New("waktu pulang harus setelah waktu masuk")
+	}
+	if effPulang != nil && effPulang.After(time.Now()) {
+		return errors.New("waktu pulang tidak boleh melebihi waktu sekarang")
+	}
+
+	// Only one open correction per day
+	var pending int
+	err = database.DB.QueryRow(
+		"SELECT COUNT(*) FROM koreksi_presensi WHERE pengguna_id = ? AND tanggal = ? AND status = 'menunggu'",
+		userID, req.Tanggal,
+	).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return errors.New("masih ada pengajuan koreksi yang menunggu untuk tanggal tersebut")
+	}
+
+	query := `
+		INSERT INTO koreksi_presensi
+		(pengguna_id, presensi_id, tanggal, waktu_masuk_usulan, waktu_pulang_usulan, alasan, bukti, status, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'menunggu', NOW(), NOW())
+	`
+	_, err = database.DB.Exec(query, userID, presensiID, req.Tanggal, masuk, pulang, req.Alasan, req.Bukti)
+	return err
+}
+
+// GetCorrectionHistory lists the user's correction requests, newest first
+func (s *CorrectionService) GetCorrectionHistory(userID int) ([]models.KoreksiPresensi, error) {
+	query := `
+		SELECT id, pengguna_id, presensi_id, tanggal, waktu_masuk_usulan, waktu_pulang_usulan,
+		       alasan, bukti, status, tanggal_persetujuan, catatan_persetujuan, dibuat_pada
+		FROM koreksi_presensi
+		WHERE pengguna_id = ?
+		ORDER BY id DESC
+	`
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.KoreksiPresensi
+	for rows.Next() {
+		var k models.KoreksiPresensi
+		if err := rows.Scan(
+			&k.ID, &k.PenggunaID, &k.PresensiID, &k.Tanggal, &k.WaktuMasukUsulan, &k.WaktuPulangUsulan,
+			&k.Alasan, &k.Bukti, &k.Status, &k.TanggalPersetujuan, &k.CatatanPersetujuan, &k.DibuatPada,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, k)
+	}
+	return history, nil
+}
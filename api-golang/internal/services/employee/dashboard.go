@@ -17,7 +17,7 @@ type DashboardStats struct {
 	AttendanceStatus string     `json:"attendance_status"` // "Hadir", "Belum Absen", "Izin", "Cuti"
 	CheckInTime      *string    `json:"check_in_time"`
 	CheckOutTime     *string    `json:"check_out_time"`
-	LeaveBalance     int        `json:"leave_balance"`
+	LeaveBalance     float64    `json:"leave_balance"`
 	LastSalaryAmount float64    `json:"last_salary_amount"`
 	LastSalaryPeriod *string    `json:"last_salary_period"` // "Januari 2024"
 }
@@ -62,7 +62,7 @@ func (s *EmployeeService) GetDashboardStats(userID int) (*DashboardStats, error)
 		FROM saldo_cuti 
 		WHERE pengguna_id = ? AND tahun = ?
 	`
-	var balance int
+	var balance float64
 	err = database.DB.QueryRow(queryLeave, userID, currentYear).Scan(&balance)
 	if err == nil {
 		stats.LeaveBalance = balance
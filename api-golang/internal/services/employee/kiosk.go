@@ -0,0 +1,58 @@
+package employee
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+// KioskPunch records a clock-in / clock-out by scanning the QR code shown on a site kiosk.
+// The signed, time-limited code replaces the GPS radius check; the punch is stored
+// with the kiosk's coordinates and sumber "kiosk".
+func (s *AttendanceService) KioskPunch(userID int, jenis string, kode string, in PunchInput) error {
+	if jenis != "masuk" && jenis != "pulang" {
+		return errors.New("jenis presensi harus 'masuk' atau 'pulang'")
+	}
+
+	now := time.Now()
+	kiosk, err := attendance.VerifyKioskCode(kode, now)
+	if err != nil {
+		return err
+	}
+
+	config, err := s.GetActiveConfig()
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	presensiID, _, err := attendance.WritePunch(tx, attendance.PunchRecord{
+		PenggunaID:  userID,
+		Jenis:       jenis,
+		Waktu:       now,
+		Latitude:    &kiosk.Latitude,
+		Longitude:   &kiosk.Longitude,
+		PerangkatID: nullIfEmpty(in.PerangkatID),
+		Sumber:      "kiosk",
+	}, config, false)
+	if err == attendance.ErrPunchConflict {
+		return errors.New("anda sudah melakukan presensi " + jenis + " hari ini")
+	}
+	if err != nil {
+		return err
+	}
+
+	// GPS spoofing heuristics are not run: every kiosk punch shares the kiosk's fixed coordinates
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	attendance.PublishPunch(presensiID, jenis)
+	return nil
+}
@@ -3,10 +3,13 @@ package employee
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/hris-system/api-golang/internal/database"
 	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+	"github.com/hris-system/api-golang/internal/services/leave"
 )
 
 type LeaveService struct{}
@@ -16,84 +19,169 @@ func NewLeaveService() *LeaveService {
 }
 
 func (s *LeaveService) GetBalance(userID int, year int) (*models.SaldoCuti, error) {
+	// Balances are generated from konfigurasi_cuti; create the row if the accrual job has not yet
+	if err := leave.EnsureBalance(userID, year); err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT id, pengguna_id, tahun, total_hari, hari_terpakai, sisa_hari, diperbarui_pada
+		SELECT id, pengguna_id, tahun, total_hari, hari_terpakai, sisa_hari,
+		       hari_dibawa, dibawa_terpakai, hari_hangus, dibawa_kedaluwarsa, hari_penyesuaian, diperbarui_pada
 		FROM saldo_cuti
 		WHERE pengguna_id = ? AND tahun = ?
 	`
 	var saldo models.SaldoCuti
 	err := database.DB.QueryRow(query, userID, year).Scan(
 		&saldo.ID, &saldo.PenggunaID, &saldo.Tahun, &saldo.TotalHari,
-		&saldo.HariTerpakai, &saldo.SisaHari, &saldo.DiperbaruiPada,
+		&saldo.HariTerpakai, &saldo.SisaHari, &saldo.HariDibawa, &saldo.DibawaTerpakai,
+		&saldo.HariHangus, &saldo.DibawaKedaluwarsa, &saldo.HariPenyesuaian, &saldo.DiperbaruiPada,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			// If no record, return default/empty balance logic or error
-			// For now, let's return a default "0" balance or create one if policy dictates
-			return &models.SaldoCuti{
-				PenggunaID:   userID,
-				Tahun:        year,
-				TotalHari:    12, // Default
-				HariTerpakai: 0,
-				SisaHari:     12,
-			}, nil
+			// Not an active employee: no leave entitlement
+			return &models.SaldoCuti{PenggunaID: userID, Tahun: year}, nil
 		}
 		return nil, err
 	}
+	saldo.SisaDibawa = leave.CarriedLeft(&saldo, time.Now())
 	return &saldo, nil
 }
 
+// GetLedger lists the accruals, usage, adjustments, carry-over and expiry behind the user's balance
+func (s *LeaveService) GetLedger(userID int, year int) ([]models.MutasiCuti, error) {
+	return leave.Ledger(userID, year)
+}
+
 type LeaveRequestInput struct {
-	TipeCuti       string `json:"tipe_cuti" binding:"required"` // cuti, izin, sakit
+	TipeCuti       string `json:"tipe_cuti" binding:"required"` // jenis_cuti.kode
 	TanggalMulai   string `json:"tanggal_mulai" binding:"required"`
 	TanggalSelesai string `json:"tanggal_selesai" binding:"required"`
+	SetengahHari   string `json:"setengah_hari"` // pagi / siang for a half-day leave on a single date
 	Alasan         string `json:"alasan" binding:"required"`
+	// Supporting documents (doctor's note, ...) uploaded as multipart "lampiran" files
+	Lampiran []*leave.Attachment `json:"-"`
 }
 
-func (s *LeaveService) RequestLeave(userID int, req LeaveRequestInput) error {
-	// 1. Parse dates
-	layout := "2006-01-02"
-	start, err := time.Parse(layout, req.TanggalMulai)
+// GetLeaveTypes lists the active leave types open to the user's gender
+func (s *LeaveService) GetLeaveTypes(userID int) ([]models.JenisCuti, error) {
+	types, err := leave.Types(true)
 	if err != nil {
-		return errors.New("format tanggal mulai tidak valid")
+		return nil, err
 	}
-	end, err := time.Parse(layout, req.TanggalSelesai)
-	if err != nil {
-		return errors.New("format tanggal selesai tidak valid")
+	var jenisKelamin sql.NullString
+	if err := database.DB.QueryRow("SELECT jenis_kelamin FROM pengguna WHERE id = ?", userID).Scan(&jenisKelamin); err != nil {
+		return nil, err
 	}
 
-	if end.Before(start) {
-		return errors.New("tanggal selesai tidak boleh lebih awal dari tanggal mulai")
+	open := []models.JenisCuti{}
+	for _, t := range types {
+		if t.JenisKelamin != nil && (!jenisKelamin.Valid || *t.JenisKelamin != jenisKelamin.String) {
+			continue
+		}
+		open = append(open, t)
 	}
+	return open, nil
+}
 
-	// Calculate days (simple calculation, improved logic would skip weekends/holidays)
-	days := int(end.Sub(start).Hours()/24) + 1
+// CalculateLeave returns the working-day breakdown of a leave before it is submitted
+func (s *LeaveService) CalculateLeave(tanggalMulai, tanggalSelesai, setengahHari string) (*attendance.LeaveDuration, error) {
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	return attendance.LeaveDurationFor(tanggalMulai, tanggalSelesai, setengahHari, config)
+}
 
-	// 2. Check Balance if type is 'cuti'
-	if req.TipeCuti == "cuti" {
-		balance, err := s.GetBalance(userID, start.Year())
-		if err != nil {
-			return err
-		}
-		if balance.SisaHari < days {
-			return errors.New("sisa cuti tidak mencukupi")
+func (s *LeaveService) RequestLeave(userID int, req LeaveRequestInput) (*attendance.LeaveDuration, error) {
+	// 1. Count working days only: weekends and hari_libur are not taken from the leave balance
+	duration, err := s.CalculateLeave(req.TanggalMulai, req.TanggalSelesai, req.SetengahHari)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Check the leave type's eligibility rules and limits
+	jenis, err := leave.TypeByCode(req.TipeCuti)
+	if err != nil {
+		return nil, err
+	}
+	start, _ := time.ParseInLocation("2006-01-02", req.TanggalMulai, time.Local)
+	if err := leave.CheckEligibility(jenis, userID, start, duration.TotalHari); err != nil {
+		return nil, err
+	}
+	if err := leave.CheckAttachments(jenis, duration.TotalHari, req.Lampiran); err != nil {
+		return nil, err
+	}
+	// A leave running into the next year takes each year's days from that year's balance
+	years := leave.SplitByYear(duration)
+	if jenis.PotongSaldo {
+		// Balances are generated from konfigurasi_cuti; create the rows if the accrual job has not yet
+		for _, y := range years {
+			if err := leave.EnsureBalance(userID, y.Tahun); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	// 3. Insert Request
+	// 3. Insert Request with its attachments
 	// Note: We are NOT deducting balance yet. Balance is deducted upon APPROVAL (HR side).
 	// Status default: 'menunggu'
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Overlaps and the balance are checked under the applicant's lock, so a concurrent submission
+	// of the same user waits and then sees this one
+	if err := leave.LockApplicant(tx, userID); err != nil {
+		return nil, err
+	}
+	if err := leave.CheckConflicts(tx, userID, duration); err != nil {
+		return nil, err
+	}
+	if jenis.PotongSaldo {
+		for _, y := range years {
+			balance, err := leave.BalanceIn(tx, userID, y.Tahun)
+			if err != nil {
+				return nil, err
+			}
+			// Carried-over days only cover the working days of the leave before they expire
+			if leave.UsableDays(balance, y.Durasi) < y.Hari {
+				return nil, fmt.Errorf("sisa cuti %d tidak mencukupi", y.Tahun)
+			}
+		}
+	}
+
 	query := `
-		INSERT INTO pengajuan_cuti (pengguna_id, tipe_cuti, tanggal_mulai, tanggal_selesai, total_hari, alasan, status, dibuat_pada, diperbarui_pada)
-		VALUES (?, ?, ?, ?, ?, ?, 'menunggu', NOW(), NOW())
+		INSERT INTO pengajuan_cuti (pengguna_id, tipe_cuti, tanggal_mulai, tanggal_selesai, setengah_hari, total_hari, alasan, status, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'menunggu', NOW(), NOW())
 	`
-	_, err = database.DB.Exec(query, userID, req.TipeCuti, start, end, days, req.Alasan)
-	return err
+	res, err := tx.Exec(query, userID, req.TipeCuti, req.TanggalMulai, req.TanggalSelesai, duration.SetengahHari,
+		duration.TotalHari, req.Alasan)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := res.LastInsertId()
+
+	// Line manager first (when the user has one), then HR
+	if err := leave.CreateSteps(tx, int(id), userID); err != nil {
+		return nil, err
+	}
+
+	keys, err := leave.StoreAttachments(tx, int(id), userID, req.Lampiran)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		leave.DeleteAttachments(keys)
+		return nil, err
+	}
+	return duration, nil
 }
 
 func (s *LeaveService) GetHistory(userID int) ([]models.PengajuanCuti, error) {
 	query := `
-		SELECT id, tipe_cuti, tanggal_mulai, tanggal_selesai, total_hari, alasan, status, dibuat_pada
+		SELECT id, tipe_cuti, tanggal_mulai, tanggal_selesai, setengah_hari, total_hari, alasan, status, dibuat_pada
 		FROM pengajuan_cuti
 		WHERE pengguna_id = ?
 		ORDER BY id DESC
@@ -107,10 +195,96 @@ func (s *LeaveService) GetHistory(userID int) ([]models.PengajuanCuti, error) {
 	var history []models.PengajuanCuti
 	for rows.Next() {
 		var p models.PengajuanCuti
-		if err := rows.Scan(&p.ID, &p.TipeCuti, &p.TanggalMulai, &p.TanggalSelesai, &p.TotalHari, &p.Alasan, &p.Status, &p.DibuatPada); err != nil {
+		if err := rows.Scan(&p.ID, &p.TipeCuti, &p.TanggalMulai, &p.TanggalSelesai, &p.SetengahHari, &p.TotalHari, &p.Alasan, &p.Status, &p.DibuatPada); err != nil {
 			return nil, err
 		}
 		history = append(history, p)
 	}
+
+	// Per-step approval status, line manager then HR
+	ids := make([]int, len(history))
+	for i, p := range history {
+		ids[i] = p.ID
+	}
+	steps, err := leave.StepsOf(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		history[i].Persetujuan = steps[history[i].ID]
+	}
 	return history, nil
 }
+
+// CancelLeave cancels one of the user's requests that HR has not processed yet
+func (s *LeaveService) CancelLeave(userID, id int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE pengajuan_cuti SET status = 'dibatalkan', diperbarui_pada = NOW()
+		WHERE id = ? AND pengguna_id = ? AND status = 'menunggu'
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("pengajuan tidak ditemukan atau sudah diproses HR")
+	}
+	if err := leave.SkipPendingSteps(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type WithdrawLeaveInput struct {
+	TanggalSelesaiBaru string `json:"tanggal_selesai_baru"` // Shortens the leave; empty withdraws all of it
+	Alasan             string `json:"alasan" binding:"required"`
+}
+
+// WithdrawLeave asks HR to withdraw an approved leave, fully or from a date on. The balance is
+// only restored once HR acknowledges the withdrawal.
+func (s *LeaveService) WithdrawLeave(userID, id int, in WithdrawLeaveInput) (*leave.Withdrawal, error) {
+	var mulai, selesai time.Time
+	var setengahHari *string
+	var totalHari float64
+	var status string
+	err := database.DB.QueryRow(`
+		SELECT tanggal_mulai, tanggal_selesai, setengah_hari, total_hari, status
+		FROM pengajuan_cuti WHERE id = ? AND pengguna_id = ?
+	`, id, userID).Scan(&mulai, &selesai, &setengahHari, &totalHari, &status)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("pengajuan tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != "disetujui" {
+		return nil, errors.New("hanya cuti yang sudah disetujui yang dapat ditarik; pengajuan yang menunggu dapat dibatalkan")
+	}
+
+	var pending int
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM penarikan_cuti WHERE pengajuan_cuti_id = ? AND status = 'menunggu'", id).Scan(&pending)
+	if err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, errors.New("penarikan cuti ini masih menunggu persetujuan HR")
+	}
+
+	w, err := leave.PlanWithdrawal(mulai, selesai, setengahHari, totalHari, in.TanggalSelesaiBaru, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO penarikan_cuti (pengajuan_cuti_id, pengguna_id, tanggal_selesai_baru, hari_dikembalikan, alasan, status, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, 'menunggu', NOW(), NOW())
+	`, id, userID, w.TanggalSelesaiBaru, w.HariDikembalikan, in.Alasan)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
@@ -0,0 +1,263 @@
+package employee
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+// LeaveApproval is a leave request waiting on the user as line manager, or as a manager's delegate
+type LeaveApproval struct {
+	PengajuanCutiID int       `json:"pengajuan_cuti_id"`
+	PenggunaID      int       `json:"pengguna_id"`
+	NamaLengkap     string    `json:"nama_lengkap"`
+	TipeCuti        string    `json:"tipe_cuti"`
+	NamaJenisCuti   string    `json:"nama_jenis_cuti"`
+	TanggalMulai    time.Time `json:"tanggal_mulai"`
+	TanggalSelesai  time.Time `json:"tanggal_selesai"`
+	SetengahHari    *string   `json:"setengah_hari"`
+	TotalHari       float64   `json:"total_hari"`
+	Alasan          string    `json:"alasan"`
+	DibuatPada      time.Time `json:"dibuat_pada"`
+	AtasNama        *string   `json:"atas_nama"` // Manager the user approves for under a delegation
+
+	// Days the leave would leave the division below its minimum staffing
+	PeringatanStaf []leave.StaffingWarning `json:"peringatan_staf"`
+}
+
+// GetApprovals lists the leave requests waiting on the user's line manager decision, including
+// those of managers who delegated their approvals to the user for today
+func (s *LeaveService) GetApprovals(userID int) ([]LeaveApproval, error) {
+	managers, err := leave.ActingFor(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	args := []interface{}{userID}
+	for _, id := range managers {
+		args = append(args, id)
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT pc.id, pc.pengguna_id, p.nama_lengkap, pc.tipe_cuti, jc.nama, pc.tanggal_mulai, pc.tanggal_selesai,
+		       pc.setengah_hari, pc.total_hari, pc.alasan, pc.dibuat_pada,
+		       CASE WHEN s.penyetuju_id = ? THEN NULL ELSE a.nama_lengkap END
+		FROM persetujuan_cuti s
+		JOIN pengajuan_cuti pc ON s.pengajuan_cuti_id = pc.id
+		JOIN pengguna p ON pc.pengguna_id = p.id
+		JOIN jenis_cuti jc ON pc.tipe_cuti = jc.kode
+		JOIN pengguna a ON s.penyetuju_id = a.id
+		WHERE s.peran = 'atasan' AND s.status = 'menunggu' AND pc.status = 'menunggu'
+		AND s.penyetuju_id IN (?`+strings.Repeat(", ?", len(managers)-1)+`)
+		ORDER BY pc.tanggal_mulai ASC, pc.id ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []LeaveApproval{}
+	for rows.Next() {
+		var a LeaveApproval
+		if err := rows.Scan(&a.PengajuanCutiID, &a.PenggunaID, &a.NamaLengkap, &a.TipeCuti, &a.NamaJenisCuti,
+			&a.TanggalMulai, &a.TanggalSelesai, &a.SetengahHari, &a.TotalHari, &a.Alasan, &a.DibuatPada, &a.AtasNama); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	requests := make([]leave.StaffingRequest, len(approvals))
+	for i, a := range approvals {
+		requests[i] = leave.StaffingRequest{
+			ID: a.PengajuanCutiID, PenggunaID: a.PenggunaID, Mulai: a.TanggalMulai, Selesai: a.TanggalSelesai,
+			SetengahHari: a.SetengahHari != nil,
+		}
+	}
+	warnings, err := leave.StaffingWarnings(requests)
+	if err != nil {
+		return nil, err
+	}
+	for i := range approvals {
+		approvals[i].PeringatanStaf = warnings[approvals[i].PengajuanCutiID]
+	}
+	return approvals, nil
+}
+
+// ProcessApproval records the line manager's decision on a leave request. An approval passes the
+// request on to HR; a rejection closes it.
+func (s *LeaveService) ProcessApproval(userID, pengajuanID int, status, notes string) error {
+	if status != "disetujui" && status != "ditolak" {
+		return errors.New("status harus 'disetujui' atau 'ditolak'")
+	}
+
+	managers, err := leave.ActingFor(userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stepID, penyetujuID, penggunaID int
+	var requestStatus string
+	err = tx.QueryRow(`
+		SELECT s.id, s.penyetuju_id, pc.pengguna_id, pc.status
+		FROM persetujuan_cuti s JOIN pengajuan_cuti pc ON s.pengajuan_cuti_id = pc.id
+		WHERE s.pengajuan_cuti_id = ? AND s.peran = 'atasan' AND s.status = 'menunggu'
+		FOR UPDATE
+	`, pengajuanID).Scan(&stepID, &penyetujuID, &penggunaID, &requestStatus)
+	if err == sql.ErrNoRows {
+		return errors.New("pengajuan tidak ditemukan atau sudah diproses atasan")
+	}
+	if err != nil {
+		return err
+	}
+	if penggunaID == userID {
+		return errors.New("tidak dapat menyetujui pengajuan cuti sendiri")
+	}
+	allowed := false
+	for _, id := range managers {
+		allowed = allowed || id == penyetujuID
+	}
+	if !allowed {
+		return errors.New("anda bukan penyetuju pengajuan ini")
+	}
+	if requestStatus != "menunggu" {
+		return errors.New("pengajuan sudah tidak menunggu persetujuan")
+	}
+
+	_, err = tx.Exec(`
+		UPDATE persetujuan_cuti SET status = ?, diproses_oleh = ?, catatan = ?, diproses_pada = NOW()
+		WHERE id = ?
+	`, status, userID, notes, stepID)
+	if err != nil {
+		return err
+	}
+
+	if status == "ditolak" {
+		_, err = tx.Exec(`
+			UPDATE pengajuan_cuti
+			SET status = 'ditolak', disetujui_oleh = ?, catatan_persetujuan = ?, tanggal_persetujuan = NOW(), diperbarui_pada = NOW()
+			WHERE id = ?
+		`, userID, notes, pengajuanID)
+		if err != nil {
+			return err
+		}
+		if err := leave.SkipPendingSteps(tx, pengajuanID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type DelegationInput struct {
+	DelegasiID     int    `json:"delegasi_id" binding:"required"`
+	TanggalMulai   string `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai string `json:"tanggal_selesai" binding:"required"`
+	Alasan         string `json:"alasan"`
+}
+
+// GetDelegations lists the approval delegations the user has made, newest first
+func (s *LeaveService) GetDelegations(userID int) ([]models.DelegasiPersetujuan, error) {
+	rows, err := database.DB.Query(`
+		SELECT d.id, d.pengguna_id, d.delegasi_id, p.nama_lengkap, d.tanggal_mulai, d.tanggal_selesai,
+		       d.alasan, d.aktif, d.dibuat_pada
+		FROM delegasi_persetujuan d JOIN pengguna p ON d.delegasi_id = p.id
+		WHERE d.pengguna_id = ?
+		ORDER BY d.tanggal_mulai DESC, d.id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	delegations := []models.DelegasiPersetujuan{}
+	for rows.Next() {
+		var d models.DelegasiPersetujuan
+		if err := rows.Scan(&d.ID, &d.PenggunaID, &d.DelegasiID, &d.NamaDelegasi, &d.TanggalMulai, &d.TanggalSelesai,
+			&d.Alasan, &d.Aktif, &d.DibuatPada); err != nil {
+			return nil, err
+		}
+		delegations = append(delegations, d)
+	}
+	return delegations, rows.Err()
+}
+
+// CreateDelegation lets another employee approve the user's team leave between two dates, e.g.
+// while the user is on leave themselves
+func (s *LeaveService) CreateDelegation(userID int, in DelegationInput) error {
+	start, err := time.ParseInLocation("2006-01-02", in.TanggalMulai, time.Local)
+	if err != nil {
+		return errors.New("format tanggal mulai tidak valid")
+	}
+	end, err := time.ParseInLocation("2006-01-02", in.TanggalSelesai, time.Local)
+	if err != nil {
+		return errors.New("format tanggal selesai tidak valid")
+	}
+	if end.Before(start) {
+		return errors.New("tanggal selesai tidak boleh sebelum tanggal mulai")
+	}
+	now := time.Now()
+	if end.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)) {
+		return errors.New("tanggal selesai sudah lewat")
+	}
+	if in.DelegasiID == userID {
+		return errors.New("tidak dapat mendelegasikan ke diri sendiri")
+	}
+
+	// Delegates approve through the employee app, so they must be active employees
+	var count int
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM pengguna WHERE id = ? AND peran_id = 4 AND aktif = TRUE", in.DelegasiID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("karyawan delegasi tidak ditemukan atau tidak aktif")
+	}
+
+	err = database.DB.QueryRow(`
+		SELECT COUNT(*) FROM delegasi_persetujuan
+		WHERE pengguna_id = ? AND aktif = TRUE AND tanggal_mulai <= ? AND tanggal_selesai >= ?
+	`, userID, in.TanggalSelesai, in.TanggalMulai).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("sudah ada delegasi aktif pada rentang tanggal tersebut")
+	}
+
+	var alasan *string
+	if strings.TrimSpace(in.Alasan) != "" {
+		alasan = &in.Alasan
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO delegasi_persetujuan (pengguna_id, delegasi_id, tanggal_mulai, tanggal_selesai, alasan, aktif, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, TRUE, NOW(), NOW())
+	`, userID, in.DelegasiID, in.TanggalMulai, in.TanggalSelesai, alasan)
+	return err
+}
+
+// RevokeDelegation ends one of the user's delegations
+func (s *LeaveService) RevokeDelegation(userID, id int) error {
+	res, err := database.DB.Exec(`
+		UPDATE delegasi_persetujuan SET aktif = FALSE, diperbarui_pada = NOW()
+		WHERE id = ? AND pengguna_id = ? AND aktif = TRUE
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("delegasi tidak ditemukan atau sudah dicabut")
+	}
+	return nil
+}
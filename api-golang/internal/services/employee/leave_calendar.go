@@ -0,0 +1,138 @@
+package employee
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+// GetTeamCalendar returns who is off when for the user. cakupan "tim" (default) covers the user and
+// the direct reports of the user and of the managers who delegated their approvals to the user;
+// "divisi" covers the user's own division, showing only that colleagues outside the user's own
+// reports are off: their leave type is hidden and their pending requests left out. Other divisions
+// are never visible to employees.
+func (s *LeaveService) GetTeamCalendar(userID int, cakupan, mulai, selesai string) (*leave.TeamCalendar, error) {
+	start, end, err := leave.CalendarRange(mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+
+	var query, nama string
+	args := []interface{}{}
+	switch cakupan {
+	case "", "tim":
+		managers, err := leave.ActingFor(userID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, userID)
+		for _, id := range managers {
+			args = append(args, id)
+		}
+		query = `
+			SELECT p.id, p.nama_lengkap, d.nama FROM pengguna p LEFT JOIN divisi d ON p.divisi_id = d.id
+			WHERE p.aktif = TRUE AND (p.id = ? OR p.atasan_id IN (?` + strings.Repeat(", ?", len(managers)-1) + `))
+			ORDER BY p.nama_lengkap ASC
+		`
+		nama = "Kalender Cuti Tim"
+	case "divisi":
+		var divisiID sql.NullInt64
+		var divisi sql.NullString
+		err := database.DB.QueryRow(`
+			SELECT p.divisi_id, d.nama FROM pengguna p LEFT JOIN divisi d ON p.divisi_id = d.id WHERE p.id = ?
+		`, userID).Scan(&divisiID, &divisi)
+		if err != nil {
+			return nil, err
+		}
+		if !divisiID.Valid {
+			return nil, errors.New("anda tidak terdaftar di divisi mana pun")
+		}
+		args = append(args, divisiID.Int64)
+		query = `
+			SELECT p.id, p.nama_lengkap, d.nama FROM pengguna p LEFT JOIN divisi d ON p.divisi_id = d.id
+			WHERE p.aktif = TRUE AND p.peran_id = 4 AND p.divisi_id = ?
+			ORDER BY p.nama_lengkap ASC
+		`
+		nama = "Kalender Cuti " + divisi.String
+	default:
+		return nil, errors.New("cakupan harus 'tim' atau 'divisi'")
+	}
+
+	members, err := teamMembers(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := leave.BuildTeamCalendar(nama, members, start, end)
+	if err != nil || cakupan != "divisi" {
+		return cal, err
+	}
+
+	reports, err := ownReports(userID)
+	if err != nil {
+		return nil, err
+	}
+	leave.RedactCalendar(cal, reports)
+	return cal, nil
+}
+
+// ownReports is the user and the employees whose leave the user approves, directly or for a
+// manager who delegated their approvals to the user
+func ownReports(userID int) (map[int]bool, error) {
+	managers, err := leave.ActingFor(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	args := []interface{}{}
+	for _, id := range managers {
+		args = append(args, id)
+	}
+	rows, err := database.DB.Query(`
+		SELECT id FROM pengguna WHERE atasan_id IN (?`+strings.Repeat(", ?", len(managers)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := map[int]bool{userID: true}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		reports[id] = true
+	}
+	return reports, rows.Err()
+}
+
+func teamMembers(query string, args ...interface{}) ([]leave.TeamMember, error) {
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []leave.TeamMember{}
+	for rows.Next() {
+		var m leave.TeamMember
+		if err := rows.Scan(&m.PenggunaID, &m.NamaLengkap, &m.Divisi); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// IssueCalendarToken creates the user's calendar feed token, revoking the previous one
+func (s *LeaveService) IssueCalendarToken(userID int) (string, error) {
+	return leave.IssueFeedToken(userID)
+}
+
+// RevokeCalendarToken ends the user's calendar feed subscriptions
+func (s *LeaveService) RevokeCalendarToken(userID int) error {
+	return leave.RevokeFeedToken(userID)
+}
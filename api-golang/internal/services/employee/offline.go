@@ -0,0 +1,307 @@
+package employee
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+const (
+	defaultOfflineSkewSeconds = 300 // Device clock may be off by 5 minutes
+	defaultOfflineMaxAgeHours = 8   // Punches older than a shift need HR approval
+	maxOfflineBatch           = 100
+)
+
+type OfflineService struct{}
+
+func NewOfflineService() *OfflineService {
+	return &OfflineService{}
+}
+
+// OfflinePunch is a punch recorded by the app without signal, signed with the device key
+type OfflinePunch struct {
+	Jenis       string   `json:"jenis" binding:"required"` // masuk / pulang
+	Waktu       string   `json:"waktu" binding:"required"` // RFC3339, device clock at the moment of the punch
+	Latitude    float64  `json:"latitude"`
+	Longitude   float64  `json:"longitude"`
+	Akurasi     *float64 `json:"akurasi"`
+	Nonce       string   `json:"nonce" binding:"required"`        // Unique per punch, guards against replay
+	TandaTangan string   `json:"tanda_tangan" binding:"required"` // hex HMAC-SHA256, see SignaturePayload
+}
+
+// OfflineSyncInput is one upload of queued punches
+type OfflineSyncInput struct {
+	PerangkatID    string         `json:"perangkat_id" binding:"required"`
+	WaktuPerangkat string         `json:"waktu_perangkat" binding:"required"` // RFC3339, device clock at upload time
+	Presensi       []OfflinePunch `json:"presensi" binding:"required,dive"`
+}
+
+type OfflineSyncResult struct {
+	Nonce      string `json:"nonce"`
+	Status     string `json:"status"` // diterima, menunggu, ditolak, duplikat
+	PresensiID *int   `json:"presensi_id"`
+	Pesan      string `json:"pesan,omitempty"`
+}
+
+// SignaturePayload is the canonical string the app signs for each punch
+func SignaturePayload(perangkatID string, p OfflinePunch) string {
+	return fmt.Sprintf("%s|%s|%s|%.8f|%.8f|%s", perangkatID, p.Jenis, p.Waktu, p.Latitude, p.Longitude, p.Nonce)
+}
+
+func envInt(name string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+// RegisterDevice issues (or rotates) the signing key the app uses for offline punches
+func (s *OfflineService) RegisterDevice(userID int, perangkatID string) (string, error) {
+	if perangkatID == "" {
+		return "", errors.New("perangkat_id harus diisi")
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	secret := hex.EncodeToString(raw)
+
+	_, err := database.DB.Exec(`
+		INSERT INTO perangkat_offline (pengguna_id, perangkat_id, kunci_rahasia, aktif, terakhir_kontak, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, TRUE, NOW(), NOW(), NOW())
+		ON DUPLICATE KEY UPDATE kunci_rahasia = VALUES(kunci_rahasia), aktif = TRUE, terakhir_kontak = NOW(), diperbarui_pada = NOW()
+	`, userID, perangkatID, secret)
+	if err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// SyncPunches validates a batch of offline punches. Punches inside the skew / age tolerance are
+// written straight to presensi; the rest are kept in presensi_offline for HR approval. The key
+// belongs to the employee, so the signature proves who punched but not when: a punch dated before
+// the account was last seen online could have been sent then, and is held for HR as well.
+func (s *OfflineService) SyncPunches(userID int, in OfflineSyncInput) ([]OfflineSyncResult, error) {
+	if len(in.Presensi) == 0 {
+		return nil, errors.New("tidak ada presensi untuk disinkronkan")
+	}
+	if len(in.Presensi) > maxOfflineBatch {
+		return nil, fmt.Errorf("maksimal %d presensi per sinkronisasi", maxOfflineBatch)
+	}
+
+	var secret string
+	err := database.DB.QueryRow(
+		"SELECT kunci_rahasia FROM perangkat_offline WHERE pengguna_id = ? AND perangkat_id = ? AND aktif = TRUE",
+		userID, in.PerangkatID,
+	).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("perangkat belum terdaftar untuk presensi offline")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	deviceNow, err := time.Parse(time.RFC3339, in.WaktuPerangkat)
+	if err != nil {
+		return nil, errors.New("format waktu_perangkat tidak valid")
+	}
+	serverNow := time.Now()
+	skew := serverNow.Sub(deviceNow).Round(time.Second)
+
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	lastContact, err := lastOnlineContact(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []OfflineSyncResult
+	for _, p := range in.Presensi {
+		results = append(results, s.syncOne(userID, in.PerangkatID, secret, skew, serverNow, lastContact, p, config))
+	}
+
+	_, err = database.DB.Exec(
+		"UPDATE perangkat_offline SET terakhir_kontak = NOW() WHERE pengguna_id = ? AND perangkat_id = ?",
+		userID, in.PerangkatID,
+	)
+	return results, err
+}
+
+// lastOnlineContact is the last time the server heard from the user: the latest registration or
+// sync of any of their devices, or an online (GPS / kiosk) punch. Zero when never seen.
+func lastOnlineContact(userID int) (time.Time, error) {
+	var device, punch sql.NullTime
+	err := database.DB.QueryRow(`
+		SELECT
+			(SELECT MAX(terakhir_kontak) FROM perangkat_offline WHERE pengguna_id = ?),
+			(SELECT MAX(GREATEST(waktu_masuk, COALESCE(waktu_pulang, waktu_masuk))) FROM presensi
+			 WHERE pengguna_id = ? AND sumber IN ('gps', 'kiosk'))
+	`, userID, userID).Scan(&device, &punch)
+	if err != nil {
+		return time.Time{}, err
+	}
+	last := device.Time
+	if punch.Valid && punch.Time.After(last) {
+		last = punch.Time
+	}
+	return last, nil
+}
+
+func (s *OfflineService) syncOne(userID int, perangkatID, secret string, skew time.Duration, serverNow, lastContact time.Time, p OfflinePunch, config *models.KonfigurasiPresensi) OfflineSyncResult {
+	result := OfflineSyncResult{Nonce: p.Nonce, Status: "ditolak"}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(SignaturePayload(perangkatID, p)))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(p.TandaTangan)) {
+		result.Pesan = "tanda tangan tidak valid"
+		return result
+	}
+	if p.Jenis != "masuk" && p.Jenis != "pulang" {
+		result.Pesan = "jenis presensi harus 'masuk' atau 'pulang'"
+		return result
+	}
+	clientTime, err := time.Parse(time.RFC3339, p.Waktu)
+	if err != nil {
+		result.Pesan = "format waktu tidak valid"
+		return result
+	}
+
+	// Replay / re-upload of the same punch
+	var existingStatus string
+	var existingPresensi *int
+	err = database.DB.QueryRow(
+		"SELECT status, presensi_id FROM presensi_offline WHERE perangkat_id = ? AND nonce = ?",
+		perangkatID, p.Nonce,
+	).Scan(&existingStatus, &existingPresensi)
+	if err == nil {
+		result.Status = "duplikat"
+		result.PresensiID = existingPresensi
+		result.Pesan = "presensi sudah pernah disinkronkan (status: " + existingStatus + ")"
+		return result
+	}
+	if err != sql.ErrNoRows {
+		result.Pesan = err.Error()
+		return result
+	}
+
+	// Shift the device time by the measured clock skew, then check the bounds
+	corrected := clientTime.Add(skew).In(time.Local)
+	if corrected.After(serverNow.Add(time.Minute)) {
+		result.Pesan = "waktu presensi berada di masa depan"
+		return result
+	}
+
+	var holdReasons []string
+	tolerance := time.Duration(envInt("OFFLINE_TOLERANSI_DETIK", defaultOfflineSkewSeconds)) * time.Second
+	if skew > tolerance || skew < -tolerance {
+		holdReasons = append(holdReasons, fmt.Sprintf("selisih jam perangkat %s melebihi toleransi %s", skew, tolerance))
+	}
+	maxAge := time.Duration(envInt("OFFLINE_MAKS_UMUR_JAM", defaultOfflineMaxAgeHours)) * time.Hour
+	if serverNow.Sub(corrected) > maxAge {
+		holdReasons = append(holdReasons, fmt.Sprintf("presensi lebih lama dari %s", maxAge))
+	}
+	if corrected.Before(lastContact) {
+		holdReasons = append(holdReasons, "presensi lebih awal dari kontak terakhir akun ("+lastContact.Format("2006-01-02 15:04")+")")
+	}
+	mode, err := attendance.WorkModeFor(userID, p.Latitude, p.Longitude, corrected, config)
+	if err != nil {
+		holdReasons = append(holdReasons, err.Error())
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		result.Pesan = err.Error()
+		return result
+	}
+	defer tx.Rollback()
+
+	var presensiID *int
+	status := "menunggu"
+	if len(holdReasons) == 0 {
+		id, _, err := attendance.WritePunch(tx, attendance.PunchRecord{
+			PenggunaID:  userID,
+			Jenis:       p.Jenis,
+			Waktu:       corrected,
+			Latitude:    &p.Latitude,
+			Longitude:   &p.Longitude,
+			Akurasi:     p.Akurasi,
+			PerangkatID: &perangkatID,
+			Sumber:      "offline",
+			ModeKerja:   mode,
+		}, config, false)
+		if err != nil {
+			holdReasons = append(holdReasons, err.Error())
+		} else {
+			presensiID = &id
+			status = "diterima"
+			if _, err := tx.Exec("UPDATE presensi SET disinkron_pada = NOW() WHERE id = ?", id); err != nil {
+				result.Pesan = err.Error()
+				return result
+			}
+		}
+	}
+
+	var alasan *string
+	if len(holdReasons) > 0 {
+		joined := holdReasons[0]
+		for _, r := range holdReasons[1:] {
+			joined += "; " + r
+		}
+		alasan = &joined
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO presensi_offline
+		(pengguna_id, perangkat_id, jenis, waktu_perangkat, waktu_terkoreksi, selisih_jam_detik,
+		 latitude, longitude, akurasi, nonce, status, alasan_penahanan, presensi_id, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+	`, userID, perangkatID, p.Jenis, clientTime.In(time.Local), corrected, int(skew.Seconds()),
+		p.Latitude, p.Longitude, p.Akurasi, p.Nonce, status, alasan, presensiID)
+	if err != nil {
+		result.Pesan = err.Error()
+		return result
+	}
+	if err := tx.Commit(); err != nil {
+		result.Pesan = err.Error()
+		return result
+	}
+
+	result.Status = status
+	result.PresensiID = presensiID
+	if alasan != nil {
+		result.Pesan = "menunggu persetujuan HR: " + *alasan
+	}
+
+	if presensiID != nil {
+		attendance.PublishPunch(*presensiID, p.Jenis)
+		attendance.FlagPunch(attendance.Punch{
+			PresensiID:  *presensiID,
+			PenggunaID:  userID,
+			Jenis:       p.Jenis,
+			Waktu:       corrected,
+			Latitude:    p.Latitude,
+			Longitude:   p.Longitude,
+			Akurasi:     p.Akurasi,
+			PerangkatID: perangkatID,
+		})
+	} else {
+		log.Printf("[SyncPunches] Punch %s of user %d held for HR: %s", p.Nonce, userID, *alasan)
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+const maxRemoteWorkDays = 31
+
+type RemoteWorkService struct{}
+
+func NewRemoteWorkService() *RemoteWorkService {
+	return &RemoteWorkService{}
+}
+
+type RemoteWorkRequestInput struct {
+	TanggalMulai   string   `json:"tanggal_mulai" binding:"required"`   // YYYY-MM-DD
+	TanggalSelesai string   `json:"tanggal_selesai" binding:"required"` // YYYY-MM-DD
+	Alasan         string   `json:"alasan" binding:"required"`
+	Alamat         *string  `json:"alamat"`
+	Latitude       *float64 `json:"latitude"` // Remote location; clock-in must be within the office radius of it
+	Longitude      *float64 `json:"longitude"`
+}
+
+// RequestRemoteWork submits a WFH request for HR approval. Requests are made in advance:
+// the first day may not be in the past.
+func (s *RemoteWorkService) RequestRemoteWork(userID int, req RemoteWorkRequestInput) error {
+	start, err := time.ParseInLocation("2006-01-02", req.TanggalMulai, time.Local)
+	if err != nil {
+		return errors.New("format tanggal mulai tidak valid")
+	}
+	end, err := time.ParseInLocation("2006-01-02", req.TanggalSelesai, time.Local)
+	if err != nil {
+		return errors.New("format tanggal selesai tidak valid")
+	}
+	if end.Before(start) {
+		return errors.New("tanggal selesai tidak boleh lebih awal dari tanggal mulai")
+	}
+	if start.Format("2006-01-02") < time.Now().Format("2006-01-02") {
+		return errors.New("pengajuan WFH tidak dapat dibuat untuk tanggal yang sudah lewat")
+	}
+	if int(end.Sub(start).Hours()/24)+1 > maxRemoteWorkDays {
+		return fmt.Errorf("rentang WFH maksimal %d hari", maxRemoteWorkDays)
+	}
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return errors.New("latitude dan longitude harus diisi bersamaan")
+	}
+
+	// No two open or approved requests on the same day
+	var overlap int
+	err = database.DB.QueryRow(`
+		SELECT COUNT(*) FROM pengajuan_wfh
+		WHERE pengguna_id = ? AND status IN ('menunggu', 'disetujui')
+		AND tanggal_mulai <= ? AND tanggal_selesai >= ?
+	`, userID, req.TanggalSelesai, req.TanggalMulai).Scan(&overlap)
+	if err != nil {
+		return err
+	}
+	if overlap > 0 {
+		return errors.New("sudah ada pengajuan WFH pada rentang tanggal tersebut")
+	}
+
+	query := `
+		INSERT INTO pengajuan_wfh (pengguna_id, tanggal_mulai, tanggal_selesai, alasan, alamat, latitude, longitude, status, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'menunggu', NOW(), NOW())
+	`
+	_, err = database.DB.Exec(query, userID, req.TanggalMulai, req.TanggalSelesai, req.Alasan, req.Alamat, req.Latitude, req.Longitude)
+	return err
+}
+
+// GetRemoteWorkHistory lists the user's WFH requests, newest first
+func (s *RemoteWorkService) GetRemoteWorkHistory(userID int) ([]models.PengajuanWFH, error) {
+	query := `
+		SELECT id, pengguna_id, tanggal_mulai, tanggal_selesai, alasan, alamat, latitude, longitude, tanpa_radius,
+		       status, diproses_oleh, tanggal_persetujuan, catatan_persetujuan, dibuat_pada
+		FROM pengajuan_wfh
+		WHERE pengguna_id = ?
+		ORDER BY id DESC
+	`
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.PengajuanWFH
+	for rows.Next() {
+		var w models.PengajuanWFH
+		if err := rows.Scan(
+			&w.ID, &w.PenggunaID, &w.TanggalMulai, &w.TanggalSelesai, &w.Alasan, &w.Alamat, &w.Latitude, &w.Longitude, &w.TanpaRadius,
+			&w.Status, &w.DiprosesOleh, &w.TanggalPersetujuan, &w.CatatanPersetujuan, &w.DibuatPada,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, w)
+	}
+	return history, nil
+}
@@ -0,0 +1,111 @@
+package employee
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+const (
+	maxTripDays         = 60
+	defaultTripRadiusKm = 50
+	maxTripRadiusKm     = 500
+)
+
+type BusinessTripService struct{}
+
+func NewBusinessTripService() *BusinessTripService {
+	return &BusinessTripService{}
+}
+
+type BusinessTripInput struct {
+	TanggalMulai    string   `json:"tanggal_mulai" binding:"required"`   // YYYY-MM-DD
+	TanggalSelesai  string   `json:"tanggal_selesai" binding:"required"` // YYYY-MM-DD
+	Tujuan          string   `json:"tujuan" binding:"required"`
+	Keperluan       string   `json:"keperluan" binding:"required"`
+	LatitudeTujuan  *float64 `json:"latitude_tujuan"` // Optional centre of the destination region
+	LongitudeTujuan *float64 `json:"longitude_tujuan"`
+	RadiusKm        int      `json:"radius_km"` // Default 50
+}
+
+// RequestTrip submits a business trip for HR approval
+func (s *BusinessTripService) RequestTrip(userID int, req BusinessTripInput) error {
+	start, err := time.ParseInLocation("2006-01-02", req.TanggalMulai, time.Local)
+	if err != nil {
+		return errors.New("format tanggal mulai tidak valid")
+	}
+	end, err := time.ParseInLocation("2006-01-02", req.TanggalSelesai, time.Local)
+	if err != nil {
+		return errors.New("format tanggal selesai tidak valid")
+	}
+	if end.Before(start) {
+		return errors.New("tanggal selesai tidak boleh lebih awal dari tanggal mulai")
+	}
+	if int(end.Sub(start).Hours()/24)+1 > maxTripDays {
+		return fmt.Errorf("perjalanan dinas maksimal %d hari", maxTripDays)
+	}
+	if (req.LatitudeTujuan == nil) != (req.LongitudeTujuan == nil) {
+		return errors.New("latitude dan longitude tujuan harus diisi bersamaan")
+	}
+	if req.RadiusKm == 0 {
+		req.RadiusKm = defaultTripRadiusKm
+	}
+	if req.RadiusKm < 0 || req.RadiusKm > maxTripRadiusKm {
+		return fmt.Errorf("radius wilayah tujuan harus antara 1 dan %d km", maxTripRadiusKm)
+	}
+
+	var overlap int
+	err = database.DB.QueryRow(`
+		SELECT COUNT(*) FROM perjalanan_dinas
+		WHERE pengguna_id = ? AND status IN ('menunggu', 'disetujui')
+		AND tanggal_mulai <= ? AND tanggal_selesai >= ?
+	`, userID, req.TanggalSelesai, req.TanggalMulai).Scan(&overlap)
+	if err != nil {
+		return err
+	}
+	if overlap > 0 {
+		return errors.New("sudah ada perjalanan dinas pada rentang tanggal tersebut")
+	}
+
+	query := `
+		INSERT INTO perjalanan_dinas
+		(pengguna_id, tanggal_mulai, tanggal_selesai, tujuan, keperluan, latitude_tujuan, longitude_tujuan, radius_km,
+		 status, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'menunggu', NOW(), NOW())
+	`
+	_, err = database.DB.Exec(query, userID, req.TanggalMulai, req.TanggalSelesai, req.Tujuan, req.Keperluan,
+		req.LatitudeTujuan, req.LongitudeTujuan, req.RadiusKm)
+	return err
+}
+
+// GetTripHistory lists the user's business trips, newest first
+func (s *BusinessTripService) GetTripHistory(userID int) ([]models.PerjalananDinas, error) {
+	query := `
+		SELECT id, pengguna_id, tanggal_mulai, tanggal_selesai, tujuan, keperluan, latitude_tujuan, longitude_tujuan,
+		       radius_km, uang_harian, status, disetujui_oleh, tanggal_persetujuan, catatan_persetujuan, dibuat_pada
+		FROM perjalanan_dinas
+		WHERE pengguna_id = ?
+		ORDER BY id DESC
+	`
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.PerjalananDinas
+	for rows.Next() {
+		var d models.PerjalananDinas
+		if err := rows.Scan(
+			&d.ID, &d.PenggunaID, &d.TanggalMulai, &d.TanggalSelesai, &d.Tujuan, &d.Keperluan, &d.LatitudeTujuan, &d.LongitudeTujuan,
+			&d.RadiusKm, &d.UangHarian, &d.Status, &d.DisetujuiOleh, &d.TanggalPersetujuan, &d.CatatanPersetujuan, &d.DibuatPada,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, d)
+	}
+	return history, nil
+}
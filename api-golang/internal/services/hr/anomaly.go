@@ -0,0 +1,146 @@
+package hr
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+type AnomalyService struct{}
+
+func NewAnomalyService() *AnomalyService {
+	return &AnomalyService{}
+}
+
+type FlaggedPunch struct {
+	ID             int        `json:"id"`
+	PresensiID     int        `json:"presensi_id"`
+	PenggunaID     int        `json:"pengguna_id"`
+	NamaLengkap    string     `json:"nama_lengkap"`
+	Divisi         *string    `json:"divisi"`
+	Tanggal        string     `json:"tanggal"`
+	JenisPresensi  string     `json:"jenis_presensi"` // masuk / pulang
+	Jenis          string     `json:"jenis"`
+	Detail         string     `json:"detail"`
+	WaktuMasuk     *time.Time `json:"waktu_masuk"`
+	WaktuPulang    *time.Time `json:"waktu_pulang"`
+	LatitudeMasuk  *float64   `json:"latitude_masuk"`
+	LongitudeMasuk *float64   `json:"longitude_masuk"`
+	AkurasiMasuk   *float64   `json:"akurasi_masuk"`
+	PerangkatID    *string    `json:"perangkat_id"`
+	InfoPerangkat  *string    `json:"info_perangkat"`
+	Status         string     `json:"status"` // menunggu, valid, curang
+	CatatanTinjau  *string    `json:"catatan_tinjauan"`
+	DitinjauPada   *time.Time `json:"ditinjau_pada"`
+	DibuatPada     time.Time  `json:"dibuat_pada"`
+}
+
+// GetFlaggedPunches lists punches flagged by the spoofing heuristics
+func (s *AnomalyService) GetFlaggedPunches(status, startDate, endDate string) ([]FlaggedPunch, error) {
+	query := `
+		SELECT
+			t.id,
+			t.presensi_id,
+			t.pengguna_id,
+			p.nama_lengkap,
+			d.nama as divisi,
+			DATE_FORMAT(pr.tanggal, '%Y-%m-%d'),
+			t.jenis_presensi,
+			t.jenis,
+			t.detail,
+			pr.waktu_masuk,
+			pr.waktu_pulang,
+			pr.latitude_masuk,
+			pr.longitude_masuk,
+			pr.akurasi_masuk,
+			pr.perangkat_id,
+			pr.info_perangkat,
+			t.status,
+			t.catatan_tinjauan,
+			t.ditinjau_pada,
+			t.dibuat_pada
+		FROM tanda_presensi t
+		JOIN presensi pr ON t.presensi_id = pr.id
+		JOIN pengguna p ON t.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	if status != "" {
+		query += " AND t.status = ?"
+		args = append(args, status)
+	}
+	if startDate != "" {
+		query += " AND pr.tanggal >= ?"
+		args = append(args, startDate)
+	}
+	if endDate != "" {
+		query += " AND pr.tanggal <= ?"
+		args = append(args, endDate)
+	}
+	query += `
+		ORDER BY
+			CASE WHEN t.status = 'menunggu' THEN 1 ELSE 2 END,
+			t.dibuat_pada DESC
+	`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []FlaggedPunch
+	for rows.Next() {
+		var f FlaggedPunch
+		err := rows.Scan(
+			&f.ID,
+			&f.PresensiID,
+			&f.PenggunaID,
+			&f.NamaLengkap,
+			&f.Divisi,
+			&f.Tanggal,
+			&f.JenisPresensi,
+			&f.Jenis,
+			&f.Detail,
+			&f.WaktuMasuk,
+			&f.WaktuPulang,
+			&f.LatitudeMasuk,
+			&f.LongitudeMasuk,
+			&f.AkurasiMasuk,
+			&f.PerangkatID,
+			&f.InfoPerangkat,
+			&f.Status,
+			&f.CatatanTinjau,
+			&f.DitinjauPada,
+			&f.DibuatPada,
+		)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, f)
+	}
+	return flags, nil
+}
+
+// ReviewFlag records HR's verdict on a flagged punch
+func (s *AnomalyService) ReviewFlag(id int, status string, notes string, reviewedBy int) error {
+	if status != "valid" && status != "curang" {
+		return errors.New("status harus 'valid' atau 'curang'")
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE tanda_presensi
+		SET status = ?, catatan_tinjauan = ?, ditinjau_oleh = ?, ditinjau_pada = NOW()
+		WHERE id = ?
+	`, status, notes, reviewedBy, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.New("data tanda presensi tidak ditemukan")
+	}
+	return nil
+}
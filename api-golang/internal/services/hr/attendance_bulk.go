@@ -0,0 +1,328 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+const (
+	maxBulkRangeDays = 31
+	maxBulkRows      = 5000
+)
+
+type BulkAttendanceService struct{}
+
+func NewBulkAttendanceService() *BulkAttendanceService {
+	return &BulkAttendanceService{}
+}
+
+// BulkAttendanceEntry targets one employee (pengguna_id) or a whole division (divisi_id) over a date range
+type BulkAttendanceEntry struct {
+	PenggunaID     *int    `json:"pengguna_id"`
+	DivisiID       *int    `json:"divisi_id"`
+	TanggalMulai   string  `json:"tanggal_mulai" binding:"required"` // YYYY-MM-DD
+	TanggalSelesai string  `json:"tanggal_selesai"`                  // Optional, defaults to tanggal_mulai
+	Status         string  `json:"status"`                           // Optional for hadir/terlambat, computed from waktu_masuk
+	WaktuMasuk     *string `json:"waktu_masuk"`                      // HH:MM
+	WaktuPulang    *string `json:"waktu_pulang"`                     // HH:MM
+	Catatan        *string `json:"catatan"`
+	Alasan         string  `json:"alasan"`
+	AbaikanJadwal  bool    `json:"abaikan_jadwal"` // Allow writing on non-working days
+}
+
+type BulkAttendanceResult struct {
+	Baris      int    `json:"baris"` // Index of the entry in the request
+	PenggunaID int    `json:"pengguna_id"`
+	Tanggal    string `json:"tanggal"`
+	PresensiID *int   `json:"presensi_id"`
+	Aksi       string `json:"aksi"` // dibuat, diperbarui, dilewati, gagal
+	Pesan      string `json:"pesan,omitempty"`
+}
+
+var validPresensiStatus = map[string]bool{
+	"hadir": true, "terlambat": true, "tidak_hadir": true, "izin": true, "cuti": true, "dinas": true,
+}
+
+// ApplyBulk creates or updates presensi rows for every employee/date targeted by the entries.
+// Every row is written in its own transaction so one invalid row does not block the rest.
+func (s *BulkAttendanceService) ApplyBulk(entries []BulkAttendanceEntry, editedBy int) ([]BulkAttendanceResult, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("data presensi kosong")
+	}
+
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Expand every entry before writing anything, so an oversized request is rejected as a whole
+	type expanded struct {
+		targets []int
+		dates   []time.Time
+		err     error
+	}
+	plan := make([]expanded, len(entries))
+	rowCount := 0
+	for i, entry := range entries {
+		targets, dates, err := s.expandEntry(entry)
+		plan[i] = expanded{targets, dates, err}
+		rowCount += len(targets) * len(dates)
+		if err != nil {
+			rowCount++
+		}
+	}
+	if rowCount > maxBulkRows {
+		return nil, fmt.Errorf("maksimal %d baris presensi per permintaan", maxBulkRows)
+	}
+
+	results := make([]BulkAttendanceResult, 0, rowCount)
+	for i, entry := range entries {
+		if plan[i].err != nil {
+			results = append(results, BulkAttendanceResult{Baris: i, Tanggal: entry.TanggalMulai, Aksi: "gagal", Pesan: plan[i].err.Error()})
+			continue
+		}
+		for _, uid := range plan[i].targets {
+			for _, date := range plan[i].dates {
+				result := s.applyRow(entry, uid, date, config, editedBy)
+				result.Baris = i
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+// expandEntry resolves the target employees and dates of a single entry
+func (s *BulkAttendanceService) expandEntry(entry BulkAttendanceEntry) ([]int, []time.Time, error) {
+	if entry.Alasan == "" {
+		return nil, nil, errors.New("alasan perubahan harus diisi")
+	}
+	if (entry.PenggunaID == nil) == (entry.DivisiID == nil) {
+		return nil, nil, errors.New("isi salah satu dari pengguna_id atau divisi_id")
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", entry.TanggalMulai, time.Local)
+	if err != nil {
+		return nil, nil, errors.New("format tanggal mulai tidak valid")
+	}
+	end := start
+	if entry.TanggalSelesai != "" {
+		end, err = time.ParseInLocation("2006-01-02", entry.TanggalSelesai, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("format tanggal selesai tidak valid")
+		}
+	}
+	if end.Before(start) {
+		return nil, nil, errors.New("tanggal selesai tidak boleh lebih awal dari tanggal mulai")
+	}
+	if int(end.Sub(start).Hours()/24)+1 > maxBulkRangeDays {
+		return nil, nil, fmt.Errorf("rentang tanggal maksimal %d hari", maxBulkRangeDays)
+	}
+	var dates []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+
+	query := "SELECT id FROM pengguna WHERE peran_id = 4 AND aktif = TRUE AND id = ?"
+	arg := entry.PenggunaID
+	if entry.DivisiID != nil {
+		query = "SELECT id FROM pengguna WHERE peran_id = 4 AND aktif = TRUE AND divisi_id = ?"
+		arg = entry.DivisiID
+	}
+	rows, err := database.DB.Query(query, *arg)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var targets []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, nil, err
+		}
+		targets = append(targets, id)
+	}
+	if len(targets) == 0 {
+		return nil, nil, errors.New("karyawan aktif tidak ditemukan")
+	}
+	return targets, dates, nil
+}
+
+// applyRow validates and upserts a single presensi row
+func (s *BulkAttendanceService) applyRow(entry BulkAttendanceEntry, userID int, date time.Time, config *models.KonfigurasiPresensi, editedBy int) BulkAttendanceResult {
+	result := BulkAttendanceResult{PenggunaID: userID, Tanggal: date.Format("2006-01-02")}
+	fail := func(msg string) BulkAttendanceResult {
+		result.Aksi = "gagal"
+		result.Pesan = msg
+		return result
+	}
+
+	// Schedule validation
+	if date.After(time.Now()) {
+		return fail("tanggal tidak boleh di masa depan")
+	}
+	if !entry.AbaikanJadwal && !attendance.IsWorkday(date, config) {
+		return fail("bukan hari kerja sesuai jadwal")
+	}
+
+	var masuk, pulang *time.Time
+	if entry.WaktuMasuk != nil {
+		t, err := attendance.ParseClock(date, *entry.WaktuMasuk)
+		if err != nil {
+			return fail("format waktu masuk tidak valid")
+		}
+		masuk = &t
+	}
+	if entry.WaktuPulang != nil {
+		t, err := attendance.ParseClock(date, *entry.WaktuPulang)
+		if err != nil {
+			return fail("format waktu pulang tidak valid")
+		}
+		pulang = &t
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fail(err.Error())
+	}
+	defer tx.Rollback()
+
+	var presensiID int
+	var masukLama, pulangLama sql.NullTime
+	var statusLama sql.NullString
+	err = tx.QueryRow(`
+		SELECT id, waktu_masuk, waktu_pulang, status
+		FROM presensi WHERE pengguna_id = ? AND tanggal = ? FOR UPDATE
+	`, userID, result.Tanggal).Scan(&presensiID, &masukLama, &pulangLama, &statusLama)
+	if err != nil && err != sql.ErrNoRows {
+		return fail(err.Error())
+	}
+	exists := err == nil
+
+	change := attendance.Change{
+		PenggunaID: userID,
+		Tanggal:    date,
+		Sumber:     "hr_manual",
+		Alasan:     entry.Alasan,
+		DiubahOleh: editedBy,
+	}
+	if masukLama.Valid {
+		change.MasukLama = &masukLama.Time
+	}
+	if pulangLama.Valid {
+		change.PulangLama = &pulangLama.Time
+	}
+	if statusLama.Valid {
+		change.StatusLama = &statusLama.String
+	}
+
+	// Fields that are not sent keep their current value
+	change.MasukBaru, change.PulangBaru = change.MasukLama, change.PulangLama
+	if masuk != nil {
+		change.MasukBaru = masuk
+	}
+	if pulang != nil {
+		change.PulangBaru = pulang
+	}
+
+	// Late minutes only apply to days that end up as terlambat
+	var late attendance.Lateness
+	if change.MasukBaru != nil {
+		if late, err = attendance.LatenessFor(*change.MasukBaru, config); err != nil {
+			return fail(err.Error())
+		}
+	}
+	status := entry.Status
+	switch {
+	case status == "" && change.MasukBaru != nil:
+		status = late.Status
+	case status == "":
+		return fail("status atau waktu masuk harus diisi")
+	case !validPresensiStatus[status]:
+		return fail("status tidak valid")
+	}
+	change.StatusBaru = status
+	if status != "terlambat" {
+		late = attendance.Lateness{Status: status}
+	}
+
+	var warnings []string
+	if status == "tidak_hadir" || status == "izin" || status == "cuti" {
+		// No clock times on days the employee was not working
+		change.MasukBaru, change.PulangBaru = nil, nil
+	} else {
+		if change.PulangBaru != nil && change.MasukBaru == nil {
+			return fail("waktu pulang diisi tanpa waktu masuk")
+		}
+		if change.PulangBaru != nil && !change.PulangBaru.After(*change.MasukBaru) {
+			return fail("waktu pulang harus setelah waktu masuk")
+		}
+		grace := time.Duration(config.ToleransiMenit) * time.Minute
+		if status == "hadir" && change.MasukBaru != nil && change.MasukBaru.After(attendance.StartDeadline(date, config).Add(grace)) {
+			warnings = append(warnings, "waktu masuk melewati jam masuk maksimal")
+		}
+		if change.PulangBaru != nil && change.PulangBaru.Before(attendance.EndMinimum(date, config)) {
+			warnings = append(warnings, "waktu pulang sebelum jam pulang minimal")
+		}
+	}
+
+	if exists && sameTime(change.MasukLama, change.MasukBaru) && sameTime(change.PulangLama, change.PulangBaru) &&
+		statusLama.String == status && entry.Catatan == nil {
+		result.PresensiID = &presensiID
+		result.Aksi = "dilewati"
+		result.Pesan = "tidak ada perubahan"
+		return result
+	}
+
+	if exists {
+		_, err = tx.Exec(`
+			UPDATE presensi
+			SET waktu_masuk = ?, waktu_pulang = ?, status = ?, menit_terlambat = ?, tingkat_keterlambatan_id = ?,
+			    catatan = COALESCE(?, catatan), diperbarui_pada = NOW()
+			WHERE id = ?
+		`, change.MasukBaru, change.PulangBaru, status, late.Menit, late.TingkatID, entry.Catatan, presensiID)
+		result.Aksi = "diperbarui"
+	} else {
+		var res sql.Result
+		res, err = tx.Exec(`
+			INSERT INTO presensi (pengguna_id, tanggal, waktu_masuk, waktu_pulang, status, menit_terlambat,
+			                      tingkat_keterlambatan_id, catatan, sumber, dibuat_pada, diperbarui_pada)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'hr_manual', NOW(), NOW())
+		`, userID, result.Tanggal, change.MasukBaru, change.PulangBaru, status, late.Menit, late.TingkatID, entry.Catatan)
+		if err == nil {
+			newID, _ := res.LastInsertId()
+			presensiID = int(newID)
+		}
+		result.Aksi = "dibuat"
+	}
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	change.PresensiID = presensiID
+	if err := attendance.RecordChange(tx, change); err != nil {
+		return fail(err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err.Error())
+	}
+
+	result.PresensiID = &presensiID
+	result.Pesan = strings.Join(warnings, "; ")
+	return result
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
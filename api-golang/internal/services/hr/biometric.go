@@ -0,0 +1,119 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+type BiometricService struct{}
+
+func NewBiometricService() *BiometricService {
+	return &BiometricService{}
+}
+
+type MesinAbsensi struct {
+	ID                int        `json:"id"`
+	NomorSeri         string     `json:"nomor_seri"`
+	Nama              string     `json:"nama"`
+	Lokasi            string     `json:"lokasi"`
+	Aktif             bool       `json:"aktif"`
+	TerakhirTerhubung *time.Time `json:"terakhir_terhubung"`
+	DibuatPada        time.Time  `json:"dibuat_pada"`
+}
+
+type MesinAbsensiInput struct {
+	NomorSeri string `json:"nomor_seri" binding:"required"`
+	Nama      string `json:"nama" binding:"required"`
+	Lokasi    string `json:"lokasi"`
+	Aktif     *bool  `json:"aktif"`
+}
+
+func (s *BiometricService) GetMachines() ([]MesinAbsensi, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, nomor_seri, nama, lokasi, aktif, terakhir_terhubung, dibuat_pada
+		FROM mesin_absensi ORDER BY nama
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var machines []MesinAbsensi
+	for rows.Next() {
+		var m MesinAbsensi
+		if err := rows.Scan(&m.ID, &m.NomorSeri, &m.Nama, &m.Lokasi, &m.Aktif, &m.TerakhirTerhubung, &m.DibuatPada); err != nil {
+			return nil, err
+		}
+		machines = append(machines, m)
+	}
+	return machines, nil
+}
+
+// SaveMachine registers a terminal by serial number, or updates it if already registered.
+// Only registered, active terminals may push logs over ADMS.
+func (s *BiometricService) SaveMachine(in MesinAbsensiInput) error {
+	aktif := true
+	if in.Aktif != nil {
+		aktif = *in.Aktif
+	}
+	_, err := database.DB.Exec(`
+		INSERT INTO mesin_absensi (nomor_seri, nama, lokasi, aktif, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, NOW(), NOW())
+		ON DUPLICATE KEY UPDATE nama = VALUES(nama), lokasi = VALUES(lokasi), aktif = VALUES(aktif), diperbarui_pada = NOW()
+	`, strings.TrimSpace(in.NomorSeri), in.Nama, in.Lokasi, aktif)
+	return err
+}
+
+// SetEmployeePIN maps the user ID enrolled on the terminals to an employee.
+// Logs already imported for that PIN are paired into presensi right away.
+func (s *BiometricService) SetEmployeePIN(penggunaID int, pin string) error {
+	pin = strings.TrimSpace(pin)
+
+	var other int
+	err := database.DB.QueryRow("SELECT id FROM pengguna WHERE pin_mesin = ? AND id <> ?", pin, penggunaID).Scan(&other)
+	if err == nil && pin != "" {
+		return errors.New("PIN mesin sudah dipakai karyawan lain")
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	var pinValue *string
+	if pin != "" {
+		pinValue = &pin
+	}
+	result, err := database.DB.Exec("UPDATE pengguna SET pin_mesin = ? WHERE id = ?", pinValue, penggunaID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		var exists int
+		if err := database.DB.QueryRow("SELECT id FROM pengguna WHERE id = ?", penggunaID).Scan(&exists); err != nil {
+			return errors.New("karyawan tidak ditemukan")
+		}
+	}
+
+	if pinValue == nil {
+		return nil
+	}
+	return attendance.RepairMachineDays(pin, penggunaID)
+}
+
+// ImportLogFile imports an attendance log exported from a terminal (ATTLOG .dat or CSV)
+func (s *BiometricService) ImportLogFile(r io.Reader) (*attendance.MachineImportResult, error) {
+	punches, err := attendance.ParseAttendanceLog(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(punches) == 0 {
+		return nil, errors.New("tidak ada data presensi yang dapat dibaca dari berkas")
+	}
+	return attendance.ImportMachinePunches("", punches)
+}
@@ -0,0 +1,128 @@
+package hr
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+type CalendarService struct{}
+
+func NewCalendarService() *CalendarService {
+	return &CalendarService{}
+}
+
+var holidayTypes = map[string]bool{
+	attendance.LiburNasional: true, attendance.LiburCutiBersama: true, attendance.LiburPerusahaan: true,
+}
+
+type HolidayInput struct {
+	Tanggal string `json:"tanggal" binding:"required"` // YYYY-MM-DD
+	Nama    string `json:"nama" binding:"required"`
+	Jenis   string `json:"jenis"` // nasional, cuti_bersama, perusahaan (default perusahaan)
+}
+
+// GetHolidays lists the holidays of a year, optionally of one jenis
+func (s *CalendarService) GetHolidays(year int, jenis string) ([]models.HariLibur, error) {
+	query := `
+		SELECT id, tanggal, nama, jenis, sumber, uid_ical, dibuat_oleh, dibuat_pada
+		FROM hari_libur
+		WHERE YEAR(tanggal) = ?
+	`
+	args := []interface{}{year}
+	if jenis != "" {
+		query += " AND jenis = ?"
+		args = append(args, jenis)
+	}
+	query += " ORDER BY tanggal ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := []models.HariLibur{}
+	for rows.Next() {
+		var h models.HariLibur
+		if err := rows.Scan(&h.ID, &h.Tanggal, &h.Nama, &h.Jenis, &h.Sumber, &h.UIDIcal, &h.DibuatOleh, &h.DibuatPada); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, nil
+}
+
+// CreateHoliday adds one day to the calendar. Company days off default to jenis perusahaan.
+func (s *CalendarService) CreateHoliday(in HolidayInput, createdBy int) error {
+	tanggal, err := time.ParseInLocation("2006-01-02", in.Tanggal, time.Local)
+	if err != nil {
+		return errors.New("format tanggal tidak valid (YYYY-MM-DD)")
+	}
+	if in.Jenis == "" {
+		in.Jenis = attendance.LiburPerusahaan
+	}
+	if !holidayTypes[in.Jenis] {
+		return errors.New("jenis harus 'nasional', 'cuti_bersama' atau 'perusahaan'")
+	}
+	nama := strings.TrimSpace(in.Nama)
+	if nama == "" {
+		return errors.New("nama hari libur wajib diisi")
+	}
+
+	var exists int
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM hari_libur WHERE tanggal = ?", tanggal.Format("2006-01-02")).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return errors.New("tanggal tersebut sudah ada di kalender libur")
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO hari_libur (tanggal, nama, jenis, sumber, dibuat_oleh, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, 'manual', ?, NOW(), NOW())
+	`, tanggal.Format("2006-01-02"), nama, in.Jenis, createdBy)
+	return err
+}
+
+// DeleteHoliday removes a day from the calendar
+func (s *CalendarService) DeleteHoliday(id int) error {
+	res, err := database.DB.Exec("DELETE FROM hari_libur WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("hari libur tidak ditemukan")
+	}
+	return nil
+}
+
+// ImportICal adds the events of an iCalendar file to the calendar as the given jenis
+// (default nasional); dates already on the calendar are left unchanged
+func (s *CalendarService) ImportICal(r io.Reader, jenis string, importedBy int) (*attendance.HolidayImportResult, error) {
+	if jenis == "" {
+		jenis = attendance.LiburNasional
+	}
+	if !holidayTypes[jenis] {
+		return nil, errors.New("jenis harus 'nasional', 'cuti_bersama' atau 'perusahaan'")
+	}
+	events, skipped, err := attendance.ParseICal(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, errors.New("tidak ada hari libur yang dapat dibaca dari berkas iCal")
+	}
+	result, err := attendance.ImportHolidays(events, jenis, importedBy)
+	if err != nil {
+		return nil, err
+	}
+	result.Dilewati = append(result.Dilewati, skipped...)
+	return result, nil
+}
@@ -0,0 +1,260 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+type CorrectionService struct{}
+
+func NewCorrectionService() *CorrectionService {
+	return &CorrectionService{}
+}
+
+type CorrectionRequest struct {
+	ID                 int        `json:"id"`
+	PenggunaID         int        `json:"pengguna_id"`
+	NamaLengkap        string     `json:"nama_lengkap"`
+	Divisi             *string    `json:"divisi"`
+	PresensiID         *int       `json:"presensi_id"`
+	Tanggal            string     `json:"tanggal"`
+	WaktuMasukSaatIni  *time.Time `json:"waktu_masuk_saat_ini"`
+	WaktuPulangSaatIni *time.Time `json:"waktu_pulang_saat_ini"`
+	StatusSaatIni      *string    `json:"status_saat_ini"`
+	WaktuMasukUsulan   *time.Time `json:"waktu_masuk_usulan"`
+	WaktuPulangUsulan  *time.Time `json:"waktu_pulang_usulan"`
+	Alasan             string     `json:"alasan"`
+	Bukti              *string    `json:"bukti"`
+	Status             string     `json:"status"`
+	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
+	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
+	DibuatPada         time.Time  `json:"dibuat_pada"`
+}
+
+// GetCorrectionRequests fetches correction requests, optionally filtered by status
+func (s *CorrectionService) GetCorrectionRequests(status string) ([]CorrectionRequest, error) {
+	query := `
+		SELECT
+			k.id,
+			k.pengguna_id,
+			p.nama_lengkap,
+			d.nama as divisi,
+			k.presensi_id,
+			DATE_FORMAT(k.tanggal, '%Y-%m-%d'),
+			pr.waktu_masuk,
+			pr.waktu_pulang,
+			pr.status,
+			k.waktu_masuk_usulan,
+			k.waktu_pulang_usulan,
+			k.alasan,
+			k.bukti,
+			k.status,
+			k.tanggal_persetujuan,
+			k.catatan_persetujuan,
+			k.dibuat_pada
+		FROM koreksi_presensi k
+		JOIN pengguna p ON k.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		LEFT JOIN presensi pr ON pr.pengguna_id = k.pengguna_id AND pr.tanggal = k.tanggal
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	if status != "" {
+		query += " AND k.status = ?"
+		args = append(args, status)
+	}
+	query += `
+		ORDER BY
+			CASE WHEN k.status = 'menunggu' THEN 1 ELSE 2 END,
+			k.dibuat_pada DESC
+	`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []CorrectionRequest
+	for rows.Next() {
+		var r CorrectionRequest
+		err := rows.Scan(
+			&r.ID,
+			&r.PenggunaID,
+			&r.NamaLengkap,
+			&r.Divisi,
+			&r.PresensiID,
+			&r.Tanggal,
+			&r.WaktuMasukSaatIni,
+			&r.WaktuPulangSaatIni,
+			&r.StatusSaatIni,
+			&r.WaktuMasukUsulan,
+			&r.WaktuPulangUsulan,
+			&r.Alasan,
+			&r.Bukti,
+			&r.Status,
+			&r.TanggalPersetujuan,
+			&r.CatatanPersetujuan,
+			&r.DibuatPada,
+		)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+// ProcessCorrection approves or rejects a correction request.
+// Approval writes the proposed times to presensi and keeps the old values in riwayat_perubahan_presensi.
+func (s *CorrectionService) ProcessCorrection(id int, status string, notes string, processedBy int) error {
+	if status != "disetujui" && status != "ditolak" {
+		return errors.New("status harus 'disetujui' atau 'ditolak'")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var penggunaID int
+	var tanggal time.Time
+	var currentStatus, alasan string
+	var masukUsulan, pulangUsulan sql.NullTime
+	err = tx.QueryRow(`
+		SELECT pengguna_id, tanggal, waktu_masuk_usulan, waktu_pulang_usulan, alasan, status
+		FROM koreksi_presensi WHERE id = ? FOR UPDATE
+	`, id).Scan(&penggunaID, &tanggal, &masukUsulan, &pulangUsulan, &alasan, &currentStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("pengajuan koreksi tidak ditemukan")
+		}
+		return err
+	}
+	if currentStatus != "menunggu" {
+		return errors.New("pengajuan koreksi sudah diproses")
+	}
+
+	var presensiID *int
+	if status == "disetujui" {
+		pid, err := applyCorrection(tx, id, penggunaID, tanggal, masukUsulan, pulangUsulan, alasan, processedBy)
+		if err != nil {
+			return err
+		}
+		presensiID = &pid
+	}
+
+	_, err = tx.Exec(`
+		UPDATE koreksi_presensi
+		SET status = ?, presensi_id = COALESCE(?, presensi_id), diproses_oleh = ?,
+		    catatan_persetujuan = ?, tanggal_persetujuan = NOW(), diperbarui_pada = NOW()
+		WHERE id = ?
+	`, status, presensiID, processedBy, notes, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applyCorrection upserts the presensi row for the corrected day and records the audit entry
+func applyCorrection(tx *sql.Tx, koreksiID, penggunaID int, tanggal time.Time, masukUsulan, pulangUsulan sql.NullTime, alasan string, processedBy int) (int, error) {
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	var presensiID int
+	var masukLama, pulangLama sql.NullTime
+	var statusLama sql.NullString
+	err = tx.QueryRow(`
+		SELECT id, waktu_masuk, waktu_pulang, status
+		FROM presensi WHERE pengguna_id = ? AND tanggal = ? FOR UPDATE
+	`, penggunaID, tanggal.Format("2006-01-02")).Scan(&presensiID, &masukLama, &pulangLama, &statusLama)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	exists := err == nil
+
+	change := attendance.Change{
+		PenggunaID:  penggunaID,
+		Tanggal:     tanggal,
+		Sumber:      "koreksi",
+		ReferensiID: &koreksiID,
+		Alasan:      alasan,
+		DiubahOleh:  processedBy,
+	}
+	if masukLama.Valid {
+		change.MasukLama = &masukLama.Time
+	}
+	if pulangLama.Valid {
+		change.PulangLama = &pulangLama.Time
+	}
+	if statusLama.Valid {
+		change.StatusLama = &statusLama.String
+	}
+
+	// Proposed values win, otherwise keep what was recorded
+	change.MasukBaru, change.PulangBaru = change.MasukLama, change.PulangLama
+	if masukUsulan.Valid {
+		change.MasukBaru = &masukUsulan.Time
+	}
+	if pulangUsulan.Valid {
+		change.PulangBaru = &pulangUsulan.Time
+	}
+	if change.MasukBaru == nil {
+		return 0, errors.New("koreksi tidak memiliki waktu masuk")
+	}
+	// The row may have changed since the request was submitted, so check the merged times again
+	if change.PulangBaru != nil && !change.PulangBaru.After(*change.MasukBaru) {
+		return 0, errors.New("waktu pulang harus setelah waktu masuk")
+	}
+
+	// Lateness only decides between hadir and terlambat. A day recorded as dinas, cuti or izin keeps
+	// its status; tidak_hadir is what a correction with a clock-in overturns.
+	var late attendance.Lateness
+	switch {
+	case !statusLama.Valid, statusLama.String == "hadir", statusLama.String == "terlambat", statusLama.String == "tidak_hadir":
+		late, err = attendance.LatenessFor(*change.MasukBaru, config)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		late = attendance.Lateness{Status: statusLama.String}
+	}
+	change.StatusBaru = late.Status
+
+	if exists {
+		_, err = tx.Exec(`
+			UPDATE presensi
+			SET waktu_masuk = ?, waktu_pulang = ?, status = ?, menit_terlambat = ?, tingkat_keterlambatan_id = ?,
+			    diperbarui_pada = NOW()
+			WHERE id = ?
+		`, change.MasukBaru, change.PulangBaru, late.Status, late.Menit, late.TingkatID, presensiID)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		result, err := tx.Exec(`
+			INSERT INTO presensi (pengguna_id, tanggal, waktu_masuk, waktu_pulang, status, menit_terlambat,
+			                      tingkat_keterlambatan_id, catatan, sumber, dibuat_pada, diperbarui_pada)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 'Koreksi presensi', 'koreksi', NOW(), NOW())
+		`, penggunaID, tanggal.Format("2006-01-02"), change.MasukBaru, change.PulangBaru, late.Status, late.Menit, late.TingkatID)
+		if err != nil {
+			return 0, err
+		}
+		newID, _ := result.LastInsertId()
+		presensiID = int(newID)
+	}
+
+	change.PresensiID = presensiID
+	if err := attendance.RecordChange(tx, change); err != nil {
+		return 0, err
+	}
+	return presensiID, nil
+}
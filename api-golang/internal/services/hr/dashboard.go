@@ -6,6 +6,7 @@ import (
 
 	"github.com/hris-system/api-golang/internal/database"
 	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
 )
 
 type DashboardService struct{}
@@ -35,9 +36,37 @@ func (s *DashboardService) GetStats() (*models.DashboardStats, error) {
 		return nil, err
 	}
 
-	stats.Kehadiran.TidakHadir = stats.Kehadiran.TotalKaryawan - (stats.Kehadiran.Hadir + stats.Kehadiran.Terlambat)
-	if stats.Kehadiran.TidakHadir < 0 {
-		stats.Kehadiran.TidakHadir = 0
+	// On an approved business trip today, with or without a dinas clock-in
+	queryDinas := `
+		SELECT COUNT(*)
+		FROM pengguna p
+		WHERE p.peran_id = 4 AND p.aktif = TRUE
+		AND EXISTS (
+			SELECT 1 FROM perjalanan_dinas t
+			WHERE t.pengguna_id = p.id AND t.status = 'disetujui' AND CURDATE() BETWEEN t.tanggal_mulai AND t.tanggal_selesai
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM presensi pr
+			WHERE pr.pengguna_id = p.id AND pr.tanggal = CURDATE() AND pr.status IN ('hadir', 'terlambat')
+		)
+	`
+	err = database.DB.QueryRow(queryDinas).Scan(&stats.Kehadiran.Dinas)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nobody is absent on a holiday
+	holiday, err := attendance.HolidayOn(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if holiday != nil {
+		stats.Kehadiran.HariLibur = &holiday.Nama
+	} else {
+		stats.Kehadiran.TidakHadir = stats.Kehadiran.TotalKaryawan - (stats.Kehadiran.Hadir + stats.Kehadiran.Terlambat + stats.Kehadiran.Dinas)
+		if stats.Kehadiran.TidakHadir < 0 {
+			stats.Kehadiran.TidakHadir = 0
+		}
 	}
 
 	// 2. Pengajuan
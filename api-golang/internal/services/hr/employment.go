@@ -0,0 +1,55 @@
+package hr
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+type EmploymentService struct{}
+
+func NewEmploymentService() *EmploymentService {
+	return &EmploymentService{}
+}
+
+// EmploymentInput is the employee data leave eligibility depends on. Fields left out keep their
+// current value.
+type EmploymentInput struct {
+	JenisKelamin     *string `json:"jenis_kelamin"`     // L / P, for gender-specific leave types
+	TanggalBergabung *string `json:"tanggal_bergabung"` // YYYY-MM-DD, start of service for tenure and proration
+}
+
+// SetEmploymentData records an employee's gender and join date
+func (s *EmploymentService) SetEmploymentData(penggunaID int, in EmploymentInput) error {
+	if in.JenisKelamin == nil && in.TanggalBergabung == nil {
+		return errors.New("isi jenis_kelamin atau tanggal_bergabung")
+	}
+	if in.JenisKelamin != nil && *in.JenisKelamin != "L" && *in.JenisKelamin != "P" {
+		return errors.New("jenis_kelamin harus 'L' atau 'P'")
+	}
+	if in.TanggalBergabung != nil {
+		bergabung, err := time.ParseInLocation("2006-01-02", *in.TanggalBergabung, time.Local)
+		if err != nil {
+			return errors.New("format tanggal bergabung tidak valid")
+		}
+		if bergabung.After(time.Now()) {
+			return errors.New("tanggal bergabung tidak boleh di masa depan")
+		}
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM pengguna WHERE id = ?", penggunaID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return errors.New("karyawan tidak ditemukan")
+	}
+
+	_, err := database.DB.Exec(`
+		UPDATE pengguna
+		SET jenis_kelamin = COALESCE(?, jenis_kelamin), tanggal_bergabung = COALESCE(?, tanggal_bergabung)
+		WHERE id = ?
+	`, in.JenisKelamin, in.TanggalBergabung, penggunaID)
+	return err
+}
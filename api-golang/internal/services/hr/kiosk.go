@@ -0,0 +1,120 @@
+package hr
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+type KioskService struct{}
+
+func NewKioskService() *KioskService {
+	return &KioskService{}
+}
+
+type KioskInput struct {
+	Nama      string  `json:"nama" binding:"required"`
+	Lokasi    string  `json:"lokasi"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Aktif     *bool   `json:"aktif"`
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *KioskService) GetKiosks() ([]models.Kiosk, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, nama, lokasi, latitude, longitude, aktif, dibuat_pada, diperbarui_pada
+		FROM kiosk ORDER BY nama
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kiosks []models.Kiosk
+	for rows.Next() {
+		var k models.Kiosk
+		if err := rows.Scan(&k.ID, &k.Nama, &k.Lokasi, &k.Latitude, &k.Longitude, &k.Aktif, &k.DibuatPada, &k.DiperbaruiPada); err != nil {
+			return nil, err
+		}
+		kiosks = append(kiosks, k)
+	}
+	return kiosks, nil
+}
+
+// CreateKiosk registers a kiosk and returns its access token. The token is only shown once.
+func (s *KioskService) CreateKiosk(in KioskInput, createdBy int) (int, string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return 0, "", err
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return 0, "", err
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO kiosk (nama, lokasi, latitude, longitude, kunci_rahasia, token_akses, aktif, dibuat_oleh, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, TRUE, ?, NOW(), NOW())
+	`, in.Nama, in.Lokasi, in.Latitude, in.Longitude, secret, token, createdBy)
+	if err != nil {
+		return 0, "", err
+	}
+	id, _ := result.LastInsertId()
+	return int(id), token, nil
+}
+
+func (s *KioskService) UpdateKiosk(id int, in KioskInput) error {
+	aktif := true
+	if in.Aktif != nil {
+		aktif = *in.Aktif
+	}
+	result, err := database.DB.Exec(`
+		UPDATE kiosk
+		SET nama = ?, lokasi = ?, latitude = ?, longitude = ?, aktif = ?, diperbarui_pada = NOW()
+		WHERE id = ?
+	`, in.Nama, in.Lokasi, in.Latitude, in.Longitude, aktif, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.New("kiosk tidak ditemukan")
+	}
+	return nil
+}
+
+// RotateKioskKeys replaces the access token and signing key, e.g. after a tablet is lost.
+// Codes already on screen stop working immediately.
+func (s *KioskService) RotateKioskKeys(id int) (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	result, err := database.DB.Exec(
+		"UPDATE kiosk SET kunci_rahasia = ?, token_akses = ?, diperbarui_pada = NOW() WHERE id = ?",
+		secret, token, id,
+	)
+	if err != nil {
+		return "", err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return "", errors.New("kiosk tidak ditemukan")
+	}
+	return token, nil
+}
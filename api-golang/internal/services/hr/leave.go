@@ -1,10 +1,15 @@
 package hr
 
 import (
+	"database/sql"
+	"errors"
 	"log"
 	"time"
 
 	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+	"github.com/hris-system/api-golang/internal/services/leave"
 )
 
 type LeaveService struct{}
@@ -19,14 +24,27 @@ type LeaveRequest struct {
 	NamaLengkap        string     `json:"nama_lengkap"`
 	Divisi             *string    `json:"divisi"`
 	TipeCuti           string     `json:"tipe_cuti"`
+	NamaJenisCuti      *string    `json:"nama_jenis_cuti"`
+	Dibayar            *bool      `json:"dibayar"` // Whether the leave type is paid
 	TanggalMulai       string     `json:"tanggal_mulai"`
 	TanggalSelesai     string     `json:"tanggal_selesai"`
-	TotalHari          int        `json:"total_hari"`
+	SetengahHari       *string    `json:"setengah_hari"`
+	TotalHari          float64    `json:"total_hari"`
 	Alasan             string     `json:"alasan"`
 	Status             string     `json:"status"`
 	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
 	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
-	SisaCuti           int        `json:"sisa_cuti"`
+	SisaCuti           float64    `json:"sisa_cuti"`
+	WajibDokumen       bool       `json:"wajib_dokumen"` // The type's document rule applies to this request
+
+	// Supporting documents, previewed through GET /api/hr/cuti/:id/lampiran/:lampiran_id
+	Lampiran []models.LampiranCuti `json:"lampiran"`
+
+	// Days a pending request would leave the division below its minimal_karyawan_hadir
+	PeringatanStaf []leave.StaffingWarning `json:"peringatan_staf"`
+
+	// Approval steps; HR can only process once the line manager step is no longer menunggu
+	Persetujuan []models.PersetujuanCuti `json:"persetujuan"`
 }
 
 // GetAllLeaveRequests fetches all leave requests for HR
@@ -38,17 +56,22 @@ func (s *LeaveService) GetAllLeaveRequests() ([]LeaveRequest, error) {
 			p.nama_lengkap, 
 			d.nama as divisi,
 			pc.tipe_cuti, 
+			jc.nama,
+			jc.dibayar,
 			pc.tanggal_mulai, 
 			pc.tanggal_selesai, 
+			pc.setengah_hari,
 			pc.total_hari, 
 			pc.alasan, 
 			pc.status,
 			pc.tanggal_persetujuan, 
 			pc.catatan_persetujuan,
-			COALESCE(sc.sisa_hari, 0) as sisa_cuti
+			COALESCE(sc.sisa_hari, 0) as sisa_cuti,
+			COALESCE(jc.wajib_dokumen AND pc.total_hari >= jc.wajib_dokumen_minimal_hari, FALSE)
 		FROM pengajuan_cuti pc
 		JOIN pengguna p ON pc.pengguna_id = p.id
 		LEFT JOIN divisi d ON p.divisi_id = d.id
+		LEFT JOIN jenis_cuti jc ON pc.tipe_cuti = jc.kode
 		LEFT JOIN saldo_cuti sc ON p.id = sc.pengguna_id AND sc.tahun = YEAR(pc.tanggal_mulai)
 		ORDER BY 
 			CASE WHEN pc.status = 'menunggu' THEN 1 ELSE 2 END,
@@ -72,14 +95,18 @@ func (s *LeaveService) GetAllLeaveRequests() ([]LeaveRequest, error) {
 			&req.NamaLengkap,
 			&req.Divisi,
 			&req.TipeCuti,
+			&req.NamaJenisCuti,
+			&req.Dibayar,
 			&startDate,
 			&endDate,
+			&req.SetengahHari,
 			&req.TotalHari,
 			&req.Alasan,
 			&req.Status,
 			&req.TanggalPersetujuan,
 			&req.CatatanPersetujuan,
 			&req.SisaCuti,
+			&req.WajibDokumen,
 		)
 		if err != nil {
 			log.Println("Scan error:", err)
@@ -93,94 +120,198 @@ func (s *LeaveService) GetAllLeaveRequests() ([]LeaveRequest, error) {
 		requests = append(requests, req)
 	}
 
+	// Attachments for HR to preview before approving
+	ids := make([]int, len(requests))
+	for i, req := range requests {
+		ids[i] = req.ID
+	}
+	attachments, err := leave.AttachmentsOf(ids)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := leave.StepsOf(ids)
+	if err != nil {
+		return nil, err
+	}
+	pending := []leave.StaffingRequest{}
+	for _, req := range requests {
+		if req.Status != "menunggu" {
+			continue
+		}
+		r, err := staffingRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, r)
+	}
+	warnings, err := leave.StaffingWarnings(pending)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range requests {
+		requests[i].Lampiran = attachments[requests[i].ID]
+		if requests[i].Lampiran == nil {
+			requests[i].Lampiran = []models.LampiranCuti{}
+		}
+
+		requests[i].Persetujuan = steps[requests[i].ID]
+		if requests[i].Persetujuan == nil {
+			requests[i].Persetujuan = []models.PersetujuanCuti{}
+		}
+
+		requests[i].PeringatanStaf = warnings[requests[i].ID]
+		if requests[i].PeringatanStaf == nil {
+			requests[i].PeringatanStaf = []leave.StaffingWarning{}
+		}
+	}
+
 	return requests, nil
 }
 
-// ProcessLeaveRequest approves or rejects a leave request
-func (s *LeaveService) ProcessLeaveRequest(id int, status string, notes string, approvedBy int) error {
+// ProcessLeaveRequest approves or rejects a leave request. An approval that leaves the employee's
+// division below its minimum staffing still goes through; the short-staffed days are returned.
+func (s *LeaveService) ProcessLeaveRequest(id int, status string, notes string, approvedBy int) ([]leave.StaffingWarning, error) {
 	log.Printf("[ProcessLeaveRequest] Processing ID: %d, Status: %s, Approver: %d", id, status, approvedBy)
+	if status != "disetujui" && status != "ditolak" {
+		return nil, errors.New("status harus 'disetujui' atau 'ditolak'")
+	}
 
 	tx, err := database.DB.Begin()
 	if err != nil {
 		log.Printf("[ProcessLeaveRequest] Error starting tx: %v", err)
-		return err
+		return nil, err
 	}
 	// Defer rollback, will be ignored if committed
 	defer tx.Rollback()
 
+	// Requests routed to a line manager reach HR only after the manager approved
+	awaiting, err := leave.AwaitingManager(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if awaiting {
+		return nil, errors.New("pengajuan masih menunggu persetujuan atasan")
+	}
+
 	// 1. Update status
+	// Only pending requests: processing one twice would post its usage to the ledger twice, and
+	// requests cancelled by the employee or withdrawn after approval are closed
 	queryUpdate := `
 		UPDATE pengajuan_cuti 
 		SET status = ?, disetujui_oleh = ?, catatan_persetujuan = ?, tanggal_persetujuan = NOW()
-		WHERE id = ?
+		WHERE id = ? AND status = 'menunggu'
 	`
-	_, err = tx.Exec(queryUpdate, status, approvedBy, notes, id)
+	res, err := tx.Exec(queryUpdate, status, approvedBy, notes, id)
 	if err != nil {
 		log.Printf("[ProcessLeaveRequest] Error updating status: %v", err)
-		return err
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, errors.New("pengajuan tidak ditemukan atau sudah diproses")
+	}
+	if err := leave.CloseHRStep(tx, id, status, approvedBy, notes); err != nil {
+		log.Printf("[ProcessLeaveRequest] Error updating approval step: %v", err)
+		return nil, err
 	}
 
-	// 2. If approved and type includes 'cuti' (case insensitive), reduce balance
+	// 2. If approved and the leave type deducts the balance, reduce it
+	warnings := []leave.StaffingWarning{}
 	if status == "disetujui" {
-		var totalHari, penggunaID int
+		var penggunaID int
+		var totalHari float64
 		var tipeCuti string
 		var tanggalMulai time.Time // Use time.Time directly
+		var tanggalSelesai time.Time
+		var setengahHari sql.NullString
+		var potongSaldo bool
 
 		// Get request details
-		err = tx.QueryRow("SELECT pengguna_id, tipe_cuti, total_hari, tanggal_mulai FROM pengajuan_cuti WHERE id = ?", id).
-			Scan(&penggunaID, &tipeCuti, &totalHari, &tanggalMulai)
+		err = tx.QueryRow(`
+			SELECT pc.pengguna_id, pc.tipe_cuti, pc.total_hari, pc.tanggal_mulai, pc.tanggal_selesai, pc.setengah_hari, jc.potong_saldo
+			FROM pengajuan_cuti pc JOIN jenis_cuti jc ON pc.tipe_cuti = jc.kode
+			WHERE pc.id = ?
+		`, id).Scan(&penggunaID, &tipeCuti, &totalHari, &tanggalMulai, &tanggalSelesai, &setengahHari, &potongSaldo)
 		if err != nil {
 			log.Printf("[ProcessLeaveRequest] Error fetching details: %v", err)
-			return err
+			return nil, err
 		}
 
-		log.Printf("[ProcessLeaveRequest] Details - User: %d, Type: %s, Days: %d, Start: %v", penggunaID, tipeCuti, totalHari, tanggalMulai)
-
-		// Check if it's a leave type that consumes annual leave (e.g. "Cuti Tahunan" or "cuti")
-		// Ideally we should have a flag in 'tipe_cuti' config, but hardcoding for now based on known types
-		isAnnualLeave := tipeCuti == "cuti" || tipeCuti == "Cuti Tahunan"
-
-		if isAnnualLeave {
-			year := tanggalMulai.Year()
-			log.Printf("[ProcessLeaveRequest] Updating balance for Year: %d", year)
-
-			// Update balance
-			// NOTE: sisa_hari is a GENERATED ALWAYS AS (total_hari - hari_terpakai) STORED column
-			// So we ONLY update hari_terpakai
-			queryBalance := `
-				UPDATE saldo_cuti 
-				SET hari_terpakai = hari_terpakai + ?
-				WHERE pengguna_id = ? AND tahun = ?
-			`
-			result, err := tx.Exec(queryBalance, totalHari, penggunaID, year)
+		log.Printf("[ProcessLeaveRequest] Details - User: %d, Type: %s, Days: %.1f, Start: %v", penggunaID, tipeCuti, totalHari, tanggalMulai)
+
+		// jenis_cuti.potong_saldo decides whether the type consumes annual leave
+		if potongSaldo {
+			// The day breakdown decides how much of the leave falls in each year and before carried
+			// days expire
+			config, err := attendance.ActiveConfig()
 			if err != nil {
-				log.Printf("[ProcessLeaveRequest] Error updating balance: %v", err)
-				return err
+				return nil, err
+			}
+			duration, err := attendance.LeaveDurationFor(tanggalMulai.Format("2006-01-02"), tanggalSelesai.Format("2006-01-02"), setengahHari.String, config)
+			if err != nil {
+				log.Printf("[ProcessLeaveRequest] Error counting leave days: %v", err)
+				return nil, err
 			}
 
-			// Initialize balance if not exists (optional protection)
-			rowsAff, _ := result.RowsAffected()
-			if rowsAff == 0 {
-				log.Printf("[ProcessLeaveRequest] No balance row found, inserting default...")
-				// Insert default balance if missing (e.g., 12 days)
-				// Do NOT insert sisa_hari
-				_, err = tx.Exec(`
-					INSERT INTO saldo_cuti (pengguna_id, tahun, total_hari, hari_terpakai)
-					VALUES (?, ?, 12, ?)
-				`, penggunaID, year, totalHari)
-				if err != nil {
-					log.Printf("[ProcessLeaveRequest] Error inserting balance: %v", err)
-					return err
-				}
+			// Post the usage to each year's ledger, carried-over days first, creating a balance the
+			// accrual job has not yet. The balance is checked again under the saldo_cuti row lock and
+			// an overdraft rolls the approval back.
+			if err := leave.ConsumeLeave(tx, penggunaID, totalHari, duration, id); err != nil {
+				log.Printf("[ProcessLeaveRequest] Error updating balance: %v", err)
+				return nil, err
 			}
 		}
+
+		// 3. Warn when the division drops below its minimum staffing on any day of the leave
+		byID, err := leave.StaffingWarnings([]leave.StaffingRequest{{
+			ID: id, PenggunaID: penggunaID, Mulai: tanggalMulai, Selesai: tanggalSelesai, SetengahHari: setengahHari.Valid,
+		}})
+		if err != nil {
+			log.Printf("[ProcessLeaveRequest] Error checking staffing: %v", err)
+			return nil, err
+		}
+		warnings = byID[id]
 	}
 
 	if err := tx.Commit(); err != nil {
 		log.Printf("[ProcessLeaveRequest] Error committing tx: %v", err)
-		return err
+		return nil, err
 	}
 
 	log.Printf("[ProcessLeaveRequest] Success")
-	return nil
+	return warnings, nil
+}
+
+// staffingRequest turns a listed request into a staffing check. The dates are scanned as RFC 3339
+// strings, of which only the date part is used.
+func staffingRequest(req LeaveRequest) (leave.StaffingRequest, error) {
+	if len(req.TanggalMulai) < 10 || len(req.TanggalSelesai) < 10 {
+		return leave.StaffingRequest{}, errors.New("format tanggal tidak valid")
+	}
+	mulai, err := time.ParseInLocation("2006-01-02", req.TanggalMulai[:10], time.Local)
+	if err != nil {
+		return leave.StaffingRequest{}, err
+	}
+	selesai, err := time.ParseInLocation("2006-01-02", req.TanggalSelesai[:10], time.Local)
+	if err != nil {
+		return leave.StaffingRequest{}, err
+	}
+	return leave.StaffingRequest{
+		ID: req.ID, PenggunaID: req.PenggunaID, Mulai: mulai, Selesai: selesai, SetengahHari: req.SetengahHari != nil,
+	}, nil
+}
+
+// GetAttachment returns an attachment of a leave request and its storage key
+func (s *LeaveService) GetAttachment(pengajuanID, lampiranID int) (*models.LampiranCuti, string, error) {
+	return leave.AttachmentFile(pengajuanID, lampiranID)
+}
+
+// IssueCalendarToken creates the user's calendar feed token, revoking the previous one
+func (s *LeaveService) IssueCalendarToken(userID int) (string, error) {
+	return leave.IssueFeedToken(userID)
+}
+
+// RevokeCalendarToken ends the user's calendar feed subscriptions
+func (s *LeaveService) RevokeCalendarToken(userID int) error {
+	return leave.RevokeFeedToken(userID)
 }
@@ -0,0 +1,108 @@
+package hr
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+type LeaveBalance struct {
+	PenggunaID        int      `json:"pengguna_id"`
+	NamaLengkap       string   `json:"nama_lengkap"`
+	Divisi            *string  `json:"divisi"`
+	TanggalBergabung  string   `json:"tanggal_bergabung"`
+	Tahun             int      `json:"tahun"`
+	JatahTahunan      *float64 `json:"jatah_tahunan"`
+	TotalHari         float64  `json:"total_hari"`
+	HariTerpakai      float64  `json:"hari_terpakai"`
+	SisaHari          float64  `json:"sisa_hari"`
+	HariDibawa        float64  `json:"hari_dibawa"`
+	DibawaTerpakai    float64  `json:"dibawa_terpakai"`
+	HariHangus        float64  `json:"hari_hangus"`
+	DibawaKedaluwarsa *string  `json:"dibawa_kedaluwarsa"`
+	HariPenyesuaian   float64  `json:"hari_penyesuaian"`
+}
+
+// GetBalances lists the leave balances of a year for active employees, optionally of one division
+func (s *LeaveService) GetBalances(year, divisiID int) ([]LeaveBalance, error) {
+	query := `
+		SELECT p.id, p.nama_lengkap, d.nama, DATE_FORMAT(COALESCE(p.tanggal_bergabung, p.dibuat_pada), '%Y-%m-%d'),
+		       sc.tahun, sc.jatah_tahunan, sc.total_hari, sc.hari_terpakai, sc.sisa_hari,
+		       sc.hari_dibawa, sc.dibawa_terpakai, sc.hari_hangus, DATE_FORMAT(sc.dibawa_kedaluwarsa, '%Y-%m-%d'),
+		       sc.hari_penyesuaian
+		FROM saldo_cuti sc
+		JOIN pengguna p ON sc.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE sc.tahun = ? AND p.aktif = TRUE
+	`
+	args := []interface{}{year}
+	if divisiID != 0 {
+		query += " AND p.divisi_id = ?"
+		args = append(args, divisiID)
+	}
+	query += " ORDER BY p.nama_lengkap ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []LeaveBalance{}
+	for rows.Next() {
+		var b LeaveBalance
+		if err := rows.Scan(&b.PenggunaID, &b.NamaLengkap, &b.Divisi, &b.TanggalBergabung,
+			&b.Tahun, &b.JatahTahunan, &b.TotalHari, &b.HariTerpakai, &b.SisaHari,
+			&b.HariDibawa, &b.DibawaTerpakai, &b.HariHangus, &b.DibawaKedaluwarsa, &b.HariPenyesuaian); err != nil {
+			return nil, err
+		}
+		balances = append(balances, b)
+	}
+	return balances, nil
+}
+
+// GenerateBalances creates or refreshes every active employee's balance of the year from konfigurasi_cuti
+func (s *LeaveService) GenerateBalances(year int) (*leave.GenerateResult, error) {
+	if year < 2000 || year > 2100 {
+		return nil, errors.New("tahun tidak valid")
+	}
+	return leave.GenerateBalances(year, 0)
+}
+
+// RolloverBalances carries the unused leave of fromYear into the next year's balances
+func (s *LeaveService) RolloverBalances(fromYear int) (*leave.RolloverResult, error) {
+	if fromYear < 2000 || fromYear > 2100 {
+		return nil, errors.New("tahun tidak valid")
+	}
+	if fromYear >= time.Now().Year() {
+		return nil, errors.New("sisa cuti hanya dapat dibawa dari tahun yang sudah berakhir")
+	}
+	return leave.RolloverBalances(fromYear)
+}
+
+type BalanceAdjustmentInput struct {
+	PenggunaID int     `json:"pengguna_id" binding:"required"`
+	Tahun      int     `json:"tahun"` // Default this year
+	Hari       float64 `json:"hari"`  // Positive adds days, negative deducts them
+	Alasan     string  `json:"alasan" binding:"required"`
+}
+
+// AdjustBalance posts a manual adjustment with its reason to an employee's leave ledger
+func (s *LeaveService) AdjustBalance(in BalanceAdjustmentInput, adjustedBy int) (*models.SaldoCuti, error) {
+	if in.Tahun == 0 {
+		in.Tahun = time.Now().Year()
+	}
+	if in.Tahun < 2000 || in.Tahun > 2100 {
+		return nil, errors.New("tahun tidak valid")
+	}
+	return leave.Adjust(in.PenggunaID, in.Tahun, in.Hari, strings.TrimSpace(in.Alasan), adjustedBy)
+}
+
+// GetLedger lists the ledger entries behind an employee's leave balance of a year
+func (s *LeaveService) GetLedger(penggunaID, year int) ([]models.MutasiCuti, error) {
+	return leave.Ledger(penggunaID, year)
+}
@@ -0,0 +1,49 @@
+package hr
+
+import (
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+// GetTeamCalendar returns who is off when in a division, or across all divisions when divisiID is 0
+func (s *LeaveService) GetTeamCalendar(divisiID int, mulai, selesai string) (*leave.TeamCalendar, error) {
+	start, end, err := leave.CalendarRange(mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT p.id, p.nama_lengkap, d.nama FROM pengguna p LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE p.aktif = TRUE AND p.peran_id = 4
+	`
+	args := []interface{}{}
+	nama := "Kalender Cuti Semua Divisi"
+	if divisiID > 0 {
+		query += " AND p.divisi_id = ?"
+		args = append(args, divisiID)
+		var divisi string
+		if err := database.DB.QueryRow("SELECT nama FROM divisi WHERE id = ?", divisiID).Scan(&divisi); err == nil {
+			nama = "Kalender Cuti " + divisi
+		}
+	}
+	query += " ORDER BY d.nama ASC, p.nama_lengkap ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []leave.TeamMember{}
+	for rows.Next() {
+		var m leave.TeamMember
+		if err := rows.Scan(&m.PenggunaID, &m.NamaLengkap, &m.Divisi); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return leave.BuildTeamCalendar(nama, members, start, end)
+}
@@ -0,0 +1,112 @@
+package hr
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+type LeaveTypeInput struct {
+	Kode                    string   `json:"kode"` // Required on create, cannot be changed afterwards
+	Nama                    string   `json:"nama" binding:"required"`
+	PotongSaldo             bool     `json:"potong_saldo"`
+	Dibayar                 bool     `json:"dibayar"`
+	MaksHariPerPengajuan    *float64 `json:"maks_hari_per_pengajuan"`
+	MaksHariPerTahun        *float64 `json:"maks_hari_per_tahun"`
+	JenisKelamin            *string  `json:"jenis_kelamin"` // L / P, null = everyone
+	MasaKerjaMinimalBulan   int      `json:"masa_kerja_minimal_bulan"`
+	WajibDokumen            bool     `json:"wajib_dokumen"`
+	WajibDokumenMinimalHari float64  `json:"wajib_dokumen_minimal_hari"` // 0 = always when wajib_dokumen
+	Aktif                   *bool    `json:"aktif"`                      // Default true
+}
+
+var leaveTypeCode = regexp.MustCompile(`^[a-z][a-z0-9_]{1,29}$`)
+
+func (in *LeaveTypeInput) validate() error {
+	in.Nama = strings.TrimSpace(in.Nama)
+	if in.Nama == "" {
+		return errors.New("nama jenis cuti wajib diisi")
+	}
+	if in.MaksHariPerPengajuan != nil && *in.MaksHariPerPengajuan <= 0 {
+		return errors.New("maks_hari_per_pengajuan harus lebih dari 0")
+	}
+	if in.MaksHariPerTahun != nil && *in.MaksHariPerTahun <= 0 {
+		return errors.New("maks_hari_per_tahun harus lebih dari 0")
+	}
+	if in.JenisKelamin != nil && *in.JenisKelamin != "L" && *in.JenisKelamin != "P" {
+		return errors.New("jenis_kelamin harus 'L' atau 'P'")
+	}
+	if in.MasaKerjaMinimalBulan < 0 {
+		return errors.New("masa_kerja_minimal_bulan tidak boleh negatif")
+	}
+	if in.WajibDokumenMinimalHari < 0 {
+		return errors.New("wajib_dokumen_minimal_hari tidak boleh negatif")
+	}
+	return nil
+}
+
+// GetLeaveTypes lists the whole leave type catalogue, inactive types included
+func (s *LeaveService) GetLeaveTypes() ([]models.JenisCuti, error) {
+	return leave.Types(false)
+}
+
+// CreateLeaveType adds a leave type to the catalogue
+func (s *LeaveService) CreateLeaveType(in LeaveTypeInput) error {
+	in.Kode = strings.ToLower(strings.TrimSpace(in.Kode))
+	if !leaveTypeCode.MatchString(in.Kode) {
+		return errors.New("kode harus 2-30 karakter huruf kecil, angka atau garis bawah")
+	}
+	if err := in.validate(); err != nil {
+		return err
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM jenis_cuti WHERE kode = ?", in.Kode).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return errors.New("kode jenis cuti sudah digunakan")
+	}
+
+	aktif := in.Aktif == nil || *in.Aktif
+	_, err := database.DB.Exec(`
+		INSERT INTO jenis_cuti (kode, nama, potong_saldo, dibayar, maks_hari_per_pengajuan, maks_hari_per_tahun,
+			jenis_kelamin, masa_kerja_minimal_bulan, wajib_dokumen, wajib_dokumen_minimal_hari, aktif, dibuat_pada, diperbarui_pada)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+	`, in.Kode, in.Nama, in.PotongSaldo, in.Dibayar, in.MaksHariPerPengajuan, in.MaksHariPerTahun,
+		in.JenisKelamin, in.MasaKerjaMinimalBulan, in.WajibDokumen, in.WajibDokumenMinimalHari, aktif)
+	return err
+}
+
+// UpdateLeaveType changes the settings of a leave type; requests already submitted keep their kode
+func (s *LeaveService) UpdateLeaveType(id int, in LeaveTypeInput) error {
+	if err := in.validate(); err != nil {
+		return err
+	}
+
+	aktif := in.Aktif == nil || *in.Aktif
+	res, err := database.DB.Exec(`
+		UPDATE jenis_cuti SET nama = ?, potong_saldo = ?, dibayar = ?, maks_hari_per_pengajuan = ?, maks_hari_per_tahun = ?,
+			jenis_kelamin = ?, masa_kerja_minimal_bulan = ?, wajib_dokumen = ?, wajib_dokumen_minimal_hari = ?, aktif = ?,
+			diperbarui_pada = NOW()
+		WHERE id = ?
+	`, in.Nama, in.PotongSaldo, in.Dibayar, in.MaksHariPerPengajuan, in.MaksHariPerTahun,
+		in.JenisKelamin, in.MasaKerjaMinimalBulan, in.WajibDokumen, in.WajibDokumenMinimalHari, aktif, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists int
+		if err := database.DB.QueryRow("SELECT COUNT(*) FROM jenis_cuti WHERE id = ?", id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return errors.New("jenis cuti tidak ditemukan")
+		}
+	}
+	return nil
+}
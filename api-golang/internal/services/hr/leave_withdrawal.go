@@ -0,0 +1,171 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+type LeaveWithdrawal struct {
+	ID                 int        `json:"id"`
+	PengajuanCutiID    int        `json:"pengajuan_cuti_id"`
+	PenggunaID         int        `json:"pengguna_id"`
+	NamaLengkap        string     `json:"nama_lengkap"`
+	Divisi             *string    `json:"divisi"`
+	TipeCuti           string     `json:"tipe_cuti"`
+	TanggalMulai       string     `json:"tanggal_mulai"`
+	TanggalSelesai     string     `json:"tanggal_selesai"`
+	TanggalSelesaiBaru *string    `json:"tanggal_selesai_baru"` // nil = whole leave withdrawn
+	HariDikembalikan   float64    `json:"hari_dikembalikan"`
+	Alasan             string     `json:"alasan"`
+	Status             string     `json:"status"`
+	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
+	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
+	DibuatPada         time.Time  `json:"dibuat_pada"`
+}
+
+// GetWithdrawals lists leave withdrawal requests, optionally filtered by status
+func (s *LeaveService) GetWithdrawals(status string) ([]LeaveWithdrawal, error) {
+	query := `
+		SELECT t.id, t.pengajuan_cuti_id, t.pengguna_id, p.nama_lengkap, d.nama, pc.tipe_cuti,
+		       DATE_FORMAT(pc.tanggal_mulai, '%Y-%m-%d'), DATE_FORMAT(pc.tanggal_selesai, '%Y-%m-%d'),
+		       DATE_FORMAT(t.tanggal_selesai_baru, '%Y-%m-%d'), t.hari_dikembalikan, t.alasan, t.status,
+		       t.tanggal_persetujuan, t.catatan_persetujuan, t.dibuat_pada
+		FROM penarikan_cuti t
+		JOIN pengajuan_cuti pc ON t.pengajuan_cuti_id = pc.id
+		JOIN pengguna p ON t.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	if status != "" {
+		query += " AND t.status = ?"
+		args = append(args, status)
+	}
+	query += `
+		ORDER BY
+			CASE WHEN t.status = 'menunggu' THEN 1 ELSE 2 END,
+			t.dibuat_pada DESC
+	`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	withdrawals := []LeaveWithdrawal{}
+	for rows.Next() {
+		var w LeaveWithdrawal
+		if err := rows.Scan(&w.ID, &w.PengajuanCutiID, &w.PenggunaID, &w.NamaLengkap, &w.Divisi, &w.TipeCuti,
+			&w.TanggalMulai, &w.TanggalSelesai, &w.TanggalSelesaiBaru, &w.HariDikembalikan, &w.Alasan, &w.Status,
+			&w.TanggalPersetujuan, &w.CatatanPersetujuan, &w.DibuatPada); err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	return withdrawals, nil
+}
+
+// ProcessWithdrawal acknowledges or rejects a leave withdrawal. Acknowledging shortens or withdraws
+// the leave, restores the refunded days to saldo_cuti and removes the leave presensi rows of the
+// withdrawn dates.
+func (s *LeaveService) ProcessWithdrawal(id int, status string, notes string, processedBy int) error {
+	if status != "disetujui" && status != "ditolak" {
+		return errors.New("status harus 'disetujui' atau 'ditolak'")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var pengajuanID int
+	var selesaiBaru sql.NullString
+	var currentStatus string
+	err = tx.QueryRow(`
+		SELECT pengajuan_cuti_id, DATE_FORMAT(tanggal_selesai_baru, '%Y-%m-%d'), status
+		FROM penarikan_cuti WHERE id = ? FOR UPDATE
+	`, id).Scan(&pengajuanID, &selesaiBaru, &currentStatus)
+	if err == sql.ErrNoRows {
+		return errors.New("penarikan cuti tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+	if currentStatus != "menunggu" {
+		return errors.New("penarikan cuti sudah diproses")
+	}
+
+	var refund *float64
+	if status == "disetujui" {
+		days, err := applyWithdrawal(tx, pengajuanID, selesaiBaru.String)
+		if err != nil {
+			return err
+		}
+		refund = &days
+	}
+
+	_, err = tx.Exec(`
+		UPDATE penarikan_cuti
+		SET status = ?, hari_dikembalikan = COALESCE(?, hari_dikembalikan), diproses_oleh = ?,
+		    catatan_persetujuan = ?, tanggal_persetujuan = NOW(), diperbarui_pada = NOW()
+		WHERE id = ?
+	`, status, refund, processedBy, notes, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyWithdrawal re-plans the withdrawal against the leave as it is today, so dates that have
+// passed since the employee asked are no longer refunded, and returns the refunded days
+func applyWithdrawal(tx *sql.Tx, pengajuanID int, tanggalSelesaiBaru string) (float64, error) {
+	var penggunaID int
+	var mulai, selesai time.Time
+	var setengahHari *string
+	var totalHari float64
+	var status string
+	var potongSaldo bool
+	err := tx.QueryRow(`
+		SELECT pc.pengguna_id, pc.tanggal_mulai, pc.tanggal_selesai, pc.setengah_hari, pc.total_hari, pc.status, jc.potong_saldo
+		FROM pengajuan_cuti pc JOIN jenis_cuti jc ON pc.tipe_cuti = jc.kode
+		WHERE pc.id = ? FOR UPDATE
+	`, pengajuanID).Scan(&penggunaID, &mulai, &selesai, &setengahHari, &totalHari, &status, &potongSaldo)
+	if err != nil {
+		return 0, err
+	}
+	if status != "disetujui" {
+		return 0, errors.New("cuti tidak lagi berstatus disetujui")
+	}
+
+	w, err := leave.PlanWithdrawal(mulai, selesai, setengahHari, totalHari, tanggalSelesaiBaru, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	if w.TanggalSelesaiBaru == nil {
+		_, err = tx.Exec("UPDATE pengajuan_cuti SET status = 'ditarik', diperbarui_pada = NOW() WHERE id = ?", pengajuanID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE pengajuan_cuti SET tanggal_selesai = ?, total_hari = ?, diperbarui_pada = NOW() WHERE id = ?
+		`, *w.TanggalSelesaiBaru, w.SisaHari, pengajuanID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if potongSaldo && w.HariDikembalikan > 0 {
+		if _, err := leave.Refund(tx, penggunaID, mulai, selesai, w.HariDikembalikan, pengajuanID); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := leave.ClearLeavePresensi(tx, penggunaID, w.DitarikMulai, w.DitarikSelesai); err != nil {
+		return 0, err
+	}
+	return w.HariDikembalikan, nil
+}
@@ -1,9 +1,14 @@
 package hr
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
 )
 
 type MonitoringService struct{}
@@ -21,64 +26,284 @@ type PresensiItem struct {
 	WaktuMasuk  *time.Time `json:"waktu_masuk"`
 	WaktuPulang *time.Time `json:"waktu_pulang"`
 	Status      string     `json:"status"`
+	ModeKerja   *string    `json:"mode_kerja"` // kantor / wfh / dinas, null without presensi
+	NamaLibur   *string    `json:"nama_libur"` // Holiday falling on the day, if any
 	Catatan     *string    `json:"catatan"`
+	FotoMasuk   *string    `json:"foto_masuk"`  // URL of the clock-in selfie, if any
+	FotoPulang  *string    `json:"foto_pulang"` // URL of the clock-out selfie, if any
 }
 
-// GetDailyMonitoring returns attendance list for a specific date
-func (s *MonitoringService) GetDailyMonitoring(date string) ([]PresensiItem, error) {
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+// photoURL points HR at the authenticated photo endpoint instead of exposing storage keys
+func photoURL(presensiID int, jenis string, key *string) *string {
+	if key == nil {
+		return nil
 	}
+	url := fmt.Sprintf("/api/hr/presensi/%d/foto/%s", presensiID, jenis)
+	return &url
+}
+
+// MonitoringFilter selects the rows of the attendance monitor. Every employee gets a row for
+// every day of the range; days without presensi show as libur on a hari_libur date or a weekday
+// outside the working schedule, dinas when covered by an approved business trip and tidak_hadir
+// otherwise.
+type MonitoringFilter struct {
+	TanggalMulai   string   // YYYY-MM-DD, default today
+	TanggalSelesai string   // YYYY-MM-DD, default TanggalMulai
+	DivisiID       int      // 0 = all divisions
+	Status         []string // hadir, terlambat, tidak_hadir, izin, cuti, dinas, libur
+	TanpaPulang    bool     // Clocked in without clocking out
+	ModeKerja      string   // kantor / wfh / dinas, "" = all
+	Cari           string   // Name or division contains
+	Urutkan        string   // tanggal, nama, divisi, waktu_masuk, waktu_pulang, status
+	Arah           string   // asc / desc
+	Halaman        int
+	PerHalaman     int
+}
+
+type MonitoringResult struct {
+	Data       []PresensiItem `json:"data"`
+	Total      int            `json:"total"`
+	Halaman    int            `json:"halaman"`
+	PerHalaman int            `json:"per_halaman"`
+	Ringkasan  map[string]int `json:"ringkasan"` // Row count per status over the whole filtered set
+}
+
+const (
+	maxMonitoringDays    = 92
+	defaultMonitoringRow = 50
+	maxMonitoringRow     = 500
+)
+
+var monitoringSortColumns = map[string]string{
+	"tanggal":      "m.tanggal",
+	"nama":         "m.nama_lengkap",
+	"divisi":       "m.divisi",
+	"waktu_masuk":  "m.waktu_masuk",
+	"waktu_pulang": "m.waktu_pulang",
+	"status":       "m.status",
+}
+
+var monitoringStatuses = map[string]bool{
+	"hadir": true, "terlambat": true, "tidak_hadir": true, "izin": true, "cuti": true, "dinas": true, "libur": true,
+}
 
-	// Query: Get all employees and their attendance for the date
-	// Left Join to include employees who haven't clocked in (status will be NULL/calculated)
+// monitoringSource builds the filtered employee x day set as a derived table "m". offDays are the
+// dates of the range that are not working days under the attendance schedule.
+func monitoringSource(f MonitoringFilter, offDays []string) (string, []interface{}) {
+	offDayCase := ""
+	if len(offDays) > 0 {
+		offDayCase = "WHEN h.tanggal IN (?" + strings.Repeat(", ?", len(offDays)-1) + ") THEN 'libur' -- Non-working weekday"
+	}
 	query := `
-		SELECT 
-			p.id as pengguna_id,
-			p.nama_lengkap,
-			d.nama as divisi,
-			COALESCE(pr.id, 0) as id,
-			COALESCE(pr.tanggal, ?) as tanggal,
-			pr.waktu_masuk,
-			pr.waktu_pulang,
-			COALESCE(pr.status, 'tidak_hadir') as status, -- Default 'tidak_hadir' if no record
-			pr.catatan
-		FROM pengguna p
-		LEFT JOIN divisi d ON p.divisi_id = d.id
-		LEFT JOIN presensi pr ON p.id = pr.pengguna_id AND pr.tanggal = ?
-		WHERE p.peran_id = 4 -- Only Karyawan
-		AND p.aktif = TRUE
-		ORDER BY p.nama_lengkap ASC
+		WITH RECURSIVE hari (tanggal) AS (
+			SELECT CAST(? AS DATE)
+			UNION ALL
+			SELECT tanggal + INTERVAL 1 DAY FROM hari WHERE tanggal < CAST(? AS DATE)
+		)
+		SELECT %s FROM (
+			SELECT
+				p.id as pengguna_id,
+				p.nama_lengkap,
+				d.nama as divisi,
+				COALESCE(pr.id, 0) as id,
+				h.tanggal,
+				pr.waktu_masuk,
+				pr.waktu_pulang,
+				COALESCE(pr.status, CASE
+					WHEN hl.id IS NOT NULL THEN 'libur' -- Holiday without presensi
+					` + offDayCase + `
+					WHEN EXISTS (
+						SELECT 1 FROM perjalanan_dinas t
+						WHERE t.pengguna_id = p.id AND t.status = 'disetujui'
+						AND h.tanggal BETWEEN t.tanggal_mulai AND t.tanggal_selesai
+					) THEN 'dinas' -- Approved business trip without presensi
+					ELSE 'tidak_hadir'
+				END) as status,
+				pr.mode_kerja,
+				hl.nama as nama_libur,
+				pr.catatan,
+				pr.foto_masuk,
+				pr.foto_pulang
+			FROM pengguna p
+			CROSS JOIN hari h
+			LEFT JOIN divisi d ON p.divisi_id = d.id
+			LEFT JOIN presensi pr ON p.id = pr.pengguna_id AND pr.tanggal = h.tanggal
+			LEFT JOIN hari_libur hl ON hl.tanggal = h.tanggal
+			WHERE p.peran_id = 4 -- Only Karyawan
+			AND p.aktif = TRUE
 	`
+	args := []interface{}{f.TanggalMulai, f.TanggalSelesai}
+	for _, d := range offDays {
+		args = append(args, d)
+	}
+	if f.DivisiID != 0 {
+		query += " AND p.divisi_id = ?"
+		args = append(args, f.DivisiID)
+	}
+	if f.Cari != "" {
+		query += " AND (p.nama_lengkap LIKE ? OR d.nama LIKE ?)"
+		like := "%" + f.Cari + "%"
+		args = append(args, like, like)
+	}
+	query += `
+		) m
+		WHERE 1=1
+	`
+	if len(f.Status) > 0 {
+		query += " AND m.status IN (?" + strings.Repeat(", ?", len(f.Status)-1) + ")"
+		for _, st := range f.Status {
+			args = append(args, st)
+		}
+	}
+	if f.TanpaPulang {
+		query += " AND m.waktu_masuk IS NOT NULL AND m.waktu_pulang IS NULL"
+	}
+	if f.ModeKerja != "" {
+		query += " AND m.mode_kerja = ?"
+		args = append(args, f.ModeKerja)
+	}
+	return query, args
+}
+
+// GetMonitoring returns one page of the attendance monitor for a date range with per-status totals
+func (s *MonitoringService) GetMonitoring(f MonitoringFilter) (*MonitoringResult, error) {
+	if f.TanggalMulai == "" {
+		f.TanggalMulai = time.Now().Format("2006-01-02")
+	}
+	if f.TanggalSelesai == "" {
+		f.TanggalSelesai = f.TanggalMulai
+	}
+	start, err := time.Parse("2006-01-02", f.TanggalMulai)
+	if err != nil {
+		return nil, errors.New("format tanggal_mulai tidak valid (YYYY-MM-DD)")
+	}
+	end, err := time.Parse("2006-01-02", f.TanggalSelesai)
+	if err != nil {
+		return nil, errors.New("format tanggal_selesai tidak valid (YYYY-MM-DD)")
+	}
+	if end.Before(start) {
+		return nil, errors.New("tanggal_selesai tidak boleh sebelum tanggal_mulai")
+	}
+	if end.Sub(start).Hours()/24 >= maxMonitoringDays {
+		return nil, fmt.Errorf("rentang tanggal maksimal %d hari", maxMonitoringDays)
+	}
+	for _, st := range f.Status {
+		if !monitoringStatuses[st] {
+			return nil, fmt.Errorf("status '%s' tidak dikenal", st)
+		}
+	}
+	switch f.ModeKerja {
+	case "", attendance.ModeKantor, attendance.ModeWFH, attendance.ModeDinas:
+	default:
+		return nil, errors.New("mode_kerja harus 'kantor', 'wfh', atau 'dinas'")
+	}
+	if f.Halaman < 1 {
+		f.Halaman = 1
+	}
+	if f.PerHalaman < 1 {
+		f.PerHalaman = defaultMonitoringRow
+	}
+	if f.PerHalaman > maxMonitoringRow {
+		f.PerHalaman = maxMonitoringRow
+	}
+
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	var offDays []string
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if !attendance.IsWorkday(day, config) {
+			offDays = append(offDays, day.Format("2006-01-02"))
+		}
+	}
 
-	rows, err := database.DB.Query(query, date, date)
+	source, args := monitoringSource(f, offDays)
+	result := &MonitoringResult{Halaman: f.Halaman, PerHalaman: f.PerHalaman, Ringkasan: map[string]int{}, Data: []PresensiItem{}}
+	for st := range monitoringStatuses {
+		result.Ringkasan[st] = 0
+	}
+
+	// Per-status counts; their sum is the total for pagination
+	rows, err := database.DB.Query(fmt.Sprintf(source, "m.status, COUNT(*)")+" GROUP BY m.status", args...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.Ringkasan[status] = count
+		result.Total += count
+	}
+	rows.Close()
+
+	sortColumn, ok := monitoringSortColumns[f.Urutkan]
+	if !ok {
+		sortColumn = "m.tanggal"
+	}
+	direction := "ASC"
+	if strings.EqualFold(f.Arah, "desc") {
+		direction = "DESC"
+	}
+	query := fmt.Sprintf(source, `m.pengguna_id, m.nama_lengkap, m.divisi, m.id, DATE_FORMAT(m.tanggal, '%%Y-%%m-%%d'),
+		m.waktu_masuk, m.waktu_pulang, m.status, m.mode_kerja, m.nama_libur, m.catatan, m.foto_masuk, m.foto_pulang`) +
+		fmt.Sprintf(" ORDER BY %s %s, m.nama_lengkap ASC, m.tanggal ASC LIMIT ? OFFSET ?", sortColumn, direction)
+	args = append(args, f.PerHalaman, (f.Halaman-1)*f.PerHalaman)
+
+	rows, err = database.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var results []PresensiItem
 	for rows.Next() {
 		var item PresensiItem
-		var tanggalStr string
+		var fotoMasuk, fotoPulang *string
 
 		err := rows.Scan(
 			&item.PenggunaID,
 			&item.NamaLengkap,
 			&item.Divisi,
 			&item.ID,
-			&tanggalStr,
+			&item.Tanggal,
 			&item.WaktuMasuk,
 			&item.WaktuPulang,
 			&item.Status,
+			&item.ModeKerja,
+			&item.NamaLibur,
 			&item.Catatan,
+			&fotoMasuk,
+			&fotoPulang,
 		)
 		if err != nil {
 			return nil, err
 		}
-		item.Tanggal = date
-		results = append(results, item)
+		item.FotoMasuk = photoURL(item.ID, "masuk", fotoMasuk)
+		item.FotoPulang = photoURL(item.ID, "pulang", fotoPulang)
+		result.Data = append(result.Data, item)
 	}
 
-	return results, nil
+	return result, nil
+}
+
+// GetPhotoKey returns the storage key of a presensi selfie (jenis: masuk / pulang)
+func (s *MonitoringService) GetPhotoKey(presensiID int, jenis string) (string, error) {
+	column := map[string]string{"masuk": "foto_masuk", "pulang": "foto_pulang"}[jenis]
+	if column == "" {
+		return "", errors.New("jenis foto harus 'masuk' atau 'pulang'")
+	}
+
+	var key sql.NullString
+	err := database.DB.QueryRow("SELECT "+column+" FROM presensi WHERE id = ?", presensiID).Scan(&key)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if !key.Valid {
+		return "", errors.New("foto presensi tidak ditemukan")
+	}
+	return key.String, nil
 }
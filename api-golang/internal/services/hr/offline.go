@@ -0,0 +1,216 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+type OfflinePunchService struct{}
+
+func NewOfflinePunchService() *OfflinePunchService {
+	return &OfflinePunchService{}
+}
+
+type OfflinePunchItem struct {
+	ID              int        `json:"id"`
+	PenggunaID      int        `json:"pengguna_id"`
+	NamaLengkap     string     `json:"nama_lengkap"`
+	Divisi          *string    `json:"divisi"`
+	PerangkatID     string     `json:"perangkat_id"`
+	Jenis           string     `json:"jenis"`
+	WaktuPerangkat  time.Time  `json:"waktu_perangkat"`
+	WaktuTerkoreksi time.Time  `json:"waktu_terkoreksi"`
+	SelisihJamDetik int        `json:"selisih_jam_detik"`
+	Latitude        *float64   `json:"latitude"`
+	Longitude       *float64   `json:"longitude"`
+	Akurasi         *float64   `json:"akurasi"`
+	Status          string     `json:"status"` // diterima, menunggu, disetujui, ditolak
+	AlasanPenahanan *string    `json:"alasan_penahanan"`
+	PresensiID      *int       `json:"presensi_id"`
+	Catatan         *string    `json:"catatan"`
+	DiprosesPada    *time.Time `json:"diproses_pada"`
+	DibuatPada      time.Time  `json:"dibuat_pada"`
+}
+
+// GetOfflinePunches lists synced offline punches, pending ones first
+func (s *OfflinePunchService) GetOfflinePunches(status string) ([]OfflinePunchItem, error) {
+	query := `
+		SELECT
+			o.id,
+			o.pengguna_id,
+			p.nama_lengkap,
+			d.nama as divisi,
+			o.perangkat_id,
+			o.jenis,
+			o.waktu_perangkat,
+			o.waktu_terkoreksi,
+			o.selisih_jam_detik,
+			o.latitude,
+			o.longitude,
+			o.akurasi,
+			o.status,
+			o.alasan_penahanan,
+			o.presensi_id,
+			o.catatan,
+			o.diproses_pada,
+			o.dibuat_pada
+		FROM presensi_offline o
+		JOIN pengguna p ON o.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+	`
+	args := []interface{}{}
+	if status != "" {
+		query += " WHERE o.status = ?"
+		args = append(args, status)
+	}
+	query += `
+		ORDER BY
+			CASE WHEN o.status = 'menunggu' THEN 1 ELSE 2 END,
+			o.waktu_terkoreksi DESC
+	`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []OfflinePunchItem
+	for rows.Next() {
+		var o OfflinePunchItem
+		err := rows.Scan(
+			&o.ID,
+			&o.PenggunaID,
+			&o.NamaLengkap,
+			&o.Divisi,
+			&o.PerangkatID,
+			&o.Jenis,
+			&o.WaktuPerangkat,
+			&o.WaktuTerkoreksi,
+			&o.SelisihJamDetik,
+			&o.Latitude,
+			&o.Longitude,
+			&o.Akurasi,
+			&o.Status,
+			&o.AlasanPenahanan,
+			&o.PresensiID,
+			&o.Catatan,
+			&o.DiprosesPada,
+			&o.DibuatPada,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, o)
+	}
+	return items, nil
+}
+
+// ProcessOfflinePunch approves or rejects a held offline punch.
+// Approval writes the punch at its corrected time, replacing what the day already has, and is audited.
+func (s *OfflinePunchService) ProcessOfflinePunch(id int, status string, notes string, processedBy int) error {
+	if status != "disetujui" && status != "ditolak" {
+		return errors.New("status harus 'disetujui' atau 'ditolak'")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var penggunaID int
+	var perangkatID, jenis, currentStatus string
+	var waktu time.Time
+	var lat, long, akurasi *float64
+	var alasan sql.NullString
+	err = tx.QueryRow(`
+		SELECT pengguna_id, perangkat_id, jenis, waktu_terkoreksi, latitude, longitude, akurasi, status, alasan_penahanan
+		FROM presensi_offline WHERE id = ? FOR UPDATE
+	`, id).Scan(&penggunaID, &perangkatID, &jenis, &waktu, &lat, &long, &akurasi, &currentStatus, &alasan)
+	if err == sql.ErrNoRows {
+		return errors.New("presensi offline tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+	if currentStatus != "menunggu" {
+		return errors.New("presensi offline sudah diproses")
+	}
+
+	var presensiID *int
+	if status == "disetujui" {
+		config, err := attendance.ActiveConfig()
+		if err != nil {
+			return err
+		}
+		// A punch away from the office on an approved trip / WFH day keeps that mode, even if HR
+		// approves it outside the registered region
+		mode := attendance.ModeKantor
+		if lat != nil && long != nil &&
+			attendance.DistanceMeters(*lat, *long, config.LatitudeKantor, config.LongitudeKantor) > float64(config.RadiusMeter) {
+			trip, err := attendance.ApprovedTrip(penggunaID, waktu)
+			if err != nil {
+				return err
+			}
+			remote, err := attendance.ApprovedRemote(penggunaID, waktu)
+			if err != nil {
+				return err
+			}
+			switch {
+			case trip != nil:
+				mode = attendance.ModeDinas
+			case remote != nil:
+				mode = attendance.ModeWFH
+			}
+		}
+		pid, change, err := attendance.WritePunch(tx, attendance.PunchRecord{
+			PenggunaID:  penggunaID,
+			Jenis:       jenis,
+			Waktu:       waktu,
+			Latitude:    lat,
+			Longitude:   long,
+			Akurasi:     akurasi,
+			PerangkatID: &perangkatID,
+			Sumber:      "offline",
+			ModeKerja:   mode,
+		}, config, true)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE presensi SET disinkron_pada = NOW() WHERE id = ?", pid); err != nil {
+			return err
+		}
+
+		change.ReferensiID = &id
+		change.Alasan = "Presensi offline disetujui HR"
+		if alasan.Valid {
+			change.Alasan += ": " + alasan.String
+		}
+		change.DiubahOleh = processedBy
+		if err := attendance.RecordChange(tx, change); err != nil {
+			return err
+		}
+		presensiID = &pid
+	}
+
+	_, err = tx.Exec(`
+		UPDATE presensi_offline
+		SET status = ?, catatan = ?, presensi_id = COALESCE(?, presensi_id), diproses_oleh = ?, diproses_pada = NOW()
+		WHERE id = ?
+	`, status, notes, presensiID, processedBy, id)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if presensiID != nil {
+		attendance.PublishPunch(*presensiID, jenis)
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package hr
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
 )
 
 type PayrollService struct{}
@@ -11,16 +15,29 @@ func NewPayrollService() *PayrollService {
 }
 
 type PayrollDraft struct {
-	ID            int     `json:"id"`
-	PenggunaID    int     `json:"pengguna_id"`
-	NamaLengkap   string  `json:"nama_lengkap"`
-	Divisi        *string `json:"divisi"`
-	Bulan         int     `json:"bulan"`
-	Tahun         int     `json:"tahun"`
-	GajiPokok     float64 `json:"gaji_pokok"`
-	TotalPotongan float64 `json:"total_potongan"`
-	GajiBersih    float64 `json:"gaji_bersih"`
-	Status        string  `json:"status"`
+	ID             int     `json:"id"`
+	PenggunaID     int     `json:"pengguna_id"`
+	NamaLengkap    string  `json:"nama_lengkap"`
+	Divisi         *string `json:"divisi"`
+	Bulan          int     `json:"bulan"`
+	Tahun          int     `json:"tahun"`
+	GajiPokok      float64 `json:"gaji_pokok"`
+	TotalPotongan  float64 `json:"total_potongan"`
+	TotalTunjangan float64 `json:"total_tunjangan"`
+	GajiBersih     float64 `json:"gaji_bersih"`
+	Status         string  `json:"status"`
+	HariKerja      int     `json:"hari_kerja"` // Working days of the payroll month, holidays excluded
+}
+
+// WorkingDays counts the working days of a payroll month from the attendance schedule and the
+// holiday calendar
+func (s *PayrollService) WorkingDays(month, year int) (int, error) {
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		return 0, err
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	return attendance.WorkingDays(start, start.AddDate(0, 1, -1), config)
 }
 
 // GetPayrollDrafts fetches all payroll records with status 'draft' for a specific month/year
@@ -35,6 +52,7 @@ func (s *PayrollService) GetPayrollDrafts(month, year int) ([]PayrollDraft, erro
 			p.tahun,
 			p.gaji_pokok,
 			p.total_potongan,
+			p.total_tunjangan,
 			p.gaji_bersih,
 			p.status
 		FROM penggajian p
@@ -44,6 +62,11 @@ func (s *PayrollService) GetPayrollDrafts(month, year int) ([]PayrollDraft, erro
 		ORDER BY u.nama_lengkap ASC
 	`
 
+	hariKerja, err := s.WorkingDays(month, year)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := database.DB.Query(query, month, year)
 	if err != nil {
 		return nil, err
@@ -62,12 +85,14 @@ func (s *PayrollService) GetPayrollDrafts(month, year int) ([]PayrollDraft, erro
 			&draft.Tahun,
 			&draft.GajiPokok,
 			&draft.TotalPotongan,
+			&draft.TotalTunjangan,
 			&draft.GajiBersih,
 			&draft.Status,
 		)
 		if err != nil {
 			return nil, err
 		}
+		draft.HariKerja = hariKerja
 		drafts = append(drafts, draft)
 	}
 
@@ -86,6 +111,7 @@ func (s *PayrollService) GetPayrollDetails(month, year int, status string) ([]Pa
 			p.tahun,
 			p.gaji_pokok,
 			p.total_potongan,
+			p.total_tunjangan,
 			p.gaji_bersih,
 			p.status
 		FROM penggajian p
@@ -103,6 +129,11 @@ func (s *PayrollService) GetPayrollDetails(month, year int, status string) ([]Pa
 
 	query += " ORDER BY u.nama_lengkap ASC"
 
+	hariKerja, err := s.WorkingDays(month, year)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := database.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
@@ -121,27 +152,103 @@ func (s *PayrollService) GetPayrollDetails(month, year int, status string) ([]Pa
 			&d.Tahun,
 			&d.GajiPokok,
 			&d.TotalPotongan,
+			&d.TotalTunjangan,
 			&d.GajiBersih,
 			&d.Status,
 		)
 		if err != nil {
 			return nil, err
 		}
+		d.HariKerja = hariKerja
 		details = append(details, d)
 	}
 
 	return details, nil
 }
 
-// SendToFinance updates status of all drafts in a month to 'dikirim_ke_keuangan'
+// SendToFinance updates status of all drafts in a month to 'dikirim_ke_keuangan'.
+// Business trip per-diems are settled into the drafts first.
 func (s *PayrollService) SendToFinance(month, year int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := applyTripAllowances(tx, month, year); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE penggajian 
 		SET status = 'dikirim_ke_keuangan', dikirim_ke_keuangan_pada = NOW() 
 		WHERE bulan = ? AND tahun = ? AND status = 'draft'
 	`
-	_, err := database.DB.Exec(query, month, year)
-	return err
+	if _, err := tx.Exec(query, month, year); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ApplyTripAllowances recomputes the business trip per-diems of the month's draft payrolls
+// and returns how many allowance lines were written
+func (s *PayrollService) ApplyTripAllowances(month, year int) (int, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := applyTripAllowances(tx, month, year)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// applyTripAllowances rebuilds the per-diem lines of draft payrolls: every approved trip day that
+// falls inside the month pays the trip's uang_harian. Payrolls already sent to finance are left alone.
+func applyTripAllowances(tx *sql.Tx, month, year int) (int, error) {
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	start := first.Format("2006-01-02")
+	end := first.AddDate(0, 1, -1).Format("2006-01-02")
+
+	_, err := tx.Exec(`
+		DELETE dt FROM detail_tunjangan_gaji dt
+		JOIN penggajian g ON dt.penggajian_id = g.id
+		WHERE g.bulan = ? AND g.tahun = ? AND g.status = 'draft' AND dt.perjalanan_dinas_id IS NOT NULL
+	`, month, year)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO detail_tunjangan_gaji (penggajian_id, perjalanan_dinas_id, deskripsi, jumlah, dibuat_pada)
+		SELECT g.id, t.id,
+		       CONCAT('Uang harian dinas ', t.tujuan, ' (', DATEDIFF(LEAST(t.tanggal_selesai, ?), GREATEST(t.tanggal_mulai, ?)) + 1, ' hari)'),
+		       (DATEDIFF(LEAST(t.tanggal_selesai, ?), GREATEST(t.tanggal_mulai, ?)) + 1) * t.uang_harian,
+		       NOW()
+		FROM penggajian g
+		JOIN perjalanan_dinas t ON t.pengguna_id = g.pengguna_id
+		WHERE g.bulan = ? AND g.tahun = ? AND g.status = 'draft'
+		AND t.status = 'disetujui' AND t.uang_harian > 0
+		AND t.tanggal_mulai <= ? AND t.tanggal_selesai >= ?
+	`, end, start, end, start, month, year, end, start)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+
+	_, err = tx.Exec(`
+		UPDATE penggajian g
+		SET g.total_tunjangan = (SELECT COALESCE(SUM(dt.jumlah), 0) FROM detail_tunjangan_gaji dt WHERE dt.penggajian_id = g.id),
+		    g.gaji_bersih = g.gaji_pokok - g.total_potongan + g.total_tunjangan
+		WHERE g.bulan = ? AND g.tahun = ? AND g.status = 'draft'
+	`, month, year)
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
 }
 
 // GetPayrollHistory fetches history of sent payrolls grouped by month
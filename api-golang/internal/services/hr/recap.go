@@ -0,0 +1,94 @@
+package hr
+
+import (
+	"fmt"
+
+	"github.com/hris-system/api-golang/internal/export"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+type RecapService struct{}
+
+func NewRecapService() *RecapService {
+	return &RecapService{}
+}
+
+// GetMonthlyRecap returns the monthly timesheet of one employee (penggunaID) or a whole division (divisiID)
+func (s *RecapService) GetMonthlyRecap(year, month, penggunaID, divisiID int) ([]attendance.EmployeeRecap, error) {
+	return attendance.MonthlyRecap(year, month, penggunaID, divisiID)
+}
+
+// RecapTable flattens timesheets into one row per employee-day followed by a total row per employee,
+// for CSV / XLSX download
+func (s *RecapService) RecapTable(year, month int, recaps []attendance.EmployeeRecap) export.Table {
+	t := export.Table{
+		Sheet: fmt.Sprintf("Rekap %04d-%02d", year, month),
+		Headers: []string{
+			"ID Pengguna", "Nama", "Divisi", "Tanggal", "Hari", "Status", "Mode Kerja", "Jam Masuk", "Jam Pulang",
+			"Menit Terlambat", "Tingkat Terlambat", "Menit Pulang Cepat", "Menit Istirahat", "Jam Kerja", "Menit Lembur",
+			"Jenis Cuti", "Tujuan Dinas", "Hari Libur", "Keterangan",
+		},
+	}
+	for _, r := range recaps {
+		divisi := ""
+		if r.Divisi != nil {
+			divisi = *r.Divisi
+		}
+		for _, d := range r.Hari {
+			jenisCuti, keterangan, libur, tingkat, mode, tujuan := "", "", "", "", "", ""
+			if d.TujuanDinas != nil {
+				tujuan = *d.TujuanDinas
+			}
+			if d.ModeKerja != nil {
+				mode = *d.ModeKerja
+			}
+			if d.TingkatTerlambat != nil {
+				tingkat = *d.TingkatTerlambat
+			}
+			if d.JenisCuti != nil {
+				jenisCuti = *d.JenisCuti
+			}
+			if d.Keterangan != nil {
+				keterangan = *d.Keterangan
+			}
+			if d.NamaLibur != nil {
+				libur = *d.NamaLibur
+			} else if d.Libur {
+				libur = "ya"
+			}
+			masuk, pulang := "", ""
+			if d.WaktuMasuk != nil {
+				masuk = d.WaktuMasuk.Format("15:04")
+			}
+			if d.WaktuPulang != nil {
+				pulang = d.WaktuPulang.Format("15:04")
+			}
+			t.Rows = append(t.Rows, []interface{}{
+				r.PenggunaID, r.NamaLengkap, divisi, d.Tanggal, d.Hari, d.Status, mode, masuk, pulang,
+				d.MenitTerlambat, tingkat, d.MenitPulangCepat, d.MenitIstirahat, d.JamKerja, d.MenitLembur,
+				jenisCuti, tujuan, libur, keterangan,
+			})
+		}
+
+		tot := r.Total
+		t.Rows = append(t.Rows, []interface{}{
+			r.PenggunaID, r.NamaLengkap, divisi, "TOTAL", "",
+			fmt.Sprintf("hari kerja %d, hadir %d, terlambat %d, dinas %d, tidak hadir %d, izin %d, cuti %d, tanpa presensi pulang %d",
+				tot.HariKerja, tot.Hadir, tot.Terlambat, tot.Dinas, tot.TidakHadir, tot.Izin, tot.Cuti, tot.TanpaPresensiPulang),
+			fmt.Sprintf("wfh %d", tot.HariWFH), "", "", tot.TotalMenitTerlambat, fmt.Sprintf("setengah hari %d", tot.TerlambatSetengahHari),
+			tot.TotalMenitPulangCepat, tot.TotalMenitIstirahat, tot.TotalJamKerja, tot.TotalMenitLembur, "", "", tot.Libur,
+			fmt.Sprintf("istirahat melebihi batas %d menit", tot.TotalIstirahatLebih),
+		})
+	}
+	return t
+}
+
+// GetLatenessSummary returns the monthly late days per employee and tier, for payroll deductions and warning letters
+func (s *RecapService) GetLatenessSummary(year, month, penggunaID, divisiID int) ([]attendance.LatenessSummary, error) {
+	return attendance.MonthlyLateness(year, month, penggunaID, divisiID)
+}
+
+// GetLatenessTiers lists the configured lateness tiers
+func (s *RecapService) GetLatenessTiers() ([]attendance.LatenessTier, error) {
+	return attendance.LatenessTiers()
+}
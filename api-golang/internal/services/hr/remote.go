@@ -0,0 +1,136 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+type RemoteWorkService struct{}
+
+func NewRemoteWorkService() *RemoteWorkService {
+	return &RemoteWorkService{}
+}
+
+type RemoteWorkRequest struct {
+	ID                 int        `json:"id"`
+	PenggunaID         int        `json:"pengguna_id"`
+	NamaLengkap        string     `json:"nama_lengkap"`
+	Divisi             *string    `json:"divisi"`
+	TanggalMulai       string     `json:"tanggal_mulai"`
+	TanggalSelesai     string     `json:"tanggal_selesai"`
+	Alasan             string     `json:"alasan"`
+	Alamat             *string    `json:"alamat"`
+	Latitude           *float64   `json:"latitude"`
+	Longitude          *float64   `json:"longitude"`
+	TanpaRadius        bool       `json:"tanpa_radius"`
+	Status             string     `json:"status"`
+	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
+	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
+	DibuatPada         time.Time  `json:"dibuat_pada"`
+}
+
+// GetRemoteWorkRequests fetches WFH requests, optionally filtered by status
+func (s *RemoteWorkService) GetRemoteWorkRequests(status string) ([]RemoteWorkRequest, error) {
+	query := `
+		SELECT
+			w.id,
+			w.pengguna_id,
+			p.nama_lengkap,
+			d.nama as divisi,
+			DATE_FORMAT(w.tanggal_mulai, '%Y-%m-%d'),
+			DATE_FORMAT(w.tanggal_selesai, '%Y-%m-%d'),
+			w.alasan,
+			w.alamat,
+			w.latitude,
+			w.longitude,
+			w.tanpa_radius,
+			w.status,
+			w.tanggal_persetujuan,
+			w.catatan_persetujuan,
+			w.dibuat_pada
+		FROM pengajuan_wfh w
+		JOIN pengguna p ON w.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	if status != "" {
+		query += " AND w.status = ?"
+		args = append(args, status)
+	}
+	query += `
+		ORDER BY
+			CASE WHEN w.status = 'menunggu' THEN 1 ELSE 2 END,
+			w.tanggal_mulai ASC
+	`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []RemoteWorkRequest
+	for rows.Next() {
+		var r RemoteWorkRequest
+		err := rows.Scan(
+			&r.ID,
+			&r.PenggunaID,
+			&r.NamaLengkap,
+			&r.Divisi,
+			&r.TanggalMulai,
+			&r.TanggalSelesai,
+			&r.Alasan,
+			&r.Alamat,
+			&r.Latitude,
+			&r.Longitude,
+			&r.TanpaRadius,
+			&r.Status,
+			&r.TanggalPersetujuan,
+			&r.CatatanPersetujuan,
+			&r.DibuatPada,
+		)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+// ProcessRemoteWork approves or rejects a WFH request. Clock-ins on approved days are checked against
+// the remote location instead of the office. A request without a location is only approved when HR
+// explicitly allows clock-in from anywhere (tanpaRadius).
+func (s *RemoteWorkService) ProcessRemoteWork(id int, status string, notes string, tanpaRadius bool, processedBy int) error {
+	if status != "disetujui" && status != "ditolak" {
+		return errors.New("status harus 'disetujui' atau 'ditolak'")
+	}
+
+	var currentStatus string
+	var latitude sql.NullFloat64
+	err := database.DB.QueryRow("SELECT status, latitude FROM pengajuan_wfh WHERE id = ?", id).Scan(&currentStatus, &latitude)
+	if err == sql.ErrNoRows {
+		return errors.New("pengajuan WFH tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+	if currentStatus != "menunggu" {
+		return errors.New("pengajuan WFH sudah diproses")
+	}
+	if latitude.Valid || status != "disetujui" {
+		tanpaRadius = false
+	} else if !tanpaRadius {
+		return errors.New("pengajuan WFH tidak memiliki lokasi; setujui dengan tanpa_radius agar presensi diterima dari mana saja")
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE pengajuan_wfh
+		SET status = ?, tanpa_radius = ?, diproses_oleh = ?, catatan_persetujuan = ?, tanggal_persetujuan = NOW(), diperbarui_pada = NOW()
+		WHERE id = ? AND status = 'menunggu'
+	`, status, tanpaRadius, processedBy, notes, id)
+	return err
+}
@@ -0,0 +1,117 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/leave"
+)
+
+type ReportingLineService struct{}
+
+func NewReportingLineService() *ReportingLineService {
+	return &ReportingLineService{}
+}
+
+type ReportingLine struct {
+	PenggunaID    int     `json:"pengguna_id"`
+	NamaLengkap   string  `json:"nama_lengkap"`
+	Divisi        *string `json:"divisi"`
+	AtasanID      *int    `json:"atasan_id"`
+	NamaAtasan    *string `json:"nama_atasan"`
+	JumlahBawahan int     `json:"jumlah_bawahan"` // Active direct reports
+}
+
+// GetReportingLines lists active employees with their line manager, optionally for one division
+func (s *ReportingLineService) GetReportingLines(divisiID int) ([]ReportingLine, error) {
+	query := `
+		SELECT p.id, p.nama_lengkap, d.nama, p.atasan_id, a.nama_lengkap,
+		       (SELECT COUNT(*) FROM pengguna b WHERE b.atasan_id = p.id AND b.aktif = TRUE)
+		FROM pengguna p
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		LEFT JOIN pengguna a ON p.atasan_id = a.id
+		WHERE p.peran_id = 4 AND p.aktif = TRUE
+	`
+	args := []interface{}{}
+	if divisiID > 0 {
+		query += " AND p.divisi_id = ?"
+		args = append(args, divisiID)
+	}
+	query += " ORDER BY d.nama ASC, p.nama_lengkap ASC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := []ReportingLine{}
+	for rows.Next() {
+		var l ReportingLine
+		if err := rows.Scan(&l.PenggunaID, &l.NamaLengkap, &l.Divisi, &l.AtasanID, &l.NamaAtasan, &l.JumlahBawahan); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// SetManager sets or clears (atasanID nil) an employee's line manager. Leave requests still waiting
+// on the previous manager move to the new one, or straight to HR when the manager is cleared.
+func (s *ReportingLineService) SetManager(penggunaID int, atasanID *int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM pengguna WHERE id = ?", penggunaID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return errors.New("karyawan tidak ditemukan")
+	}
+
+	if atasanID != nil {
+		if *atasanID == penggunaID {
+			return errors.New("karyawan tidak dapat menjadi atasan dirinya sendiri")
+		}
+		// Managers approve through the employee app, so they must be active employees
+		if err := tx.QueryRow("SELECT COUNT(*) FROM pengguna WHERE id = ? AND peran_id = 4 AND aktif = TRUE", *atasanID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return errors.New("atasan tidak ditemukan, tidak aktif, atau bukan karyawan")
+		}
+
+		// Walk up from the new manager; reaching the employee would close a loop
+		seen := map[int]bool{}
+		for current := *atasanID; ; {
+			var next sql.NullInt64
+			if err := tx.QueryRow("SELECT atasan_id FROM pengguna WHERE id = ?", current).Scan(&next); err != nil {
+				return err
+			}
+			if !next.Valid {
+				break
+			}
+			current = int(next.Int64)
+			if current == penggunaID {
+				return errors.New("atasan tersebut berada di bawah karyawan ini dalam garis pelaporan")
+			}
+			if seen[current] {
+				break
+			}
+			seen[current] = true
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE pengguna SET atasan_id = ? WHERE id = ?", atasanID, penggunaID); err != nil {
+		return err
+	}
+	if err := leave.ReassignManagerSteps(tx, penggunaID, atasanID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
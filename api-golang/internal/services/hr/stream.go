@@ -0,0 +1,60 @@
+package hr
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/realtime"
+)
+
+// EventStatistik carries the refreshed DashboardService.GetStats counters on the live feed
+const EventStatistik = "statistik"
+
+// Counters are recomputed at most this often, however many punches arrive on a busy morning
+const statsInterval = 2 * time.Second
+
+var statsOnce sync.Once
+
+type StreamService struct{}
+
+func NewStreamService() *StreamService {
+	return &StreamService{}
+}
+
+// Subscribe joins the attendance live feed. The first subscriber starts the counter refresher.
+func (s *StreamService) Subscribe() (<-chan realtime.Event, func()) {
+	statsOnce.Do(func() { go refreshStats() })
+	return realtime.Attendance.Subscribe()
+}
+
+// refreshStats republishes the dashboard counters after punches, so every HR client shares
+// one query instead of each running its own
+func refreshStats() {
+	events, _ := realtime.Attendance.Subscribe()
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	dirty := false
+	dashboard := NewDashboardService()
+	for {
+		select {
+		case e := <-events:
+			if e.Jenis != EventStatistik {
+				dirty = true
+			}
+		case <-ticker.C:
+			// The refresher is a subscriber itself
+			if !dirty || realtime.Attendance.Subscribers() <= 1 {
+				continue
+			}
+			dirty = false
+			stats, err := dashboard.GetStats()
+			if err != nil {
+				log.Printf("[refreshStats] Error computing dashboard stats: %v", err)
+				continue
+			}
+			realtime.Attendance.Publish(realtime.Event{Jenis: EventStatistik, Data: stats})
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package hr
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+type BusinessTripService struct{}
+
+func NewBusinessTripService() *BusinessTripService {
+	return &BusinessTripService{}
+}
+
+type BusinessTrip struct {
+	ID                 int        `json:"id"`
+	PenggunaID         int        `json:"pengguna_id"`
+	NamaLengkap        string     `json:"nama_lengkap"`
+	Divisi             *string    `json:"divisi"`
+	TanggalMulai       string     `json:"tanggal_mulai"`
+	TanggalSelesai     string     `json:"tanggal_selesai"`
+	JumlahHari         int        `json:"jumlah_hari"`
+	Tujuan             string     `json:"tujuan"`
+	Keperluan          string     `json:"keperluan"`
+	LatitudeTujuan     *float64   `json:"latitude_tujuan"`
+	LongitudeTujuan    *float64   `json:"longitude_tujuan"`
+	RadiusKm           int        `json:"radius_km"`
+	UangHarian         float64    `json:"uang_harian"`
+	TotalUangHarian    float64    `json:"total_uang_harian"`
+	Status             string     `json:"status"`
+	TanggalPersetujuan *time.Time `json:"tanggal_persetujuan"`
+	CatatanPersetujuan *string    `json:"catatan_persetujuan"`
+	DibuatPada         time.Time  `json:"dibuat_pada"`
+}
+
+// GetTrips fetches business trips, optionally filtered by status
+func (s *BusinessTripService) GetTrips(status string) ([]BusinessTrip, error) {
+	query := `
+		SELECT
+			t.id,
+			t.pengguna_id,
+			p.nama_lengkap,
+			d.nama as divisi,
+			DATE_FORMAT(t.tanggal_mulai, '%Y-%m-%d'),
+			DATE_FORMAT(t.tanggal_selesai, '%Y-%m-%d'),
+			DATEDIFF(t.tanggal_selesai, t.tanggal_mulai) + 1,
+			t.tujuan,
+			t.keperluan,
+			t.latitude_tujuan,
+			t.longitude_tujuan,
+			t.radius_km,
+			t.uang_harian,
+			t.status,
+			t.tanggal_persetujuan,
+			t.catatan_persetujuan,
+			t.dibuat_pada
+		FROM perjalanan_dinas t
+		JOIN pengguna p ON t.pengguna_id = p.id
+		LEFT JOIN divisi d ON p.divisi_id = d.id
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	if status != "" {
+		query += " AND t.status = ?"
+		args = append(args, status)
+	}
+	query += `
+		ORDER BY
+			CASE WHEN t.status = 'menunggu' THEN 1 ELSE 2 END,
+			t.tanggal_mulai DESC
+	`
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trips []BusinessTrip
+	for rows.Next() {
+		var t BusinessTrip
+		err := rows.Scan(
+			&t.ID,
+			&t.PenggunaID,
+			&t.NamaLengkap,
+			&t.Divisi,
+			&t.TanggalMulai,
+			&t.TanggalSelesai,
+			&t.JumlahHari,
+			&t.Tujuan,
+			&t.Keperluan,
+			&t.LatitudeTujuan,
+			&t.LongitudeTujuan,
+			&t.RadiusKm,
+			&t.UangHarian,
+			&t.Status,
+			&t.TanggalPersetujuan,
+			&t.CatatanPersetujuan,
+			&t.DibuatPada,
+		)
+		if err != nil {
+			return nil, err
+		}
+		t.TotalUangHarian = t.UangHarian * float64(t.JumlahHari)
+		trips = append(trips, t)
+	}
+	return trips, nil
+}
+
+// ProcessTrip approves or rejects a business trip. uangHarian is the per-diem per trip day
+// set on approval; it is paid through the draft payroll of each month the trip covers.
+func (s *BusinessTripService) ProcessTrip(id int, status string, notes string, uangHarian float64, approvedBy int) error {
+	if status != "disetujui" && status != "ditolak" {
+		return errors.New("status harus 'disetujui' atau 'ditolak'")
+	}
+	if uangHarian < 0 {
+		return errors.New("uang harian tidak boleh negatif")
+	}
+	if status == "ditolak" {
+		uangHarian = 0
+	}
+
+	var currentStatus string
+	err := database.DB.QueryRow("SELECT status FROM perjalanan_dinas WHERE id = ?", id).Scan(&currentStatus)
+	if err == sql.ErrNoRows {
+		return errors.New("perjalanan dinas tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+	if currentStatus != "menunggu" {
+		return errors.New("perjalanan dinas sudah diproses")
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE perjalanan_dinas
+		SET status = ?, uang_harian = ?, disetujui_oleh = ?, catatan_persetujuan = ?, tanggal_persetujuan = NOW(), diperbarui_pada = NOW()
+		WHERE id = ? AND status = 'menunggu'
+	`, status, uangHarian, approvedBy, notes, id)
+	return err
+}
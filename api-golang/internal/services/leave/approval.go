@@ -0,0 +1,148 @@
+package leave
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// CreateSteps routes a new leave request inside tx: to the employee's line manager first when
+// they have one who is still an active employee, then to HR
+func CreateSteps(tx *sql.Tx, pengajuanID, penggunaID int) error {
+	var atasanID sql.NullInt64
+	err := tx.QueryRow(`
+		SELECT a.id FROM pengguna p JOIN pengguna a ON p.atasan_id = a.id AND a.peran_id = 4 AND a.aktif = TRUE
+		WHERE p.id = ?
+	`, penggunaID).Scan(&atasanID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	tahap := 1
+	if atasanID.Valid {
+		_, err = tx.Exec(`
+			INSERT INTO persetujuan_cuti (pengajuan_cuti_id, tahap, peran, penyetuju_id, status)
+			VALUES (?, ?, 'atasan', ?, 'menunggu')
+		`, pengajuanID, tahap, atasanID.Int64)
+		if err != nil {
+			return err
+		}
+		tahap++
+	}
+	_, err = tx.Exec(`
+		INSERT INTO persetujuan_cuti (pengajuan_cuti_id, tahap, peran, status)
+		VALUES (?, ?, 'hr', 'menunggu')
+	`, pengajuanID, tahap)
+	return err
+}
+
+// StepsOf returns the approval steps of the given leave requests, keyed by pengajuan_cuti_id
+func StepsOf(pengajuanIDs []int) (map[int][]models.PersetujuanCuti, error) {
+	result := map[int][]models.PersetujuanCuti{}
+	if len(pengajuanIDs) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, len(pengajuanIDs))
+	for i, id := range pengajuanIDs {
+		args[i] = id
+	}
+	rows, err := database.DB.Query(`
+		SELECT s.id, s.pengajuan_cuti_id, s.tahap, s.peran, s.penyetuju_id, p.nama_lengkap,
+		       s.status, s.diproses_oleh, s.catatan, s.diproses_pada
+		FROM persetujuan_cuti s
+		LEFT JOIN pengguna p ON s.penyetuju_id = p.id
+		WHERE s.pengajuan_cuti_id IN (?`+strings.Repeat(", ?", len(pengajuanIDs)-1)+`)
+		ORDER BY s.pengajuan_cuti_id ASC, s.tahap ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.PersetujuanCuti
+		if err := rows.Scan(&s.ID, &s.PengajuanCutiID, &s.Tahap, &s.Peran, &s.PenyetujuID, &s.NamaPenyetuju,
+			&s.Status, &s.DiprosesOleh, &s.Catatan, &s.DiprosesPada); err != nil {
+			return nil, err
+		}
+		result[s.PengajuanCutiID] = append(result[s.PengajuanCutiID], s)
+	}
+	return result, rows.Err()
+}
+
+// ActingFor lists the managers whose leave approvals the user can process on date: the user
+// themselves and every manager with an active delegation to the user covering that date
+func ActingFor(penggunaID int, date time.Time) ([]int, error) {
+	rows, err := database.DB.Query(`
+		SELECT DISTINCT pengguna_id FROM delegasi_persetujuan
+		WHERE delegasi_id = ? AND aktif = TRUE AND ? BETWEEN tanggal_mulai AND tanggal_selesai
+	`, penggunaID, date.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{penggunaID}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// AwaitingManager reports whether the leave request still waits for its line manager inside tx
+func AwaitingManager(tx *sql.Tx, pengajuanID int) (bool, error) {
+	var pending int
+	err := tx.QueryRow(`
+		SELECT COUNT(*) FROM persetujuan_cuti
+		WHERE pengajuan_cuti_id = ? AND peran = 'atasan' AND status = 'menunggu'
+	`, pengajuanID).Scan(&pending)
+	return pending > 0, err
+}
+
+// CloseHRStep records HR's decision on the HR step of a leave request. Requests submitted before
+// approval routing have no steps and are left as they are.
+func CloseHRStep(tx *sql.Tx, pengajuanID int, status string, processedBy int, notes string) error {
+	_, err := tx.Exec(`
+		UPDATE persetujuan_cuti SET status = ?, diproses_oleh = ?, catatan = ?, diproses_pada = NOW()
+		WHERE pengajuan_cuti_id = ? AND peran = 'hr' AND status = 'menunggu'
+	`, status, processedBy, notes, pengajuanID)
+	return err
+}
+
+// SkipPendingSteps marks the steps still waiting on a request that is closed early (rejected by
+// the line manager or cancelled by the employee) as dilewati
+func SkipPendingSteps(tx *sql.Tx, pengajuanID int) error {
+	_, err := tx.Exec(`
+		UPDATE persetujuan_cuti SET status = 'dilewati', diproses_pada = NOW()
+		WHERE pengajuan_cuti_id = ? AND status = 'menunggu'
+	`, pengajuanID)
+	return err
+}
+
+// ReassignManagerSteps moves the employee's requests still waiting on a line manager to
+// atasanID, or straight to HR when the employee no longer has a manager
+func ReassignManagerSteps(tx *sql.Tx, penggunaID int, atasanID *int) error {
+	var err error
+	if atasanID != nil {
+		_, err = tx.Exec(`
+			UPDATE persetujuan_cuti s JOIN pengajuan_cuti pc ON s.pengajuan_cuti_id = pc.id
+			SET s.penyetuju_id = ?
+			WHERE pc.pengguna_id = ? AND pc.status = 'menunggu' AND s.peran = 'atasan' AND s.status = 'menunggu'
+		`, *atasanID, penggunaID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE persetujuan_cuti s JOIN pengajuan_cuti pc ON s.pengajuan_cuti_id = pc.id
+			SET s.status = 'dilewati', s.catatan = 'Karyawan tidak lagi memiliki atasan', s.diproses_pada = NOW()
+			WHERE pc.pengguna_id = ? AND pc.status = 'menunggu' AND s.peran = 'atasan' AND s.status = 'menunggu'
+		`, penggunaID)
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package leave
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/storage"
+)
+
+const (
+	maxAttachmentBytes = 5 << 20 // 5 MB per file
+	maxAttachments     = 5
+)
+
+var attachmentExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+}
+
+// Attachment is a validated supporting document (doctor's note, marriage certificate, ...)
+type Attachment struct {
+	NamaBerkas  string
+	Data        []byte
+	ContentType string
+}
+
+// ReadAttachment reads an uploaded document, enforcing the size limit and sniffing the real content type
+func ReadAttachment(name string, r io.Reader) (*Attachment, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxAttachmentBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("lampiran %s kosong", name)
+	}
+	if len(data) > maxAttachmentBytes {
+		return nil, fmt.Errorf("ukuran lampiran maksimal %d MB", maxAttachmentBytes>>20)
+	}
+	contentType := http.DetectContentType(data)
+	if _, ok := attachmentExtensions[contentType]; !ok {
+		return nil, errors.New("format lampiran harus PDF, JPEG, PNG, atau WEBP")
+	}
+
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "lampiran" + attachmentExtensions[contentType]
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return &Attachment{NamaBerkas: name, Data: data, ContentType: contentType}, nil
+}
+
+// CheckAttachmentCount limits the number of files per request. Handlers call it before reading
+// any upload, so a request with many large files is refused without buffering them.
+func CheckAttachmentCount(n int) error {
+	if n > maxAttachments {
+		return fmt.Errorf("maksimal %d lampiran per pengajuan", maxAttachments)
+	}
+	return nil
+}
+
+// CheckAttachments enforces the leave type's document rule and the number of files per request
+func CheckAttachments(t *models.JenisCuti, days float64, files []*Attachment) error {
+	if err := CheckAttachmentCount(len(files)); err != nil {
+		return err
+	}
+	if DocumentRequired(t, days) && len(files) == 0 {
+		if t.WajibDokumenMinimalHari > 0 {
+			return fmt.Errorf("%s %g hari atau lebih wajib melampirkan dokumen pendukung", t.Nama, t.WajibDokumenMinimalHari)
+		}
+		return fmt.Errorf("%s wajib melampirkan dokumen pendukung", t.Nama)
+	}
+	return nil
+}
+
+// DocumentRequired reports whether a request of days of the type needs a supporting document
+func DocumentRequired(t *models.JenisCuti, days float64) bool {
+	return t.WajibDokumen && days >= t.WajibDokumenMinimalHari
+}
+
+// StoreAttachments saves the files of a leave request in the storage backend and records them
+// inside tx. The returned keys are already stored; the caller removes them with DeleteAttachments
+// when tx does not commit.
+func StoreAttachments(tx *sql.Tx, pengajuanID, uploadedBy int, files []*Attachment) ([]string, error) {
+	var keys []string
+	now := time.Now()
+	for i, f := range files {
+		key := fmt.Sprintf("cuti/%s/%d/%d-%d%s", now.Format("2006/01"), pengajuanID, now.UnixNano(), i, attachmentExtensions[f.ContentType])
+		if err := storage.Store.Put(key, bytes.NewReader(f.Data), int64(len(f.Data)), f.ContentType); err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+
+		_, err := tx.Exec(`
+			INSERT INTO lampiran_cuti (pengajuan_cuti_id, nama_berkas, kunci_penyimpanan, tipe_konten, ukuran, diunggah_oleh, dibuat_pada)
+			VALUES (?, ?, ?, ?, ?, ?, NOW())
+		`, pengajuanID, f.NamaBerkas, key, f.ContentType, len(f.Data), uploadedBy)
+		if err != nil {
+			return keys, err
+		}
+	}
+	return keys, nil
+}
+
+// DeleteAttachments removes stored files, logging instead of failing
+func DeleteAttachments(keys []string) {
+	for _, key := range keys {
+		if err := storage.Store.Delete(key); err != nil {
+			log.Printf("[DeleteAttachments] Error deleting %s: %v", key, err)
+		}
+	}
+}
+
+// AttachmentsOf lists the attachments of the given leave requests keyed by request ID
+func AttachmentsOf(pengajuanIDs []int) (map[int][]models.LampiranCuti, error) {
+	result := map[int][]models.LampiranCuti{}
+	if len(pengajuanIDs) == 0 {
+		return result, nil
+	}
+
+	args := make([]interface{}, len(pengajuanIDs))
+	for i, id := range pengajuanIDs {
+		args[i] = id
+	}
+	rows, err := database.DB.Query(`
+		SELECT id, pengajuan_cuti_id, nama_berkas, tipe_konten, ukuran, dibuat_pada
+		FROM lampiran_cuti
+		WHERE pengajuan_cuti_id IN (?`+strings.Repeat(", ?", len(pengajuanIDs)-1)+`)
+		ORDER BY id ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l models.LampiranCuti
+		if err := rows.Scan(&l.ID, &l.PengajuanCutiID, &l.NamaBerkas, &l.TipeKonten, &l.Ukuran, &l.DibuatPada); err != nil {
+			return nil, err
+		}
+		result[l.PengajuanCutiID] = append(result[l.PengajuanCutiID], l)
+	}
+	return result, rows.Err()
+}
+
+// AttachmentFile looks up one attachment of a leave request for download
+func AttachmentFile(pengajuanID, lampiranID int) (*models.LampiranCuti, string, error) {
+	var l models.LampiranCuti
+	var key string
+	err := database.DB.QueryRow(`
+		SELECT id, pengajuan_cuti_id, nama_berkas, tipe_konten, ukuran, dibuat_pada, kunci_penyimpanan
+		FROM lampiran_cuti WHERE id = ? AND pengajuan_cuti_id = ?
+	`, lampiranID, pengajuanID).Scan(&l.ID, &l.PengajuanCutiID, &l.NamaBerkas, &l.TipeKonten, &l.Ukuran, &l.DibuatPada, &key)
+	if err == sql.ErrNoRows {
+		return nil, "", errors.New("lampiran tidak ditemukan")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &l, key, nil
+}
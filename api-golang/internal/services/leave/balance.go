@@ -0,0 +1,277 @@
+package leave
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+// Values of konfigurasi_cuti.akrual
+const (
+	AkrualTahunan = "tahunan" // The whole year's entitlement at once
+	AkrualBulanan = "bulanan" // One twelfth per month, credited on the first of the month
+)
+
+// Fallbacks for divisions without konfigurasi_cuti: the statutory minimum of 12 days after
+// 12 months of continuous service (UU 13/2003 pasal 79)
+const (
+	defaultJatahCuti      = 12
+	defaultMasaKerjaBulan = 12
+)
+
+// Fallbacks for the year-end carry-over: up to 6 unused days, to be used by the end of June
+const (
+	defaultMaksDibawa       = 6
+	defaultBulanKedaluwarsa = 6
+)
+
+// Entitlement is the annual leave of one employee for one year
+type Entitlement struct {
+	PenggunaID    int       `json:"pengguna_id"`
+	Tahun         int       `json:"tahun"`
+	Akrual        string    `json:"akrual"`
+	JatahDivisi   float64   `json:"jatah_divisi"`   // konfigurasi_cuti.jatah_cuti_tahunan
+	JatahTahunan  float64   `json:"jatah_tahunan"`  // Prorated for the months the employee is entitled
+	TotalHari     float64   `json:"total_hari"`     // Credited so far
+	TanggalBerhak time.Time `json:"tanggal_berhak"` // Join date + masa_kerja_minimal_bulan
+}
+
+// GenerateResult summarises one balance generation run
+type GenerateResult struct {
+	Tahun    int           `json:"tahun"`
+	Karyawan int           `json:"karyawan"`
+	Saldo    []Entitlement `json:"saldo"`
+}
+
+type policy struct {
+	penggunaID  int
+	bergabung   time.Time
+	jatah       float64
+	akrual      string
+	masaKerja   int
+	maksDibawa  float64
+	kedaluwarsa int // Month of the next year by whose end carried days must be used
+}
+
+// policies loads the join date and division leave policy effective in the year of every active
+// employee (penggunaID 0 = all)
+func policies(year, penggunaID int) ([]policy, error) {
+	query := `
+		SELECT p.id, COALESCE(p.tanggal_bergabung, DATE(p.dibuat_pada)),
+		       kc.jatah_cuti_tahunan, kc.akrual, kc.masa_kerja_minimal_bulan,
+		       kc.maks_sisa_dibawa, kc.bulan_kedaluwarsa_sisa
+		FROM pengguna p
+		LEFT JOIN konfigurasi_cuti kc ON kc.id = (
+			SELECT k.id FROM konfigurasi_cuti k
+			WHERE k.divisi_id = p.divisi_id AND k.aktif = TRUE AND k.tahun_berlaku <= ?
+			ORDER BY k.tahun_berlaku DESC, k.id DESC LIMIT 1
+		)
+		WHERE p.peran_id = 4 AND p.aktif = TRUE
+	`
+	args := []interface{}{year}
+	if penggunaID != 0 {
+		query += " AND p.id = ?"
+		args = append(args, penggunaID)
+	}
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []policy
+	for rows.Next() {
+		var p policy
+		var jatah sql.NullFloat64
+		var akrual sql.NullString
+		var masaKerja, kedaluwarsa sql.NullInt64
+		var maksDibawa sql.NullFloat64
+		if err := rows.Scan(&p.penggunaID, &p.bergabung, &jatah, &akrual, &masaKerja, &maksDibawa, &kedaluwarsa); err != nil {
+			return nil, err
+		}
+		p.jatah, p.akrual, p.masaKerja = defaultJatahCuti, AkrualTahunan, defaultMasaKerjaBulan
+		p.maksDibawa, p.kedaluwarsa = defaultMaksDibawa, defaultBulanKedaluwarsa
+		if jatah.Valid {
+			p.jatah, p.akrual, p.masaKerja = jatah.Float64, akrual.String, int(masaKerja.Int64)
+			p.maksDibawa, p.kedaluwarsa = maksDibawa.Float64, int(kedaluwarsa.Int64)
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// entitlementFor computes the year's leave as of asOf. A month counts when the employee is already
+// entitled on its first day, so joiners get a prorated share; with monthly accrual only the months
+// started by asOf are credited. Days are rounded down to half days.
+func entitlementFor(p policy, year int, asOf time.Time) Entitlement {
+	bergabung := time.Date(p.bergabung.Year(), p.bergabung.Month(), p.bergabung.Day(), 0, 0, 0, 0, time.Local)
+	e := Entitlement{
+		PenggunaID:    p.penggunaID,
+		Tahun:         year,
+		Akrual:        p.akrual,
+		JatahDivisi:   p.jatah,
+		TanggalBerhak: bergabung.AddDate(0, p.masaKerja, 0),
+	}
+
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	yearEnd := yearStart.AddDate(1, 0, -1)
+	if asOf.Before(yearStart) {
+		asOf = yearStart
+	}
+	if asOf.After(yearEnd) {
+		asOf = yearEnd
+	}
+
+	entitled, credited := 0, 0
+	for m := time.January; m <= time.December; m++ {
+		first := time.Date(year, m, 1, 0, 0, 0, 0, time.Local)
+		if first.Before(e.TanggalBerhak) {
+			continue
+		}
+		entitled++
+		if m <= asOf.Month() {
+			credited++
+		}
+	}
+
+	e.JatahTahunan = halfDays(p.jatah * float64(entitled) / 12)
+	switch {
+	case p.akrual == AkrualBulanan:
+		e.TotalHari = halfDays(p.jatah * float64(credited) / 12)
+	case !asOf.Before(e.TanggalBerhak):
+		e.TotalHari = e.JatahTahunan
+	}
+	return e
+}
+
+func halfDays(days float64) float64 {
+	return math.Floor(days*2) / 2
+}
+
+// GenerateBalances creates or refreshes the saldo_cuti rows of the year for every active employee
+// (penggunaID 0 = all). The entitlement credited since the last run is posted to the ledger as an
+// akrual entry; used days are untouched.
+func GenerateBalances(year, penggunaID int) (*GenerateResult, error) {
+	list, err := policies(year, penggunaID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GenerateResult{Tahun: year, Saldo: []Entitlement{}}
+	now := time.Now()
+	for _, p := range list {
+		e := entitlementFor(p, year, now)
+		if err := credit(e); err != nil {
+			return nil, err
+		}
+		result.Karyawan++
+		result.Saldo = append(result.Saldo, e)
+	}
+	return result, nil
+}
+
+// credit brings the credited entitlement of e's balance up (or down, after a policy change) to
+// e.TotalHari
+func credit(e Entitlement) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := creditIn(tx, e); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// creditIn is credit inside the caller's tx
+func creditIn(tx *sql.Tx, e Entitlement) error {
+	_, err := tx.Exec(`
+		INSERT INTO saldo_cuti (pengguna_id, tahun, total_hari, jatah_tahunan, hari_terpakai)
+		VALUES (?, ?, 0, ?, 0)
+		ON DUPLICATE KEY UPDATE jatah_tahunan = VALUES(jatah_tahunan)
+	`, e.PenggunaID, e.Tahun, e.JatahTahunan)
+	if err != nil {
+		return err
+	}
+	s, err := lockBalance(tx, e.PenggunaID, e.Tahun)
+	if err != nil {
+		return err
+	}
+	if delta := e.TotalHari - s.TotalHari; delta != 0 {
+		err = post(tx, s, Entry{
+			Jenis:      MutasiAkrual,
+			Hari:       delta,
+			Keterangan: fmt.Sprintf("Jatah cuti %d (%s) dikreditkan menjadi %.1f hari", e.Tahun, e.Akrual, e.TotalHari),
+		})
+	}
+	return err
+}
+
+// EnsureBalance creates the user's saldo_cuti row of the year when it does not exist yet
+func EnsureBalance(penggunaID, year int) error {
+	var exists int
+	err := database.DB.QueryRow("SELECT COUNT(*) FROM saldo_cuti WHERE pengguna_id = ? AND tahun = ?", penggunaID, year).Scan(&exists)
+	if err != nil || exists > 0 {
+		return err
+	}
+	_, err = GenerateBalances(year, penggunaID)
+	return err
+}
+
+// EnsureBalanceIn is EnsureBalance inside tx, for a caller about to take days from the balance.
+// Only active employees have a leave entitlement, so for anyone else it fails instead of leaving
+// the caller without a row.
+func EnsureBalanceIn(tx *sql.Tx, penggunaID, year int) error {
+	var exists int
+	err := tx.QueryRow("SELECT COUNT(*) FROM saldo_cuti WHERE pengguna_id = ? AND tahun = ?", penggunaID, year).Scan(&exists)
+	if err != nil || exists > 0 {
+		return err
+	}
+	list, err := policies(year, penggunaID)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("karyawan tidak aktif, saldo cuti %d tidak dapat dibuat", year)
+	}
+	return creditIn(tx, entitlementFor(list[0], year, time.Now()))
+}
+
+// StartBalanceAccrual refreshes this year's balances once a day in the background so monthly
+// accruals and newly entitled joiners are credited without HR action. The same run carries last
+// year's unused days over and forfeits carried days past their expiry.
+func StartBalanceAccrual() {
+	go func() {
+		for {
+			now := time.Now()
+			result, err := GenerateBalances(now.Year(), 0)
+			if err != nil {
+				log.Printf("[LeaveAccrual] Error: %v", err)
+			} else {
+				log.Printf("[LeaveAccrual] Refreshed %d leave balances for %d", result.Karyawan, result.Tahun)
+			}
+
+			rollover, err := RolloverBalances(now.Year() - 1)
+			if err != nil {
+				log.Printf("[LeaveAccrual] Rollover error: %v", err)
+			} else if rollover.Karyawan > 0 {
+				log.Printf("[LeaveAccrual] Carried over leave of %d employees into %d", rollover.Karyawan, rollover.KeTahun)
+			}
+
+			expired, err := ExpireCarryOver(now)
+			if err != nil {
+				log.Printf("[LeaveAccrual] Expiry error: %v", err)
+			} else if expired > 0 {
+				log.Printf("[LeaveAccrual] Forfeited expired carry-over of %d balances", expired)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
@@ -0,0 +1,66 @@
+package leave
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestHalfDays(t *testing.T) {
+	tests := []struct {
+		in, want float64
+	}{
+		{0, 0},
+		{0.49, 0},
+		{1, 1},
+		{5.4, 5},
+		{5.8333, 5.5},
+		{7.5, 7.5},
+	}
+	for _, tt := range tests {
+		if got := halfDays(tt.in); got != tt.want {
+			t.Errorf("halfDays(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEntitlementFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		bergabung string
+		jatah     float64
+		akrual    string
+		year      int
+		asOf      string
+		tahunan   float64 // JatahTahunan
+		total     float64 // TotalHari
+	}{
+		{"long-serving, yearly", "2020-01-15", 12, AkrualTahunan, 2024, "2024-03-10", 12, 12},
+		{"entitled mid-year, before the date", "2023-04-10", 12, AkrualTahunan, 2024, "2024-03-01", 8, 0},
+		{"entitled mid-year, after the date", "2023-04-10", 12, AkrualTahunan, 2024, "2024-06-01", 8, 8},
+		{"not entitled this year", "2024-06-01", 12, AkrualTahunan, 2024, "2024-12-31", 0, 0},
+		{"monthly accrual", "2020-01-15", 12, AkrualBulanan, 2024, "2024-03-15", 12, 3},
+		{"monthly accrual rounds down", "2020-01-15", 14, AkrualBulanan, 2024, "2024-05-01", 14, 5.5},
+		{"generated ahead of the year", "2020-01-15", 12, AkrualBulanan, 2025, "2024-12-01", 12, 1},
+		{"generated after the year", "2020-01-15", 12, AkrualBulanan, 2023, "2024-02-01", 12, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy{penggunaID: 1, bergabung: date(tt.bergabung), jatah: tt.jatah, akrual: tt.akrual, masaKerja: 12}
+			e := entitlementFor(p, tt.year, date(tt.asOf))
+			if e.JatahTahunan != tt.tahunan || e.TotalHari != tt.total {
+				t.Errorf("got jatah_tahunan %.1f, total_hari %.1f; want %.1f, %.1f", e.JatahTahunan, e.TotalHari, tt.tahunan, tt.total)
+			}
+			if want := date(tt.bergabung).AddDate(1, 0, 0); !e.TanggalBerhak.Equal(want) {
+				t.Errorf("tanggal_berhak %v, want %v", e.TanggalBerhak, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package leave
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+// Longest range a team calendar covers, so a subscription cannot ask for years of data at once
+const maxCalendarDays = 366
+
+// Kinds of TeamCalendarEvent
+const (
+	EventCuti  = "cuti"
+	EventLibur = "libur"
+	EventWFH   = "wfh"
+)
+
+// TeamMember is an employee shown on a team calendar
+type TeamMember struct {
+	PenggunaID  int     `json:"pengguna_id"`
+	NamaLengkap string  `json:"nama_lengkap"`
+	Divisi      *string `json:"divisi"`
+}
+
+// TeamCalendarEvent is a leave, WFH period or holiday, as whole days
+type TeamCalendarEvent struct {
+	ID             int     `json:"id"` // pengajuan_cuti / pengajuan_wfh id; 0 for holidays
+	Jenis          string  `json:"jenis"`
+	PenggunaID     *int    `json:"pengguna_id"` // nil for holidays
+	NamaLengkap    *string `json:"nama_lengkap"`
+	Judul          string  `json:"judul"`  // Leave type name, "WFH" or the holiday name
+	Status         *string `json:"status"` // menunggu / disetujui; nil for holidays
+	TanggalMulai   string  `json:"tanggal_mulai"`
+	TanggalSelesai string  `json:"tanggal_selesai"` // Inclusive
+	SetengahHari   *string `json:"setengah_hari"`
+}
+
+// TeamCalendar is who is off when among a set of employees
+type TeamCalendar struct {
+	Nama           string              `json:"nama"`
+	TanggalMulai   string              `json:"tanggal_mulai"`
+	TanggalSelesai string              `json:"tanggal_selesai"`
+	Karyawan       []TeamMember        `json:"karyawan"`
+	Acara          []TeamCalendarEvent `json:"acara"`
+}
+
+// CalendarRange parses the YYYY-MM-DD range of a calendar request. An empty start defaults to the
+// first day of this month and an empty end to the last day of the start's month.
+func CalendarRange(mulai, selesai string) (time.Time, time.Time, error) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	if mulai != "" {
+		t, err := time.ParseInLocation("2006-01-02", mulai, time.Local)
+		if err != nil {
+			return start, start, errors.New("format tanggal mulai tidak valid")
+		}
+		start = t
+	}
+	end := time.Date(start.Year(), start.Month()+1, 0, 0, 0, 0, 0, time.Local)
+	if selesai != "" {
+		t, err := time.ParseInLocation("2006-01-02", selesai, time.Local)
+		if err != nil {
+			return start, end, errors.New("format tanggal selesai tidak valid")
+		}
+		end = t
+	}
+	if end.Before(start) {
+		return start, end, errors.New("tanggal selesai tidak boleh sebelum tanggal mulai")
+	}
+	if end.Sub(start).Hours()/24 >= maxCalendarDays {
+		return start, end, fmt.Errorf("rentang kalender maksimal %d hari", maxCalendarDays)
+	}
+	return start, end, nil
+}
+
+// BuildTeamCalendar collects the pending and approved leave and WFH of the members overlapping
+// start to end, and the holidays in that range
+func BuildTeamCalendar(nama string, members []TeamMember, start, end time.Time) (*TeamCalendar, error) {
+	cal := &TeamCalendar{
+		Nama:           nama,
+		TanggalMulai:   start.Format("2006-01-02"),
+		TanggalSelesai: end.Format("2006-01-02"),
+		Karyawan:       members,
+		Acara:          []TeamCalendarEvent{},
+	}
+
+	if len(members) > 0 {
+		args := []interface{}{end.Format("2006-01-02"), start.Format("2006-01-02")}
+		for _, m := range members {
+			args = append(args, m.PenggunaID)
+		}
+		in := "(?" + strings.Repeat(", ?", len(members)-1) + ")"
+
+		queries := []struct {
+			jenis string
+			query string
+		}{
+			{EventCuti, `
+				SELECT pc.id, pc.pengguna_id, p.nama_lengkap, COALESCE(jc.nama, pc.tipe_cuti), pc.status,
+				       DATE_FORMAT(pc.tanggal_mulai, '%Y-%m-%d'), DATE_FORMAT(pc.tanggal_selesai, '%Y-%m-%d'), pc.setengah_hari
+				FROM pengajuan_cuti pc
+				JOIN pengguna p ON pc.pengguna_id = p.id
+				LEFT JOIN jenis_cuti jc ON pc.tipe_cuti = jc.kode
+				WHERE pc.status IN ('menunggu', 'disetujui') AND pc.tanggal_mulai <= ? AND pc.tanggal_selesai >= ?
+				AND pc.pengguna_id IN ` + in},
+			{EventWFH, `
+				SELECT w.id, w.pengguna_id, p.nama_lengkap, 'WFH', w.status,
+				       DATE_FORMAT(w.tanggal_mulai, '%Y-%m-%d'), DATE_FORMAT(w.tanggal_selesai, '%Y-%m-%d'), NULL
+				FROM pengajuan_wfh w
+				JOIN pengguna p ON w.pengguna_id = p.id
+				WHERE w.status IN ('menunggu', 'disetujui') AND w.tanggal_mulai <= ? AND w.tanggal_selesai >= ?
+				AND w.pengguna_id IN ` + in},
+		}
+		for _, q := range queries {
+			rows, err := database.DB.Query(q.query, args...)
+			if err != nil {
+				return nil, err
+			}
+			for rows.Next() {
+				e := TeamCalendarEvent{Jenis: q.jenis}
+				if err := rows.Scan(&e.ID, &e.PenggunaID, &e.NamaLengkap, &e.Judul, &e.Status,
+					&e.TanggalMulai, &e.TanggalSelesai, &e.SetengahHari); err != nil {
+					rows.Close()
+					return nil, err
+				}
+				cal.Acara = append(cal.Acara, e)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	holidays, err := attendance.HolidaysBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range holidays {
+		cal.Acara = append(cal.Acara, TeamCalendarEvent{
+			Jenis: EventLibur, Judul: h.Nama, TanggalMulai: h.Tanggal, TanggalSelesai: h.Tanggal,
+		})
+	}
+
+	sort.SliceStable(cal.Acara, func(i, j int) bool {
+		a, b := cal.Acara[i], cal.Acara[j]
+		if a.TanggalMulai != b.TanggalMulai {
+			return a.TanggalMulai < b.TanggalMulai
+		}
+		return a.Jenis < b.Jenis
+	})
+	return cal, nil
+}
+
+// RedactCalendar reduces the events of employees not in visible to the fact that they are off:
+// leave shows as a plain "Cuti" and pending requests are left out
+func RedactCalendar(cal *TeamCalendar, visible map[int]bool) {
+	acara := cal.Acara[:0]
+	for _, e := range cal.Acara {
+		if e.PenggunaID != nil && !visible[*e.PenggunaID] {
+			if e.Status != nil && *e.Status == "menunggu" {
+				continue
+			}
+			if e.Jenis == EventCuti {
+				e.Judul = "Cuti"
+			}
+		}
+		acara = append(acara, e)
+	}
+	cal.Acara = acara
+}
+
+// WriteICS writes the calendar as an iCalendar (RFC 5545) feed of all-day events that Google
+// Calendar or Outlook can subscribe to. Pending requests are TENTATIVE.
+func WriteICS(w io.Writer, cal *TeamCalendar) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		// Lines longer than 75 octets are folded with CRLF and a leading space, which counts
+		// towards the next line's 75
+		for limit := 75; len(s) > limit; limit = 74 {
+			cut := limit
+			for cut > 1 && s[cut]&0xC0 == 0x80 {
+				cut-- // Do not split a UTF-8 sequence
+			}
+			bw.WriteString(s[:cut] + "\r\n ")
+			s = s[cut:]
+		}
+		bw.WriteString(s + "\r\n")
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//HRIS//Kalender Cuti Tim//ID")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icsText(cal.Nama))
+	line("X-WR-TIMEZONE:Asia/Jakarta")
+
+	for _, e := range cal.Acara {
+		start, err := time.ParseInLocation("2006-01-02", e.TanggalMulai, time.Local)
+		if err != nil {
+			return err
+		}
+		end, err := time.ParseInLocation("2006-01-02", e.TanggalSelesai, time.Local)
+		if err != nil {
+			return err
+		}
+
+		uid := fmt.Sprintf("%s-%d@hris", e.Jenis, e.ID)
+		summary := e.Judul
+		if e.Jenis == EventLibur {
+			uid = fmt.Sprintf("libur-%s@hris", start.Format("20060102"))
+		} else if e.NamaLengkap != nil {
+			summary = *e.NamaLengkap + " - " + e.Judul
+		}
+		if e.SetengahHari != nil {
+			summary += " (setengah hari " + *e.SetengahHari + ")"
+		}
+		status := "CONFIRMED"
+		if e.Status != nil && *e.Status == "menunggu" {
+			status = "TENTATIVE"
+			summary += " [menunggu]"
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:" + uid)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format("20060102")) // Exclusive
+		line("SUMMARY:" + icsText(summary))
+		line("STATUS:" + status)
+		line("CATEGORIES:" + strings.ToUpper(e.Jenis))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// icsText escapes a TEXT value as RFC 5545 requires
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,97 @@
+package leave
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestICSText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Cuti Tahunan", "Cuti Tahunan"},
+		{"Budi, Ani; Citra", `Budi\, Ani\; Citra`},
+		{`C:\cuti`, `C:\\cuti`},
+		{"baris satu\nbaris dua", `baris satu\nbaris dua`},
+		{"baris satu\r\nbaris dua", `baris satu\nbaris dua`},
+	}
+	for _, tt := range tests {
+		if got := icsText(tt.in); got != tt.want {
+			t.Errorf("icsText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	name := "Siti Nurhaliza"
+	pending, pagi := "menunggu", "pagi"
+	cal := &TeamCalendar{
+		Nama: "Kalender Cuti " + strings.Repeat("Pengembangan Produk ", 5),
+		Acara: []TeamCalendarEvent{
+			{ID: 7, Jenis: EventCuti, PenggunaID: new(int), NamaLengkap: &name, Judul: "Cuti Tahunan",
+				Status: &pending, TanggalMulai: "2024-06-10", TanggalSelesai: "2024-06-10", SetengahHari: &pagi},
+			{Jenis: EventLibur, Judul: "Hari Raya Idul Adha – " + strings.Repeat("é", 40),
+				TanggalMulai: "2024-06-17", TanggalSelesai: "2024-06-17"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, cal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+		t.Fatalf("feed does not end with END:VCALENDAR: %q", out)
+	}
+
+	for _, l := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line of %d octets not folded: %q", len(l), l)
+		}
+		if strings.ToValidUTF8(l, "") != l {
+			t.Errorf("folding split a UTF-8 sequence: %q", l)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	for _, want := range []string{
+		"X-WR-CALNAME:" + cal.Nama + "\r\n",
+		"UID:cuti-7@hris\r\n",
+		"DTSTART;VALUE=DATE:20240610\r\nDTEND;VALUE=DATE:20240611\r\n",
+		"SUMMARY:Siti Nurhaliza - Cuti Tahunan (setengah hari pagi) [menunggu]\r\n",
+		"STATUS:TENTATIVE\r\n",
+		"UID:libur-20240617@hris\r\n",
+		"SUMMARY:" + cal.Acara[1].Judul + "\r\n",
+		"STATUS:CONFIRMED\r\n",
+	} {
+		if !strings.Contains(unfolded, want) {
+			t.Errorf("feed is missing %q", want)
+		}
+	}
+}
+
+func TestRedactCalendar(t *testing.T) {
+	own, colleague := 1, 2
+	approved, pending := "disetujui", "menunggu"
+	cal := &TeamCalendar{Acara: []TeamCalendarEvent{
+		{ID: 1, Jenis: EventCuti, PenggunaID: &own, Judul: "Cuti Sakit", Status: &pending},
+		{ID: 2, Jenis: EventCuti, PenggunaID: &colleague, Judul: "Cuti Sakit", Status: &approved},
+		{ID: 3, Jenis: EventCuti, PenggunaID: &colleague, Judul: "Cuti Tahunan", Status: &pending},
+		{ID: 4, Jenis: EventWFH, PenggunaID: &colleague, Judul: "WFH", Status: &approved},
+		{ID: 5, Jenis: EventWFH, PenggunaID: &colleague, Judul: "WFH", Status: &pending},
+		{Jenis: EventLibur, Judul: "Hari Raya Idul Adha"},
+	}}
+
+	RedactCalendar(cal, map[int]bool{own: true})
+
+	want := map[int]string{1: "Cuti Sakit", 2: "Cuti", 4: "WFH", 0: "Hari Raya Idul Adha"}
+	if len(cal.Acara) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(cal.Acara), len(want), cal.Acara)
+	}
+	for _, e := range cal.Acara {
+		if judul, ok := want[e.ID]; !ok || e.Judul != judul {
+			t.Errorf("event %d titled %q, want %q", e.ID, e.Judul, judul)
+		}
+	}
+}
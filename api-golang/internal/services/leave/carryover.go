@@ -0,0 +1,293 @@
+package leave
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+// Carry is the carry-over of one employee into the new year
+type Carry struct {
+	PenggunaID        int     `json:"pengguna_id"`
+	SisaTahunLalu     float64 `json:"sisa_tahun_lalu"` // Unused own entitlement of the closed year
+	HariDibawa        float64 `json:"hari_dibawa"`     // Capped at maks_sisa_dibawa
+	DibawaKedaluwarsa string  `json:"dibawa_kedaluwarsa"`
+}
+
+// RolloverResult summarises one year-end rollover run
+type RolloverResult struct {
+	DariTahun int     `json:"dari_tahun"`
+	KeTahun   int     `json:"ke_tahun"`
+	Karyawan  int     `json:"karyawan"`
+	Dibawa    []Carry `json:"dibawa"`
+}
+
+// CarriedLeft is how many carried-over days of the balance a leave starting on date can still use:
+// none once the carried days expired or when the leave starts after their expiry date
+func CarriedLeft(s *models.SaldoCuti, date time.Time) float64 {
+	if s.DibawaKedaluwarsa == nil || s.HariHangus > 0 {
+		return 0
+	}
+	expiry := time.Date(s.DibawaKedaluwarsa.Year(), s.DibawaKedaluwarsa.Month(), s.DibawaKedaluwarsa.Day(), 0, 0, 0, 0, time.Local)
+	if date.After(expiry) {
+		return 0
+	}
+	return math.Max(s.HariDibawa-s.DibawaTerpakai, 0)
+}
+
+// CarriedFor is how many carried-over days the leave d can use. Only its working days up to the
+// expiry date count against the carried balance; a leave running past the expiry takes the rest
+// from the own entitlement.
+func CarriedFor(s *models.SaldoCuti, d *attendance.LeaveDuration) float64 {
+	start, err := time.ParseInLocation("2006-01-02", d.TanggalMulai, time.Local)
+	if err != nil {
+		return 0
+	}
+	left := CarriedLeft(s, start)
+	if left == 0 {
+		return 0
+	}
+	expiry := s.DibawaKedaluwarsa.Format("2006-01-02")
+	beforeExpiry := 0.0
+	for _, day := range d.Rincian {
+		if day.Tanggal <= expiry {
+			beforeExpiry += day.Dihitung
+		}
+	}
+	return math.Min(left, beforeExpiry)
+}
+
+// UsableDays is the balance the leave d can draw from: the own entitlement left plus the carried
+// days it can use
+func UsableDays(s *models.SaldoCuti, d *attendance.LeaveDuration) float64 {
+	carried := math.Max(s.HariDibawa-s.DibawaTerpakai-s.HariHangus, 0)
+	return s.SisaHari - carried + CarriedFor(s, d)
+}
+
+// Consume takes days for the approved leave d from the user's balance of the year inside tx,
+// carried-over days first. The balance is re-checked under the row lock, so two requests that both
+// fit the balance when submitted cannot overdraw it when approved together.
+func Consume(tx *sql.Tx, penggunaID, year int, days float64, d *attendance.LeaveDuration, pengajuanID int) error {
+	s, err := lockBalance(tx, penggunaID, year)
+	if err != nil {
+		return err
+	}
+	if usable := UsableDays(s, d); usable < days {
+		return fmt.Errorf("sisa cuti tidak mencukupi: tersisa %.1f hari, dibutuhkan %.1f hari", usable, days)
+	}
+
+	fromCarried := math.Min(days, CarriedFor(s, d))
+	return post(tx, s, Entry{
+		Jenis:           MutasiPemakaian,
+		Hari:            -days,
+		HariDibawa:      -fromCarried,
+		PengajuanCutiID: &pengajuanID,
+		Keterangan:      "Cuti disetujui",
+	})
+}
+
+// YearPart is the share of a leave that falls in one calendar year
+type YearPart struct {
+	Tahun  int
+	Hari   float64
+	Durasi *attendance.LeaveDuration // The days of the leave in that year
+}
+
+// SplitByYear divides a leave at year boundaries, so each year's days are taken from that year's
+// balance. Years in which the leave has no working day are left out.
+func SplitByYear(d *attendance.LeaveDuration) []YearPart {
+	var parts []YearPart
+	for _, day := range d.Rincian {
+		year, _ := strconv.Atoi(day.Tanggal[:4])
+		if len(parts) == 0 || parts[len(parts)-1].Tahun != year {
+			parts = append(parts, YearPart{Tahun: year, Durasi: &attendance.LeaveDuration{
+				TanggalMulai: day.Tanggal, SetengahHari: d.SetengahHari,
+			}})
+		}
+		p := &parts[len(parts)-1]
+		p.Hari += day.Dihitung
+		p.Durasi.TanggalSelesai = day.Tanggal
+		p.Durasi.HariKalender++
+		p.Durasi.TotalHari += day.Dihitung
+		p.Durasi.Rincian = append(p.Durasi.Rincian, day)
+	}
+
+	kept := parts[:0]
+	for _, p := range parts {
+		if p.Hari > 0 {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// ConsumeLeave takes an approved leave of days from the balances inside tx, each year's days from
+// that year's balance, creating a balance the accrual job has not yet. days is the request's
+// total_hari; when today's breakdown d counts differently (a holiday added since submission), the
+// last year absorbs the difference so the ledger matches the request.
+func ConsumeLeave(tx *sql.Tx, penggunaID int, days float64, d *attendance.LeaveDuration, pengajuanID int) error {
+	parts := SplitByYear(d)
+	if len(parts) == 0 {
+		return nil
+	}
+	for i, p := range parts {
+		hari := p.Hari
+		if i == len(parts)-1 {
+			hari = math.Max(days, 0)
+		}
+		days -= p.Hari
+		if hari == 0 {
+			continue
+		}
+		if err := EnsureBalanceIn(tx, penggunaID, p.Tahun); err != nil {
+			return err
+		}
+		if err := Consume(tx, penggunaID, p.Tahun, hari, p.Durasi, pengajuanID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RolloverBalances carries the unused own entitlement of fromYear into the next year, up to the
+// division's maks_sisa_dibawa, to be used by the end of bulan_kedaluwarsa_sisa. Days carried into
+// fromYear are not carried again. Safe to re-run: the carry is recomputed until it expires.
+func RolloverBalances(fromYear int) (*RolloverResult, error) {
+	toYear := fromYear + 1
+	list, err := policies(toYear, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RolloverResult{DariTahun: fromYear, KeTahun: toYear, Dibawa: []Carry{}}
+	for _, p := range list {
+		var sisa float64
+		err := database.DB.QueryRow(`
+			SELECT total_hari + hari_penyesuaian - (hari_terpakai - dibawa_terpakai) FROM saldo_cuti
+			WHERE pengguna_id = ? AND tahun = ?
+		`, p.penggunaID, fromYear).Scan(&sisa)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := EnsureBalance(p.penggunaID, toYear); err != nil {
+			return nil, err
+		}
+		c := Carry{
+			PenggunaID:        p.penggunaID,
+			SisaTahunLalu:     sisa,
+			HariDibawa:        halfDays(math.Min(math.Max(sisa, 0), p.maksDibawa)),
+			DibawaKedaluwarsa: carryExpiry(toYear, p.kedaluwarsa).Format("2006-01-02"),
+		}
+		carried, err := carry(c, toYear)
+		if err != nil {
+			return nil, err
+		}
+		if carried {
+			result.Karyawan++
+			result.Dibawa = append(result.Dibawa, c)
+		}
+	}
+	return result, nil
+}
+
+// carry posts the difference between c and what was already carried into toYear as a bawaan
+// entry. Once the carried days expired the carry is final and false is returned.
+func carry(c Carry, toYear int) (bool, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	s, err := lockBalance(tx, c.PenggunaID, toYear)
+	if err != nil {
+		return false, err
+	}
+	today := time.Now()
+	if s.HariHangus > 0 || (s.DibawaKedaluwarsa != nil && s.DibawaKedaluwarsa.Format("2006-01-02") < today.Format("2006-01-02")) {
+		return false, nil
+	}
+
+	// Carried days already used stay carried
+	if delta := math.Max(c.HariDibawa, s.DibawaTerpakai) - s.HariDibawa; delta != 0 {
+		err = post(tx, s, Entry{
+			Jenis:      MutasiBawaan,
+			Hari:       delta,
+			Keterangan: fmt.Sprintf("Sisa cuti %d dibawa, berlaku s/d %s", toYear-1, c.DibawaKedaluwarsa),
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE saldo_cuti SET dibawa_kedaluwarsa = ? WHERE id = ?", c.DibawaKedaluwarsa, s.ID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// carryExpiry is the last day of the given month of the year; months outside 1-12 mean year end
+func carryExpiry(year, month int) time.Time {
+	if month < 1 || month > 12 {
+		month = 12
+	}
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.Local)
+}
+
+// ExpireCarryOver forfeits the carried-over days left unused after their expiry date
+func ExpireCarryOver(today time.Time) (int64, error) {
+	rows, err := database.DB.Query(`
+		SELECT pengguna_id, tahun FROM saldo_cuti
+		WHERE dibawa_kedaluwarsa < ? AND hari_hangus = 0 AND hari_dibawa > dibawa_terpakai
+	`, today.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	type key struct{ penggunaID, tahun int }
+	var due []key
+	for rows.Next() {
+		var k key
+		if err := rows.Scan(&k.penggunaID, &k.tahun); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, k)
+	}
+	rows.Close()
+
+	var expired int64
+	for _, k := range due {
+		tx, err := database.DB.Begin()
+		if err != nil {
+			return expired, err
+		}
+		s, err := lockBalance(tx, k.penggunaID, k.tahun)
+		if err == nil && s.HariHangus == 0 && s.HariDibawa > s.DibawaTerpakai {
+			err = post(tx, s, Entry{
+				Jenis:      MutasiHangus,
+				Hari:       -(s.HariDibawa - s.DibawaTerpakai),
+				Keterangan: "Sisa cuti yang dibawa kedaluwarsa",
+			})
+			if err == nil {
+				expired++
+			}
+		}
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			tx.Rollback()
+			return expired, err
+		}
+	}
+	return expired, nil
+}
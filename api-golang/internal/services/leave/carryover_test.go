@@ -0,0 +1,107 @@
+package leave
+
+import (
+	"testing"
+
+	"github.com/hris-system/api-golang/internal/models"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+// leaveOf builds the breakdown of a leave starting on start, one day per counted value
+func leaveOf(start string, counted ...float64) *attendance.LeaveDuration {
+	d := &attendance.LeaveDuration{TanggalMulai: start}
+	day := date(start)
+	for _, c := range counted {
+		d.Rincian = append(d.Rincian, attendance.LeaveDay{Tanggal: day.Format("2006-01-02"), Dihitung: c})
+		d.TotalHari += c
+		d.TanggalSelesai = day.Format("2006-01-02")
+		day = day.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+func TestCarriedLeft(t *testing.T) {
+	expiry := date("2024-06-30")
+	tests := []struct {
+		name  string
+		saldo models.SaldoCuti
+		on    string
+		want  float64
+	}{
+		{"nothing carried", models.SaldoCuti{HariDibawa: 0}, "2024-03-01", 0},
+		{"no expiry set", models.SaldoCuti{HariDibawa: 5}, "2024-03-01", 0},
+		{"before expiry", models.SaldoCuti{HariDibawa: 5, DibawaTerpakai: 2, DibawaKedaluwarsa: &expiry}, "2024-03-01", 3},
+		{"on the expiry date", models.SaldoCuti{HariDibawa: 5, DibawaTerpakai: 2, DibawaKedaluwarsa: &expiry}, "2024-06-30", 3},
+		{"after expiry", models.SaldoCuti{HariDibawa: 5, DibawaTerpakai: 2, DibawaKedaluwarsa: &expiry}, "2024-07-01", 0},
+		{"already forfeited", models.SaldoCuti{HariDibawa: 5, DibawaTerpakai: 2, HariHangus: 3, DibawaKedaluwarsa: &expiry}, "2024-03-01", 0},
+		{"fully used", models.SaldoCuti{HariDibawa: 5, DibawaTerpakai: 5, DibawaKedaluwarsa: &expiry}, "2024-03-01", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CarriedLeft(&tt.saldo, date(tt.on)); got != tt.want {
+				t.Errorf("CarriedLeft = %.1f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsableDays(t *testing.T) {
+	expiry := date("2024-06-30")
+	// 7 own days and 3 carried days left
+	saldo := models.SaldoCuti{SisaHari: 10, HariDibawa: 5, DibawaTerpakai: 2, DibawaKedaluwarsa: &expiry}
+	forfeited := models.SaldoCuti{SisaHari: 7, HariDibawa: 5, DibawaTerpakai: 2, HariHangus: 3, DibawaKedaluwarsa: &expiry}
+
+	tests := []struct {
+		name    string
+		saldo   models.SaldoCuti
+		leave   *attendance.LeaveDuration
+		carried float64
+		usable  float64
+	}{
+		{"entirely before expiry", saldo, leaveOf("2024-06-27", 1, 1), 2, 9},
+		{"more than the carried days", saldo, leaveOf("2024-06-24", 1, 1, 1, 1, 1), 3, 10},
+		{"running past expiry", saldo, leaveOf("2024-06-28", 1, 0, 0, 1, 1), 1, 8},
+		{"half day before expiry", saldo, leaveOf("2024-06-28", 0.5), 0.5, 7.5},
+		{"after expiry", saldo, leaveOf("2024-07-01", 1, 1), 0, 7},
+		{"carried days forfeited", forfeited, leaveOf("2024-06-27", 1, 1), 0, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CarriedFor(&tt.saldo, tt.leave); got != tt.carried {
+				t.Errorf("CarriedFor = %.1f, want %.1f", got, tt.carried)
+			}
+			if got := UsableDays(&tt.saldo, tt.leave); got != tt.usable {
+				t.Errorf("UsableDays = %.1f, want %.1f", got, tt.usable)
+			}
+		})
+	}
+}
+
+func TestSplitByYear(t *testing.T) {
+	tests := []struct {
+		name  string
+		leave *attendance.LeaveDuration
+		want  []YearPart
+	}{
+		{"within one year", leaveOf("2024-06-10", 1, 1, 1), []YearPart{{Tahun: 2024, Hari: 3}}},
+		{"across new year", leaveOf("2024-12-30", 1, 1, 0, 1, 1), []YearPart{{Tahun: 2024, Hari: 2}, {Tahun: 2025, Hari: 2}}},
+		{"only holidays left in the old year", leaveOf("2024-12-31", 0, 0, 1), []YearPart{{Tahun: 2025, Hari: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitByYear(tt.leave)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d parts, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, p := range got {
+				if p.Tahun != tt.want[i].Tahun || p.Hari != tt.want[i].Hari || p.Durasi.TotalHari != p.Hari {
+					t.Errorf("part %d = %d/%.1f (durasi %.1f), want %d/%.1f",
+						i, p.Tahun, p.Hari, p.Durasi.TotalHari, tt.want[i].Tahun, tt.want[i].Hari)
+				}
+				if p.Durasi.TanggalMulai[:4] != p.Durasi.TanggalSelesai[:4] {
+					t.Errorf("part %d spans %s to %s", i, p.Durasi.TanggalMulai, p.Durasi.TanggalSelesai)
+				}
+			}
+		})
+	}
+}
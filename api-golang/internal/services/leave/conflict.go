@@ -0,0 +1,282 @@
+package leave
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+// StaffingWarning is a working day on which approving a leave leaves the division short-staffed
+type StaffingWarning struct {
+	Tanggal  string  `json:"tanggal"`
+	Divisi   string  `json:"divisi"`
+	Tersedia float64 `json:"tersedia"` // Employees of the division not on leave that day, half days counting half
+	Minimal  int     `json:"minimal"`  // konfigurasi_cuti.minimal_karyawan_hadir
+}
+
+// LockApplicant locks the applicant's pengguna row for the rest of tx. Submissions hold it while
+// checking for conflicts and inserting, so two concurrent requests of the same user (a double tap,
+// two tabs) are checked one after the other and the second sees the first.
+func LockApplicant(tx *sql.Tx, penggunaID int) error {
+	var id int
+	err := tx.QueryRow("SELECT id FROM pengguna WHERE id = ? FOR UPDATE", penggunaID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return errors.New("karyawan tidak ditemukan")
+	}
+	return err
+}
+
+// CheckConflicts refuses a leave that overlaps another pending or approved request of the user, or
+// a day the user already clocked in, inside tx after LockApplicant. A morning and an afternoon half
+// day on the same date do not conflict, and a half day may follow a clock-in.
+func CheckConflicts(tx *sql.Tx, penggunaID int, d *attendance.LeaveDuration) error {
+	mulai, selesai, setengahHari := d.TanggalMulai, d.TanggalSelesai, ""
+	if d.SetengahHari != nil {
+		setengahHari = *d.SetengahHari
+	}
+
+	var tipe, dari, sampai string
+	err := tx.QueryRow(`
+		SELECT tipe_cuti, DATE_FORMAT(tanggal_mulai, '%Y-%m-%d'), DATE_FORMAT(tanggal_selesai, '%Y-%m-%d')
+		FROM pengajuan_cuti
+		WHERE pengguna_id = ? AND status IN ('menunggu', 'disetujui')
+		AND tanggal_mulai <= ? AND tanggal_selesai >= ?
+		AND NOT (setengah_hari IS NOT NULL AND ? <> '' AND setengah_hari <> ?)
+		ORDER BY tanggal_mulai ASC LIMIT 1
+	`, penggunaID, selesai, mulai, setengahHari, setengahHari).Scan(&tipe, &dari, &sampai)
+	if err == nil {
+		return fmt.Errorf("bertabrakan dengan pengajuan %s tanggal %s s/d %s", tipe, dari, sampai)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	if setengahHari != "" {
+		return nil
+	}
+	var tanggal string
+	err = tx.QueryRow(`
+		SELECT DATE_FORMAT(tanggal, '%Y-%m-%d') FROM presensi
+		WHERE pengguna_id = ? AND tanggal BETWEEN ? AND ? AND waktu_masuk IS NOT NULL
+		ORDER BY tanggal ASC LIMIT 1
+	`, penggunaID, mulai, selesai).Scan(&tanggal)
+	if err == nil {
+		return fmt.Errorf("sudah ada presensi masuk pada tanggal %s", tanggal)
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+	return nil
+}
+
+// StaffingRequest is a leave to check against its division's minimum staffing
+type StaffingRequest struct {
+	ID           int // Key of the result, e.g. the pengajuan_cuti id
+	PenggunaID   int
+	Mulai        time.Time
+	Selesai      time.Time
+	SetengahHari bool
+}
+
+// StaffingWarnings lists, per request ID, the working days on which the applicant's division would
+// have fewer employees than its minimal_karyawan_hadir if the leave were approved. Other approved
+// leave of the division counts as absent, half days as half an absence; divisions without a
+// minimum never warn. Lookups are made once per division over the combined range of its requests,
+// so a long approval queue costs no more queries than a short one.
+func StaffingWarnings(requests []StaffingRequest) (map[int][]StaffingWarning, error) {
+	result := map[int][]StaffingWarning{}
+	if len(requests) == 0 {
+		return result, nil
+	}
+
+	type division struct {
+		nama           string
+		requests       []StaffingRequest
+		mulai, selesai time.Time
+	}
+	divisions := map[int64]*division{}
+	divisiOf := map[int]int64{}
+	args := []interface{}{}
+	for _, r := range requests {
+		result[r.ID] = []StaffingWarning{}
+		args = append(args, r.PenggunaID)
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT p.id, p.divisi_id, d.nama FROM pengguna p JOIN divisi d ON p.divisi_id = d.id
+		WHERE p.id IN (?`+strings.Repeat(", ?", len(args)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	names := map[int64]string{}
+	for rows.Next() {
+		var uid int
+		var divisiID int64
+		var nama string
+		if err := rows.Scan(&uid, &divisiID, &nama); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		divisiOf[uid] = divisiID
+		names[divisiID] = nama
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var mulai, selesai time.Time
+	for _, r := range requests {
+		divisiID, ok := divisiOf[r.PenggunaID]
+		if !ok {
+			continue
+		}
+		dv := divisions[divisiID]
+		if dv == nil {
+			dv = &division{nama: names[divisiID], mulai: r.Mulai, selesai: r.Selesai}
+			divisions[divisiID] = dv
+		}
+		dv.requests = append(dv.requests, r)
+		if r.Mulai.Before(dv.mulai) {
+			dv.mulai = r.Mulai
+		}
+		if r.Selesai.After(dv.selesai) {
+			dv.selesai = r.Selesai
+		}
+		if mulai.IsZero() || dv.mulai.Before(mulai) {
+			mulai = dv.mulai
+		}
+		if dv.selesai.After(selesai) {
+			selesai = dv.selesai
+		}
+	}
+	if len(divisions) == 0 {
+		return result, nil
+	}
+
+	config, err := attendance.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := attendance.HolidaysBetween(mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+
+	for divisiID, dv := range divisions {
+		var minimal sql.NullInt64
+		err := database.DB.QueryRow(`
+			SELECT minimal_karyawan_hadir FROM konfigurasi_cuti
+			WHERE divisi_id = ? AND aktif = TRUE AND tahun_berlaku <= ?
+			ORDER BY tahun_berlaku DESC, id DESC LIMIT 1
+		`, divisiID, dv.mulai.Year()).Scan(&minimal)
+		if err == sql.ErrNoRows || (err == nil && (!minimal.Valid || minimal.Int64 <= 0)) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		members, err := divisionMembers(divisiID)
+		if err != nil {
+			return nil, err
+		}
+		away, err := divisionAbsences(divisiID, dv.mulai, dv.selesai)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range dv.requests {
+			for day := r.Mulai; !day.After(r.Selesai); day = day.AddDate(0, 0, 1) {
+				key := day.Format("2006-01-02")
+				if _, libur := holidays[key]; libur || !attendance.IsWorkday(day, config) {
+					continue
+				}
+				tersedia := float64(len(members))
+				for uid, absent := range away[key] {
+					if uid != r.PenggunaID {
+						tersedia -= absent
+					}
+				}
+				// Applicants outside the counted headcount (not an active karyawan) change nothing
+				if members[r.PenggunaID] {
+					if r.SetengahHari {
+						tersedia -= 0.5
+					} else {
+						tersedia--
+					}
+				}
+				if tersedia < float64(minimal.Int64) {
+					result[r.ID] = append(result[r.ID], StaffingWarning{
+						Tanggal: key, Divisi: dv.nama, Tersedia: tersedia, Minimal: int(minimal.Int64),
+					})
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// divisionMembers is the staffing headcount of a division: its active karyawan
+func divisionMembers(divisiID int64) (map[int]bool, error) {
+	rows, err := database.DB.Query("SELECT id FROM pengguna WHERE divisi_id = ? AND peran_id = 4 AND aktif = TRUE", divisiID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		members[id] = true
+	}
+	return members, rows.Err()
+}
+
+// divisionAbsences maps each date from mulai to selesai to the members of the division away on
+// approved leave and how much of the day they are away (1, or 0.5 for a half day)
+func divisionAbsences(divisiID int64, mulai, selesai time.Time) (map[string]map[int]float64, error) {
+	rows, err := database.DB.Query(`
+		SELECT pc.pengguna_id, pc.tanggal_mulai, pc.tanggal_selesai, pc.setengah_hari IS NOT NULL
+		FROM pengajuan_cuti pc JOIN pengguna p ON pc.pengguna_id = p.id
+		WHERE p.divisi_id = ? AND p.peran_id = 4 AND p.aktif = TRUE AND pc.status = 'disetujui'
+		AND pc.tanggal_mulai <= ? AND pc.tanggal_selesai >= ?
+	`, divisiID, selesai.Format("2006-01-02"), mulai.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	away := map[string]map[int]float64{}
+	for rows.Next() {
+		var uid int
+		var from, to time.Time
+		var setengahHari bool
+		if err := rows.Scan(&uid, &from, &to, &setengahHari); err != nil {
+			return nil, err
+		}
+		absent := 1.0
+		if setengahHari {
+			absent = 0.5
+		}
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			key := day.Format("2006-01-02")
+			if away[key] == nil {
+				away[key] = map[int]float64{}
+			}
+			// A morning and an afternoon half day of the same person make a whole day
+			away[key][uid] = math.Min(away[key][uid]+absent, 1)
+		}
+	}
+	return away, rows.Err()
+}
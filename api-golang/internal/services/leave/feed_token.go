@@ -0,0 +1,57 @@
+package leave
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"github.com/hris-system/api-golang/internal/database"
+)
+
+// IssueFeedToken creates the user's calendar feed token, replacing (and so revoking) any previous
+// one. Only its hash is stored; the token itself is returned once to be put in the feed URL.
+func IssueFeedToken(penggunaID int) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	_, err := database.DB.Exec(`
+		INSERT INTO token_kalender (pengguna_id, token_hash) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), dibuat_pada = NOW()
+	`, penggunaID, feedTokenHash(token))
+	return token, err
+}
+
+// RevokeFeedToken removes the user's calendar feed token, ending their subscriptions
+func RevokeFeedToken(penggunaID int) error {
+	res, err := database.DB.Exec("DELETE FROM token_kalender WHERE pengguna_id = ?", penggunaID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errors.New("token kalender tidak ditemukan")
+	}
+	return nil
+}
+
+// FeedTokenUser returns the active user a calendar feed token belongs to and their role
+func FeedTokenUser(token string) (int, int, error) {
+	var penggunaID, peranID int
+	err := database.DB.QueryRow(`
+		SELECT p.id, p.peran_id FROM token_kalender t JOIN pengguna p ON t.pengguna_id = p.id
+		WHERE t.token_hash = ? AND p.aktif = TRUE
+	`, feedTokenHash(token)).Scan(&penggunaID, &peranID)
+	if err == sql.ErrNoRows {
+		return 0, 0, errors.New("token kalender tidak valid")
+	}
+	return penggunaID, peranID, err
+}
+
+func feedTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,195 @@
+package leave
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+// Values of mutasi_cuti.jenis. Every entry's hari is signed so that sisa_hari is the sum of hari.
+const (
+	MutasiAkrual       = "akrual"       // Entitlement credited (+), or corrected (-) when the policy changed
+	MutasiPemakaian    = "pemakaian"    // Approved leave (-)
+	MutasiPengembalian = "pengembalian" // Withdrawn leave given back (+)
+	MutasiPenyesuaian  = "penyesuaian"  // Manual HR adjustment (+/-) with a reason
+	MutasiBawaan       = "bawaan"       // Days carried over from last year (+/-)
+	MutasiHangus       = "hangus"       // Carried days forfeited at expiry (-)
+)
+
+// Entry is one mutasi_cuti row to post
+type Entry struct {
+	Jenis           string
+	Hari            float64
+	HariDibawa      float64 // Part of a pemakaian / pengembalian taken from or given back to carried days
+	PengajuanCutiID *int
+	Keterangan      string
+	DibuatOleh      *int // nil for entries made by the system
+}
+
+// BalanceIn reads the user's saldo_cuti row of the year inside tx. A user without one (not an
+// active employee) has an empty balance.
+func BalanceIn(tx *sql.Tx, penggunaID, year int) (*models.SaldoCuti, error) {
+	s := models.SaldoCuti{PenggunaID: penggunaID, Tahun: year}
+	var kedaluwarsa sql.NullTime
+	err := tx.QueryRow(`
+		SELECT id, total_hari, hari_terpakai, sisa_hari, hari_dibawa, dibawa_terpakai, hari_hangus,
+		       hari_penyesuaian, dibawa_kedaluwarsa
+		FROM saldo_cuti WHERE pengguna_id = ? AND tahun = ?
+	`, penggunaID, year).Scan(&s.ID, &s.TotalHari, &s.HariTerpakai, &s.SisaHari, &s.HariDibawa, &s.DibawaTerpakai,
+		&s.HariHangus, &s.HariPenyesuaian, &kedaluwarsa)
+	if err == sql.ErrNoRows {
+		return &s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kedaluwarsa.Valid {
+		s.DibawaKedaluwarsa = &kedaluwarsa.Time
+	}
+	return &s, nil
+}
+
+// lockBalance locks the user's saldo_cuti row of the year for the rest of tx and returns it. A row
+// from before the ledger existed first gets opening entries for the amounts it already holds.
+func lockBalance(tx *sql.Tx, penggunaID, year int) (*models.SaldoCuti, error) {
+	var s models.SaldoCuti
+	var kedaluwarsa sql.NullTime
+	err := tx.QueryRow(`
+		SELECT id, pengguna_id, tahun, total_hari, hari_terpakai, sisa_hari, hari_dibawa, dibawa_terpakai,
+		       hari_hangus, hari_penyesuaian, dibawa_kedaluwarsa
+		FROM saldo_cuti WHERE pengguna_id = ? AND tahun = ? FOR UPDATE
+	`, penggunaID, year).Scan(&s.ID, &s.PenggunaID, &s.Tahun, &s.TotalHari, &s.HariTerpakai, &s.SisaHari,
+		&s.HariDibawa, &s.DibawaTerpakai, &s.HariHangus, &s.HariPenyesuaian, &kedaluwarsa)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("saldo cuti tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if kedaluwarsa.Valid {
+		s.DibawaKedaluwarsa = &kedaluwarsa.Time
+	}
+
+	var entries int
+	err = tx.QueryRow("SELECT COUNT(*) FROM mutasi_cuti WHERE pengguna_id = ? AND tahun = ?", penggunaID, year).Scan(&entries)
+	if err != nil || entries > 0 {
+		return &s, err
+	}
+	opening := []Entry{
+		{Jenis: MutasiAkrual, Hari: s.TotalHari},
+		{Jenis: MutasiBawaan, Hari: s.HariDibawa},
+		{Jenis: MutasiPemakaian, Hari: -s.HariTerpakai, HariDibawa: -s.DibawaTerpakai},
+		{Jenis: MutasiHangus, Hari: -s.HariHangus},
+		{Jenis: MutasiPenyesuaian, Hari: s.HariPenyesuaian},
+	}
+	s.SisaHari = 0 // Running total of the opening entries
+	for _, e := range opening {
+		if e.Hari == 0 {
+			continue
+		}
+		e.Keterangan = "Saldo awal"
+		if err := post(tx, &s, e); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// post appends an entry to the ledger of the locked balance s and re-derives the saldo_cuti
+// amounts from the ledger, updating s to match
+func post(tx *sql.Tx, s *models.SaldoCuti, e Entry) error {
+	_, err := tx.Exec(`
+		INSERT INTO mutasi_cuti (pengguna_id, tahun, jenis, hari, hari_dibawa, saldo_setelah, pengajuan_cuti_id, keterangan, dibuat_oleh)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, s.PenggunaID, s.Tahun, e.Jenis, e.Hari, e.HariDibawa, s.SisaHari+e.Hari, e.PengajuanCutiID, e.Keterangan, e.DibuatOleh)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN jenis = 'akrual' THEN hari END), 0),
+		       COALESCE(SUM(CASE WHEN jenis IN ('pemakaian', 'pengembalian') THEN -hari END), 0),
+		       COALESCE(SUM(CASE WHEN jenis IN ('pemakaian', 'pengembalian') THEN -hari_dibawa END), 0),
+		       COALESCE(SUM(CASE WHEN jenis = 'bawaan' THEN hari END), 0),
+		       COALESCE(SUM(CASE WHEN jenis = 'hangus' THEN -hari END), 0),
+		       COALESCE(SUM(CASE WHEN jenis = 'penyesuaian' THEN hari END), 0),
+		       COALESCE(SUM(hari), 0)
+		FROM mutasi_cuti WHERE pengguna_id = ? AND tahun = ?
+	`, s.PenggunaID, s.Tahun).Scan(&s.TotalHari, &s.HariTerpakai, &s.DibawaTerpakai, &s.HariDibawa,
+		&s.HariHangus, &s.HariPenyesuaian, &s.SisaHari)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE saldo_cuti
+		SET total_hari = ?, hari_terpakai = ?, dibawa_terpakai = ?, hari_dibawa = ?, hari_hangus = ?, hari_penyesuaian = ?
+		WHERE id = ?
+	`, s.TotalHari, s.HariTerpakai, s.DibawaTerpakai, s.HariDibawa, s.HariHangus, s.HariPenyesuaian, s.ID)
+	return err
+}
+
+// Adjust posts a manual HR adjustment of days (negative to deduct) to the user's balance of the
+// year. A deduction cannot take the balance below zero.
+func Adjust(penggunaID, year int, days float64, alasan string, adjustedBy int) (*models.SaldoCuti, error) {
+	if days == 0 || math.Mod(days*2, 1) != 0 {
+		return nil, errors.New("hari penyesuaian harus kelipatan setengah hari dan tidak nol")
+	}
+	if alasan == "" {
+		return nil, errors.New("alasan penyesuaian wajib diisi")
+	}
+	if err := EnsureBalance(penggunaID, year); err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	s, err := lockBalance(tx, penggunaID, year)
+	if err != nil {
+		return nil, err
+	}
+	if s.SisaHari+days < 0 {
+		return nil, fmt.Errorf("sisa cuti %.1f hari tidak mencukupi untuk dikurangi %.1f hari", s.SisaHari, -days)
+	}
+	if err := post(tx, s, Entry{Jenis: MutasiPenyesuaian, Hari: days, Keterangan: alasan, DibuatOleh: &adjustedBy}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Ledger lists the entries of the user's balance of the year, oldest first
+func Ledger(penggunaID, year int) ([]models.MutasiCuti, error) {
+	rows, err := database.DB.Query(`
+		SELECT m.id, m.pengguna_id, m.tahun, m.jenis, m.hari, m.hari_dibawa, m.saldo_setelah,
+		       m.pengajuan_cuti_id, m.keterangan, m.dibuat_oleh, p.nama_lengkap, m.dibuat_pada
+		FROM mutasi_cuti m
+		LEFT JOIN pengguna p ON m.dibuat_oleh = p.id
+		WHERE m.pengguna_id = ? AND m.tahun = ?
+		ORDER BY m.id ASC
+	`, penggunaID, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.MutasiCuti{}
+	for rows.Next() {
+		var m models.MutasiCuti
+		if err := rows.Scan(&m.ID, &m.PenggunaID, &m.Tahun, &m.Jenis, &m.Hari, &m.HariDibawa, &m.SaldoSetelah,
+			&m.PengajuanCutiID, &m.Keterangan, &m.DibuatOleh, &m.NamaPembuat, &m.DibuatPada); err != nil {
+			return nil, err
+		}
+		entries = append(entries, m)
+	}
+	return entries, rows.Err()
+}
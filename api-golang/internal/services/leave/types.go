@@ -0,0 +1,105 @@
+package leave
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/database"
+	"github.com/hris-system/api-golang/internal/models"
+)
+
+const typeColumns = `
+	id, kode, nama, potong_saldo, dibayar, maks_hari_per_pengajuan, maks_hari_per_tahun,
+	jenis_kelamin, masa_kerja_minimal_bulan, wajib_dokumen, wajib_dokumen_minimal_hari, aktif
+`
+
+func scanType(row interface{ Scan(...interface{}) error }, t *models.JenisCuti) error {
+	return row.Scan(&t.ID, &t.Kode, &t.Nama, &t.PotongSaldo, &t.Dibayar, &t.MaksHariPerPengajuan, &t.MaksHariPerTahun,
+		&t.JenisKelamin, &t.MasaKerjaMinimalBulan, &t.WajibDokumen, &t.WajibDokumenMinimalHari, &t.Aktif)
+}
+
+// Types lists the leave type catalogue, optionally active types only
+func Types(activeOnly bool) ([]models.JenisCuti, error) {
+	query := "SELECT " + typeColumns + " FROM jenis_cuti"
+	if activeOnly {
+		query += " WHERE aktif = TRUE"
+	}
+	query += " ORDER BY id ASC"
+
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []models.JenisCuti{}
+	for rows.Next() {
+		var t models.JenisCuti
+		if err := scanType(rows, &t); err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, rows.Err()
+}
+
+// TypeByCode loads an active leave type by its kode
+func TypeByCode(kode string) (*models.JenisCuti, error) {
+	var t models.JenisCuti
+	err := scanType(database.DB.QueryRow("SELECT "+typeColumns+" FROM jenis_cuti WHERE kode = ? AND aktif = TRUE", kode), &t)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("jenis cuti tidak dikenal")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CheckEligibility refuses a request of days starting on start when the employee's gender or
+// tenure does not qualify, or when it exceeds the type's per-request or per-year limit. Pending
+// and approved requests of the same type count towards the yearly limit.
+func CheckEligibility(t *models.JenisCuti, penggunaID int, start time.Time, days float64) error {
+	var jenisKelamin sql.NullString
+	var bergabung time.Time
+	err := database.DB.QueryRow(`
+		SELECT jenis_kelamin, COALESCE(tanggal_bergabung, DATE(dibuat_pada)) FROM pengguna WHERE id = ?
+	`, penggunaID).Scan(&jenisKelamin, &bergabung)
+	if err == sql.ErrNoRows {
+		return errors.New("karyawan tidak ditemukan")
+	}
+	if err != nil {
+		return err
+	}
+
+	if t.JenisKelamin != nil && (!jenisKelamin.Valid || jenisKelamin.String != *t.JenisKelamin) {
+		return fmt.Errorf("%s hanya untuk karyawan %s", t.Nama, namaJenisKelamin[*t.JenisKelamin])
+	}
+	if t.MasaKerjaMinimalBulan > 0 {
+		bergabung = time.Date(bergabung.Year(), bergabung.Month(), bergabung.Day(), 0, 0, 0, 0, time.Local)
+		if start.Before(bergabung.AddDate(0, t.MasaKerjaMinimalBulan, 0)) {
+			return fmt.Errorf("%s memerlukan masa kerja minimal %d bulan", t.Nama, t.MasaKerjaMinimalBulan)
+		}
+	}
+	if t.MaksHariPerPengajuan != nil && days > *t.MaksHariPerPengajuan {
+		return fmt.Errorf("%s maksimal %g hari per pengajuan", t.Nama, *t.MaksHariPerPengajuan)
+	}
+	if t.MaksHariPerTahun != nil {
+		var taken float64
+		err := database.DB.QueryRow(`
+			SELECT COALESCE(SUM(total_hari), 0) FROM pengajuan_cuti
+			WHERE pengguna_id = ? AND tipe_cuti = ? AND YEAR(tanggal_mulai) = ? AND status IN ('menunggu', 'disetujui')
+		`, penggunaID, t.Kode, start.Year()).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken+days > *t.MaksHariPerTahun {
+			return fmt.Errorf("%s maksimal %g hari per tahun, sudah diajukan %g hari", t.Nama, *t.MaksHariPerTahun, taken)
+		}
+	}
+	return nil
+}
+
+var namaJenisKelamin = map[string]string{"L": "laki-laki", "P": "perempuan"}
@@ -0,0 +1,146 @@
+package leave
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/hris-system/api-golang/internal/services/attendance"
+)
+
+// Withdrawal is what withdrawing an approved leave from a date on changes
+type Withdrawal struct {
+	TanggalSelesaiBaru *string `json:"tanggal_selesai_baru"` // nil = the whole leave is withdrawn
+	DitarikMulai       string  `json:"ditarik_mulai"`        // First withdrawn date
+	DitarikSelesai     string  `json:"ditarik_selesai"`
+	SisaHari           float64 `json:"sisa_hari"` // Working days still taken
+	HariDikembalikan   float64 `json:"hari_dikembalikan"`
+}
+
+// PlanWithdrawal works out a withdrawal of an approved leave of totalHari days from mulai to selesai.
+// tanggalSelesaiBaru shortens the leave to end on that date; "" withdraws all of it. Only dates
+// after today can be withdrawn, so days already taken stay on the balance.
+func PlanWithdrawal(mulai, selesai time.Time, setengahHari *string, totalHari float64, tanggalSelesaiBaru string, today time.Time) (*Withdrawal, error) {
+	return planWithdrawal(mulai, selesai, setengahHari, totalHari, tanggalSelesaiBaru, today, func(end time.Time) (float64, error) {
+		config, err := attendance.ActiveConfig()
+		if err != nil {
+			return 0, err
+		}
+		remaining, err := attendance.LeaveDurationFor(mulai.Format("2006-01-02"), end.Format("2006-01-02"), "", config)
+		if err != nil {
+			return 0, errors.New("sisa cuti tidak mencakup hari kerja, tarik seluruh cuti")
+		}
+		return remaining.TotalHari, nil
+	})
+}
+
+// planWithdrawal is PlanWithdrawal with the working days from mulai to a new end date counted by
+// daysUntil
+func planWithdrawal(mulai, selesai time.Time, setengahHari *string, totalHari float64, tanggalSelesaiBaru string, today time.Time, daysUntil func(end time.Time) (float64, error)) (*Withdrawal, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	w := &Withdrawal{DitarikSelesai: selesai.Format("2006-01-02")}
+
+	if tanggalSelesaiBaru == "" {
+		if !mulai.After(today) {
+			return nil, errors.New("cuti yang sudah berjalan hanya dapat ditarik sebagian dengan memajukan tanggal selesai")
+		}
+		w.DitarikMulai = mulai.Format("2006-01-02")
+		w.HariDikembalikan = totalHari
+		return w, nil
+	}
+
+	end, err := time.ParseInLocation("2006-01-02", tanggalSelesaiBaru, time.Local)
+	if err != nil {
+		return nil, errors.New("format tanggal selesai baru tidak valid")
+	}
+	if setengahHari != nil {
+		return nil, errors.New("cuti setengah hari hanya dapat ditarik seluruhnya")
+	}
+	if end.Before(mulai) || !end.Before(selesai) {
+		return nil, errors.New("tanggal selesai baru harus di antara tanggal mulai dan tanggal selesai cuti")
+	}
+	if end.Before(today) {
+		return nil, errors.New("tanggal yang sudah lewat tidak dapat ditarik")
+	}
+
+	remaining, err := daysUntil(end)
+	if err != nil {
+		return nil, err
+	}
+	w.TanggalSelesaiBaru = &tanggalSelesaiBaru
+	w.DitarikMulai = end.AddDate(0, 0, 1).Format("2006-01-02")
+	w.SisaHari = remaining
+	w.HariDikembalikan = math.Max(totalHari-remaining, 0)
+	return w, nil
+}
+
+// Refund returns the withdrawn days of a leave running from mulai to selesai to the user's
+// balances inside tx and reports how many were returned. Withdrawals cut the end of a leave, so a
+// leave spanning a new year gives back to the later year first. No year gets back more than the
+// leave still holds in its ledger (its usage less earlier refunds), so a leave cannot give back
+// days it never took.
+func Refund(tx *sql.Tx, penggunaID int, mulai, selesai time.Time, days float64, pengajuanID int) (float64, error) {
+	refunded := 0.0
+	for year := selesai.Year(); year >= mulai.Year() && refunded < days; year-- {
+		n, err := refundYear(tx, penggunaID, year, days-refunded, pengajuanID)
+		if err != nil {
+			return refunded, err
+		}
+		refunded += n
+	}
+	return refunded, nil
+}
+
+// refundYear returns up to days of the leave to the balance of the year. The year's own days are
+// returned before carried-over ones; carried days refunded after they expired are forfeited again.
+func refundYear(tx *sql.Tx, penggunaID, year int, days float64, pengajuanID int) (float64, error) {
+	var held, heldCarried float64
+	err := tx.QueryRow(`
+		SELECT COALESCE(-SUM(hari), 0), COALESCE(-SUM(hari_dibawa), 0) FROM mutasi_cuti
+		WHERE pengguna_id = ? AND tahun = ? AND pengajuan_cuti_id = ? AND jenis IN (?, ?)
+	`, penggunaID, year, pengajuanID, MutasiPemakaian, MutasiPengembalian).Scan(&held, &heldCarried)
+	if err != nil {
+		return 0, err
+	}
+
+	days = math.Max(math.Min(days, held), 0)
+	if days == 0 {
+		return 0, nil
+	}
+	s, err := lockBalance(tx, penggunaID, year)
+	if err != nil {
+		return 0, err
+	}
+	fromCarried := math.Min(math.Max(days-(held-heldCarried), 0), heldCarried)
+	err = post(tx, s, Entry{
+		Jenis:           MutasiPengembalian,
+		Hari:            days,
+		HariDibawa:      fromCarried,
+		PengajuanCutiID: &pengajuanID,
+		Keterangan:      "Penarikan cuti disetujui",
+	})
+	if err != nil || s.HariHangus == 0 || fromCarried == 0 {
+		return days, err
+	}
+	return days, post(tx, s, Entry{
+		Jenis:           MutasiHangus,
+		Hari:            -fromCarried,
+		PengajuanCutiID: &pengajuanID,
+		Keterangan:      "Sisa dibawa yang dikembalikan sudah kedaluwarsa",
+	})
+}
+
+// ClearLeavePresensi removes the presensi rows recorded as leave (izin / cuti without clock-in)
+// of the user between from and to
+func ClearLeavePresensi(tx *sql.Tx, penggunaID int, from, to string) (int64, error) {
+	res, err := tx.Exec(`
+		DELETE FROM presensi
+		WHERE pengguna_id = ? AND tanggal BETWEEN ? AND ?
+		AND status IN ('cuti', 'izin') AND waktu_masuk IS NULL
+	`, penggunaID, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
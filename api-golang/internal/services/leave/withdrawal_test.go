@@ -0,0 +1,67 @@
+package leave
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPlanWithdrawal(t *testing.T) {
+	// Leave of Monday 10 to Friday 14 June 2024, five working days
+	mulai, selesai := date("2024-06-10"), date("2024-06-14")
+	weekdays := func(end time.Time) (float64, error) {
+		days := 0.0
+		for d := mulai; !d.After(end); d = d.AddDate(0, 0, 1) {
+			if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+				days++
+			}
+		}
+		return days, nil
+	}
+	pagi := "pagi"
+
+	tests := []struct {
+		name         string
+		setengahHari *string
+		total        float64
+		selesaiBaru  string
+		today        time.Time
+		ditarikMulai string
+		sisa         float64
+		kembali      float64
+		wantErr      bool
+	}{
+		{"whole leave before it starts", nil, 5, "", date("2024-06-05"), "2024-06-10", 0, 5, false},
+		{"whole leave late the evening before", nil, 5, "", date("2024-06-09").Add(23 * time.Hour), "2024-06-10", 0, 5, false},
+		{"whole leave once started", nil, 5, "", date("2024-06-10"), "", 0, 0, true},
+		{"shortened in advance", nil, 5, "2024-06-12", date("2024-06-05"), "2024-06-13", 3, 2, false},
+		{"shortened while running", nil, 5, "2024-06-11", date("2024-06-11"), "2024-06-12", 2, 3, false},
+		{"new end already past", nil, 5, "2024-06-12", date("2024-06-13"), "", 0, 0, true},
+		{"new end not shorter", nil, 5, "2024-06-14", date("2024-06-05"), "", 0, 0, true},
+		{"new end before the start", nil, 5, "2024-06-09", date("2024-06-05"), "", 0, 0, true},
+		{"bad date", nil, 5, "12-06-2024", date("2024-06-05"), "", 0, 0, true},
+		{"half day shortened", &pagi, 0.5, "2024-06-10", date("2024-06-05"), "", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := planWithdrawal(mulai, selesai, tt.setengahHari, tt.total, tt.selesaiBaru, tt.today, weekdays)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", w)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if w.DitarikMulai != tt.ditarikMulai || w.DitarikSelesai != "2024-06-14" {
+				t.Errorf("withdrawn %s to %s, want %s to 2024-06-14", w.DitarikMulai, w.DitarikSelesai, tt.ditarikMulai)
+			}
+			if w.SisaHari != tt.sisa || w.HariDikembalikan != tt.kembali {
+				t.Errorf("sisa %.1f, dikembalikan %.1f; want %.1f, %.1f", w.SisaHari, w.HariDikembalikan, tt.sisa, tt.kembali)
+			}
+			if (tt.selesaiBaru == "") != (w.TanggalSelesaiBaru == nil) {
+				t.Errorf("tanggal_selesai_baru %v for %q", w.TanggalSelesaiBaru, tt.selesaiBaru)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage keeps files on the local disk
+type LocalStorage struct {
+	BasePath string
+}
+
+func (s *LocalStorage) filePath(key string) (string, error) {
+	cleaned, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.BasePath, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalStorage) Put(key string, r io.Reader, size int64, contentType string) error {
+	p, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so readers never see a partial upload
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	p, err := s.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	p, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// Storage is a blob store for uploaded files (selfies, attachments, ...)
+type Storage interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// ErrNotFound is returned by Get when the key does not exist
+var ErrNotFound = errors.New("file tidak ditemukan")
+
+// Store is the backend selected by STORAGE_DRIVER
+var Store Storage
+
+// Init configures Store from the environment.
+// STORAGE_DRIVER=local (default) writes under STORAGE_LOCAL_PATH,
+// STORAGE_DRIVER=s3 uses any S3-compatible endpoint (AWS, MinIO, ...).
+func Init() error {
+	driver := os.Getenv("STORAGE_DRIVER")
+	switch driver {
+	case "", "local":
+		basePath := os.Getenv("STORAGE_LOCAL_PATH")
+		if basePath == "" {
+			basePath = "./uploads"
+		}
+		Store = &LocalStorage{BasePath: basePath}
+		log.Printf("✅ Storage: local disk (%s)", basePath)
+	case "s3":
+		s3, err := NewS3StorageFromEnv()
+		if err != nil {
+			return err
+		}
+		Store = s3
+		log.Printf("✅ Storage: S3 bucket %s at %s", s3.Bucket, s3.Endpoint)
+	default:
+		return fmt.Errorf("STORAGE_DRIVER tidak dikenal: %s", driver)
+	}
+	return nil
+}
+
+// CleanKey normalises a key and rejects path traversal
+func CleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(key, "\\", "/"))
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || cleaned == "." || strings.HasPrefix(cleaned, "..") {
+		return "", fmt.Errorf("key penyimpanan tidak valid: %q", key)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,35 @@
+package storage
+
+import "testing"
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"presensi/2024/06/foto.jpg", "presensi/2024/06/foto.jpg", false},
+		{"/presensi/foto.jpg", "presensi/foto.jpg", false},
+		{"presensi//2024/./foto.jpg", "presensi/2024/foto.jpg", false},
+		{`cuti\12\surat.pdf`, "cuti/12/surat.pdf", false},
+		{"cuti/12/../13/surat.pdf", "cuti/13/surat.pdf", false},
+		{"../etc/passwd", "etc/passwd", false},
+		{`..\..\etc\passwd`, "etc/passwd", false},
+		{"", "", true},
+		{"/", "", true},
+		{".", "", true},
+		{"..", "", true},
+	}
+	for _, tt := range tests {
+		got, err := CleanKey(tt.key)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("CleanKey(%q) = %q, want an error", tt.key, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("CleanKey(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
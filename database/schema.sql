@@ -46,11 +46,16 @@ CREATE TABLE pengguna (
     nama_bank VARCHAR(50),
     nomor_rekening VARCHAR(50),
     nama_pemilik_rekening VARCHAR(100),
+    pin_mesin VARCHAR(20) NULL UNIQUE COMMENT 'User ID terdaftar di mesin fingerprint',
+    tanggal_bergabung DATE NULL COMMENT 'Awal masa kerja; NULL = tanggal dibuat_pada',
+    jenis_kelamin ENUM('L', 'P') NULL COMMENT 'Untuk jenis cuti khusus laki-laki / perempuan',
+    atasan_id INT NULL COMMENT 'Atasan langsung, penyetuju cuti pertama sebelum HR',
     aktif BOOLEAN DEFAULT TRUE,
     dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (peran_id) REFERENCES peran(id) ON DELETE RESTRICT,
-    FOREIGN KEY (divisi_id) REFERENCES divisi(id) ON DELETE SET NULL
+    FOREIGN KEY (divisi_id) REFERENCES divisi(id) ON DELETE SET NULL,
+    FOREIGN KEY (atasan_id) REFERENCES pengguna(id) ON DELETE SET NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- ============================================================
@@ -91,6 +96,11 @@ CREATE TABLE konfigurasi_cuti (
     divisi_id INT NOT NULL,
     jatah_cuti_tahunan INT NOT NULL COMMENT 'Jatah cuti per tahun',
     tahun_berlaku YEAR NOT NULL,
+    akrual ENUM('tahunan', 'bulanan') NOT NULL DEFAULT 'tahunan' COMMENT 'tahunan = penuh sekaligus, bulanan = 1/12 tiap awal bulan',
+    masa_kerja_minimal_bulan INT NOT NULL DEFAULT 12 COMMENT 'Masa kerja sebelum berhak cuti',
+    maks_sisa_dibawa DECIMAL(5,1) NOT NULL DEFAULT 6 COMMENT 'Sisa cuti maksimal yang dibawa ke tahun berikutnya',
+    bulan_kedaluwarsa_sisa INT NOT NULL DEFAULT 6 COMMENT 'Sisa yang dibawa hangus setelah akhir bulan ini',
+    minimal_karyawan_hadir INT NULL COMMENT 'Karyawan minimal yang hadir per hari kerja; NULL = tanpa batas',
     aktif BOOLEAN DEFAULT TRUE,
     dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
@@ -109,11 +119,44 @@ CREATE TABLE konfigurasi_presensi (
     latitude_kantor DECIMAL(10,8) NOT NULL COMMENT 'Latitude kantor',
     longitude_kantor DECIMAL(11,8) NOT NULL COMMENT 'Longitude kantor',
     radius_meter INT NOT NULL COMMENT 'Radius presensi dalam meter',
+    hari_kerja VARCHAR(20) NOT NULL DEFAULT '1,2,3,4,5' COMMENT 'Hari kerja (ISO: 1=Senin ... 7=Minggu)',
+    toleransi_terlambat_menit INT NOT NULL DEFAULT 0 COMMENT 'Masa tenggang setelah jam masuk maksimal',
+    maks_istirahat_menit INT NOT NULL DEFAULT 60 COMMENT 'Batas total durasi istirahat per hari, 0 = tanpa batas',
     aktif BOOLEAN DEFAULT TRUE,
     dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- Tabel: tingkat_keterlambatan (Tingkatan sanksi keterlambatan untuk potongan gaji & surat peringatan)
+CREATE TABLE tingkat_keterlambatan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    nama VARCHAR(50) NOT NULL,
+    menit_dari INT NOT NULL COMMENT 'Batas bawah menit terlambat (inklusif)',
+    menit_sampai INT NULL COMMENT 'Batas atas menit terlambat (inklusif), NULL = tanpa batas',
+    setengah_hari BOOLEAN DEFAULT FALSE COMMENT 'Dihitung sebagai setengah hari kerja',
+    aturan_potongan_id INT NULL COMMENT 'Aturan potongan gaji yang dikenakan',
+    poin_peringatan INT NOT NULL DEFAULT 0 COMMENT 'Poin akumulasi untuk surat peringatan',
+    aktif BOOLEAN DEFAULT TRUE,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (aturan_potongan_id) REFERENCES aturan_potongan(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: hari_libur (Kalender libur nasional, cuti bersama & libur perusahaan)
+CREATE TABLE hari_libur (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    tanggal DATE NOT NULL,
+    nama VARCHAR(150) NOT NULL,
+    jenis ENUM('nasional', 'cuti_bersama', 'perusahaan') NOT NULL DEFAULT 'nasional',
+    sumber ENUM('manual', 'ical') NOT NULL DEFAULT 'manual' COMMENT 'Diinput HR atau diimpor dari berkas iCal',
+    uid_ical VARCHAR(255) NULL COMMENT 'UID event iCal asal',
+    dibuat_oleh INT NULL,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_hari_libur_tanggal (tanggal),
+    FOREIGN KEY (dibuat_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- ============================================================
 -- 5. PRESENSI (Panel Karyawan & HR)
 -- ============================================================
@@ -126,54 +169,383 @@ CREATE TABLE presensi (
     waktu_masuk DATETIME,
     latitude_masuk DECIMAL(10,8),
     longitude_masuk DECIMAL(11,8),
+    akurasi_masuk DECIMAL(8,2) NULL COMMENT 'Akurasi GPS presensi masuk (meter)',
     waktu_pulang DATETIME,
     latitude_pulang DECIMAL(10,8),
     longitude_pulang DECIMAL(11,8),
-    status ENUM('hadir', 'terlambat', 'tidak_hadir', 'izin', 'cuti') NOT NULL,
+    akurasi_pulang DECIMAL(8,2) NULL COMMENT 'Akurasi GPS presensi pulang (meter)',
+    perangkat_id VARCHAR(100) NULL COMMENT 'ID perangkat yang dipakai presensi masuk',
+    info_perangkat VARCHAR(255) NULL COMMENT 'Model / OS / versi aplikasi',
+    foto_masuk VARCHAR(255) NULL COMMENT 'Key penyimpanan foto selfie presensi masuk',
+    foto_pulang VARCHAR(255) NULL COMMENT 'Key penyimpanan foto selfie presensi pulang',
+    sumber VARCHAR(20) NOT NULL DEFAULT 'gps' COMMENT 'gps, offline, kiosk, mesin, koreksi, hr_manual, ...',
+    mode_kerja ENUM('kantor', 'wfh', 'dinas') NOT NULL DEFAULT 'kantor' COMMENT 'wfh / dinas jika presensi memakai pengajuan WFH / perjalanan dinas yang disetujui',
+    disinkron_pada DATETIME NULL COMMENT 'Waktu presensi offline diterima server',
+    status ENUM('hadir', 'terlambat', 'tidak_hadir', 'izin', 'cuti', 'dinas') NOT NULL,
+    menit_terlambat INT NOT NULL DEFAULT 0 COMMENT 'Menit terlambat dihitung dari jam masuk maksimal',
+    tingkat_keterlambatan_id INT NULL,
     catatan TEXT,
     dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (tingkat_keterlambatan_id) REFERENCES tingkat_keterlambatan(id) ON DELETE SET NULL,
     UNIQUE KEY unik_pengguna_tanggal (pengguna_id, tanggal)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- Tabel: koreksi_presensi (Pengajuan koreksi presensi oleh karyawan)
+CREATE TABLE koreksi_presensi (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL,
+    presensi_id INT NULL COMMENT 'NULL jika belum ada presensi pada tanggal tersebut',
+    tanggal DATE NOT NULL,
+    waktu_masuk_usulan DATETIME NULL,
+    waktu_pulang_usulan DATETIME NULL,
+    alasan TEXT NOT NULL,
+    bukti VARCHAR(255) NULL COMMENT 'Tautan / keterangan bukti pendukung',
+    status ENUM('menunggu', 'disetujui', 'ditolak') DEFAULT 'menunggu',
+    diproses_oleh INT NULL COMMENT 'ID Pengguna HR yang memproses',
+    tanggal_persetujuan DATETIME NULL,
+    catatan_persetujuan TEXT,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (presensi_id) REFERENCES presensi(id) ON DELETE SET NULL,
+    FOREIGN KEY (diproses_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: pengajuan_wfh (Pengajuan kerja dari rumah / remote oleh karyawan)
+CREATE TABLE pengajuan_wfh (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL,
+    tanggal_mulai DATE NOT NULL,
+    tanggal_selesai DATE NOT NULL,
+    alasan TEXT NOT NULL,
+    alamat VARCHAR(255) NULL COMMENT 'Alamat lokasi kerja remote',
+    latitude DECIMAL(10,8) NULL COMMENT 'Lokasi kerja remote; presensi harus dalam radius kantor dari titik ini',
+    longitude DECIMAL(11,8) NULL,
+    tanpa_radius BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'HR menyetujui tanpa lokasi: presensi WFH diterima dari mana saja',
+    status ENUM('menunggu', 'disetujui', 'ditolak') DEFAULT 'menunggu',
+    diproses_oleh INT NULL COMMENT 'ID Pengguna HR yang memproses',
+    tanggal_persetujuan DATETIME NULL,
+    catatan_persetujuan TEXT,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (diproses_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: perjalanan_dinas (Pengajuan perjalanan dinas luar)
+CREATE TABLE perjalanan_dinas (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL,
+    tanggal_mulai DATE NOT NULL,
+    tanggal_selesai DATE NOT NULL,
+    tujuan VARCHAR(150) NOT NULL COMMENT 'Kota / lokasi tujuan',
+    keperluan TEXT NOT NULL,
+    latitude_tujuan DECIMAL(10,8) NULL COMMENT 'Pusat wilayah tujuan; NULL = presensi di mana saja',
+    longitude_tujuan DECIMAL(11,8) NULL,
+    radius_km INT NOT NULL DEFAULT 50 COMMENT 'Radius wilayah tujuan untuk presensi GPS',
+    uang_harian DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Uang harian per hari, ditetapkan HR saat persetujuan',
+    status ENUM('menunggu', 'disetujui', 'ditolak') DEFAULT 'menunggu',
+    disetujui_oleh INT NULL,
+    tanggal_persetujuan DATETIME NULL,
+    catatan_persetujuan TEXT,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (disetujui_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: istirahat_presensi (Presensi mulai & selesai istirahat dalam satu hari kerja)
+CREATE TABLE istirahat_presensi (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    presensi_id INT NOT NULL,
+    waktu_mulai DATETIME NOT NULL,
+    waktu_selesai DATETIME NULL COMMENT 'NULL selama istirahat berlangsung',
+    ditutup_otomatis BOOLEAN DEFAULT FALSE COMMENT 'Ditutup oleh presensi pulang',
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (presensi_id) REFERENCES presensi(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: riwayat_perubahan_presensi (Jejak audit perubahan presensi)
+CREATE TABLE riwayat_perubahan_presensi (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    presensi_id INT NOT NULL,
+    pengguna_id INT NOT NULL,
+    tanggal DATE NOT NULL,
+    sumber VARCHAR(30) NOT NULL COMMENT 'koreksi, hr_manual, ...',
+    referensi_id INT NULL COMMENT 'ID data sumber, mis. koreksi_presensi.id',
+    waktu_masuk_lama DATETIME NULL,
+    waktu_pulang_lama DATETIME NULL,
+    status_lama VARCHAR(20) NULL,
+    waktu_masuk_baru DATETIME NULL,
+    waktu_pulang_baru DATETIME NULL,
+    status_baru VARCHAR(20) NOT NULL,
+    alasan TEXT,
+    diubah_oleh INT NULL COMMENT 'ID Pengguna yang melakukan perubahan',
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (presensi_id) REFERENCES presensi(id) ON DELETE CASCADE,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (diubah_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: tanda_presensi (Presensi mencurigakan hasil deteksi anomali GPS)
+CREATE TABLE tanda_presensi (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    presensi_id INT NOT NULL,
+    pengguna_id INT NOT NULL,
+    jenis_presensi ENUM('masuk', 'pulang') NOT NULL,
+    jenis VARCHAR(30) NOT NULL COMMENT 'perjalanan_mustahil, koordinat_identik, akurasi_mencurigakan, perangkat_bersama, lokasi_palsu',
+    detail TEXT,
+    status ENUM('menunggu', 'valid', 'curang') DEFAULT 'menunggu',
+    ditinjau_oleh INT NULL COMMENT 'ID Pengguna HR yang meninjau',
+    ditinjau_pada DATETIME NULL,
+    catatan_tinjauan TEXT,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (presensi_id) REFERENCES presensi(id) ON DELETE CASCADE,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (ditinjau_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: perangkat_offline (Kunci penandatanganan presensi offline per perangkat)
+CREATE TABLE perangkat_offline (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL,
+    perangkat_id VARCHAR(100) NOT NULL,
+    kunci_rahasia VARCHAR(64) NOT NULL COMMENT 'Kunci HMAC-SHA256 (hex)',
+    aktif BOOLEAN DEFAULT TRUE,
+    terakhir_kontak DATETIME NULL COMMENT 'Pendaftaran atau sinkronisasi terakhir perangkat',
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    UNIQUE KEY unik_pengguna_perangkat (pengguna_id, perangkat_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: presensi_offline (Presensi yang direkam tanpa sinyal dan disinkronkan kemudian)
+CREATE TABLE presensi_offline (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL,
+    perangkat_id VARCHAR(100) NOT NULL,
+    jenis ENUM('masuk', 'pulang') NOT NULL,
+    waktu_perangkat DATETIME NOT NULL COMMENT 'Waktu menurut jam perangkat',
+    waktu_terkoreksi DATETIME NOT NULL COMMENT 'Waktu setelah dikoreksi selisih jam perangkat',
+    selisih_jam_detik INT NOT NULL DEFAULT 0 COMMENT 'Jam server dikurangi jam perangkat saat sinkronisasi',
+    latitude DECIMAL(10,8),
+    longitude DECIMAL(11,8),
+    akurasi DECIMAL(8,2) NULL,
+    nonce VARCHAR(64) NOT NULL,
+    status ENUM('diterima', 'menunggu', 'disetujui', 'ditolak') NOT NULL,
+    alasan_penahanan TEXT COMMENT 'Alasan presensi menunggu persetujuan HR',
+    presensi_id INT NULL,
+    diproses_oleh INT NULL COMMENT 'ID Pengguna HR yang memproses',
+    diproses_pada DATETIME NULL,
+    catatan TEXT,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (presensi_id) REFERENCES presensi(id) ON DELETE SET NULL,
+    FOREIGN KEY (diproses_oleh) REFERENCES pengguna(id) ON DELETE SET NULL,
+    UNIQUE KEY unik_perangkat_nonce (perangkat_id, nonce)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: kiosk (Tablet bersama di pintu masuk lokasi kerja, menampilkan QR presensi)
+CREATE TABLE kiosk (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    nama VARCHAR(100) NOT NULL,
+    lokasi VARCHAR(255) NOT NULL DEFAULT '',
+    latitude DECIMAL(10,8) NOT NULL,
+    longitude DECIMAL(11,8) NOT NULL,
+    kunci_rahasia VARCHAR(64) NOT NULL COMMENT 'Kunci HMAC penanda tangan kode QR (tidak pernah dikirim ke tablet)',
+    token_akses VARCHAR(64) NOT NULL COMMENT 'Token autentikasi tablet kiosk',
+    aktif BOOLEAN DEFAULT TRUE,
+    dibuat_oleh INT NULL,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (dibuat_oleh) REFERENCES pengguna(id) ON DELETE SET NULL,
+    UNIQUE KEY unik_token_akses (token_akses)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: mesin_absensi (Mesin fingerprint ZKTeco yang boleh mengirim log via ADMS)
+CREATE TABLE mesin_absensi (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    nomor_seri VARCHAR(50) NOT NULL UNIQUE,
+    nama VARCHAR(100) NOT NULL,
+    lokasi VARCHAR(255) NOT NULL DEFAULT '',
+    aktif BOOLEAN DEFAULT TRUE,
+    terakhir_terhubung DATETIME NULL,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: log_mesin_absensi (Data mentah scan fingerprint sebelum dipasangkan menjadi presensi)
+CREATE TABLE log_mesin_absensi (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    nomor_seri VARCHAR(50) NOT NULL DEFAULT '' COMMENT 'Kosong untuk impor berkas',
+    pin VARCHAR(20) NOT NULL,
+    waktu DATETIME NOT NULL,
+    pengguna_id INT NULL COMMENT 'NULL jika PIN belum dipetakan',
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE SET NULL,
+    UNIQUE KEY unik_pin_waktu (pin, waktu)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- ============================================================
 -- 6. PENGAJUAN IZIN & CUTI (Panel Karyawan & HR)
 -- ============================================================
 
+-- Tabel: jenis_cuti (Katalog jenis izin & cuti)
+CREATE TABLE jenis_cuti (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    kode VARCHAR(30) NOT NULL UNIQUE COMMENT 'Disimpan di pengajuan_cuti.tipe_cuti',
+    nama VARCHAR(100) NOT NULL,
+    potong_saldo BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Mengurangi saldo_cuti saat disetujui',
+    dibayar BOOLEAN NOT NULL DEFAULT TRUE,
+    maks_hari_per_pengajuan DECIMAL(5,1) NULL COMMENT 'NULL = tanpa batas',
+    maks_hari_per_tahun DECIMAL(5,1) NULL COMMENT 'NULL = tanpa batas',
+    jenis_kelamin ENUM('L', 'P') NULL COMMENT 'NULL = semua karyawan',
+    masa_kerja_minimal_bulan INT NOT NULL DEFAULT 0,
+    wajib_dokumen BOOLEAN NOT NULL DEFAULT FALSE,
+    wajib_dokumen_minimal_hari DECIMAL(5,1) NOT NULL DEFAULT 0 COMMENT 'Dokumen wajib mulai jumlah hari ini (0 = selalu)',
+    aktif BOOLEAN DEFAULT TRUE,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- Tabel: pengajuan_cuti (Pengajuan izin & cuti)
 CREATE TABLE pengajuan_cuti (
     id INT PRIMARY KEY AUTO_INCREMENT,
     pengguna_id INT NOT NULL,
-    tipe_cuti ENUM('cuti', 'izin', 'sakit') NOT NULL,
+    tipe_cuti VARCHAR(30) NOT NULL COMMENT 'FK ke jenis_cuti.kode',
     tanggal_mulai DATE NOT NULL,
     tanggal_selesai DATE NOT NULL,
-    total_hari INT NOT NULL,
+    setengah_hari ENUM('pagi', 'siang') NULL COMMENT 'Cuti setengah hari (hanya satu tanggal)',
+    total_hari DECIMAL(5,1) NOT NULL COMMENT 'Hari kerja yang diambil, tanpa akhir pekan & hari libur',
     alasan TEXT NOT NULL,
-    status ENUM('menunggu', 'disetujui', 'ditolak') DEFAULT 'menunggu',
+    status ENUM('menunggu', 'disetujui', 'ditolak', 'dibatalkan', 'ditarik') DEFAULT 'menunggu' COMMENT 'dibatalkan oleh karyawan sebelum diproses; ditarik seluruhnya setelah disetujui',
     disetujui_oleh INT NULL COMMENT 'ID Pengguna HR yang menyetujui',
     tanggal_persetujuan DATETIME NULL,
     catatan_persetujuan TEXT,
     dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (tipe_cuti) REFERENCES jenis_cuti(kode) ON UPDATE CASCADE,
     FOREIGN KEY (disetujui_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- Tabel: lampiran_cuti (Dokumen pendukung pengajuan cuti, mis. surat dokter)
+CREATE TABLE lampiran_cuti (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengajuan_cuti_id INT NOT NULL,
+    nama_berkas VARCHAR(255) NOT NULL COMMENT 'Nama file asli',
+    kunci_penyimpanan VARCHAR(255) NOT NULL COMMENT 'Key di storage (STORAGE_DRIVER)',
+    tipe_konten VARCHAR(100) NOT NULL,
+    ukuran INT NOT NULL COMMENT 'Byte',
+    diunggah_oleh INT NULL,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengajuan_cuti_id) REFERENCES pengajuan_cuti(id) ON DELETE CASCADE,
+    FOREIGN KEY (diunggah_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: penarikan_cuti (Penarikan cuti yang sudah disetujui, seluruhnya atau sebagian)
+CREATE TABLE penarikan_cuti (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengajuan_cuti_id INT NOT NULL,
+    pengguna_id INT NOT NULL,
+    tanggal_selesai_baru DATE NULL COMMENT 'NULL = seluruh cuti ditarik',
+    hari_dikembalikan DECIMAL(5,1) NOT NULL COMMENT 'Hari kerja yang dikembalikan ke saldo',
+    alasan TEXT NOT NULL,
+    status ENUM('menunggu', 'disetujui', 'ditolak') DEFAULT 'menunggu',
+    diproses_oleh INT NULL COMMENT 'ID Pengguna HR yang memproses',
+    tanggal_persetujuan DATETIME NULL,
+    catatan_persetujuan TEXT,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengajuan_cuti_id) REFERENCES pengajuan_cuti(id) ON DELETE CASCADE,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (diproses_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: persetujuan_cuti (Tahap persetujuan pengajuan cuti: atasan langsung lalu HR)
+CREATE TABLE persetujuan_cuti (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengajuan_cuti_id INT NOT NULL,
+    tahap INT NOT NULL COMMENT 'Urutan persetujuan, mulai dari 1',
+    peran ENUM('atasan', 'hr') NOT NULL,
+    penyetuju_id INT NULL COMMENT 'Atasan yang ditunjuk; NULL untuk tahap HR',
+    status ENUM('menunggu', 'disetujui', 'ditolak', 'dilewati') DEFAULT 'menunggu',
+    diproses_oleh INT NULL COMMENT 'Penyetuju atau delegasinya yang memproses',
+    catatan TEXT,
+    diproses_pada DATETIME NULL,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengajuan_cuti_id) REFERENCES pengajuan_cuti(id) ON DELETE CASCADE,
+    FOREIGN KEY (penyetuju_id) REFERENCES pengguna(id) ON DELETE SET NULL,
+    FOREIGN KEY (diproses_oleh) REFERENCES pengguna(id) ON DELETE SET NULL,
+    UNIQUE KEY unik_pengajuan_tahap (pengajuan_cuti_id, tahap)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: delegasi_persetujuan (Pelimpahan persetujuan cuti selama atasan berhalangan)
+CREATE TABLE delegasi_persetujuan (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL COMMENT 'Atasan yang melimpahkan',
+    delegasi_id INT NOT NULL COMMENT 'Karyawan yang menyetujui atas nama atasan',
+    tanggal_mulai DATE NOT NULL,
+    tanggal_selesai DATE NOT NULL,
+    alasan TEXT,
+    aktif BOOLEAN DEFAULT TRUE,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (delegasi_id) REFERENCES pengguna(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Tabel: token_kalender (Token langganan feed iCal kalender cuti, satu per pengguna)
+CREATE TABLE token_kalender (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL UNIQUE,
+    token_hash CHAR(64) NOT NULL UNIQUE COMMENT 'SHA-256 token; token asli hanya ditampilkan sekali',
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- Tabel: saldo_cuti (Saldo cuti karyawan)
 CREATE TABLE saldo_cuti (
     id INT PRIMARY KEY AUTO_INCREMENT,
     pengguna_id INT NOT NULL,
     tahun YEAR NOT NULL,
-    total_hari INT NOT NULL COMMENT 'Jatah cuti per tahun',
-    hari_terpakai INT DEFAULT 0 COMMENT 'Cuti yang sudah digunakan',
-    sisa_hari INT GENERATED ALWAYS AS (total_hari - hari_terpakai) STORED,
+    total_hari DECIMAL(5,1) NOT NULL COMMENT 'Jatah cuti yang sudah dikreditkan',
+    jatah_tahunan DECIMAL(5,1) NULL COMMENT 'Jatah setahun setelah prorata masa kerja',
+    hari_terpakai DECIMAL(5,1) DEFAULT 0 COMMENT 'Cuti yang sudah digunakan',
+    hari_dibawa DECIMAL(5,1) NOT NULL DEFAULT 0 COMMENT 'Sisa tahun lalu yang dibawa',
+    dibawa_terpakai DECIMAL(5,1) NOT NULL DEFAULT 0 COMMENT 'Bagian hari_terpakai yang diambil dari hari_dibawa',
+    dibawa_kedaluwarsa DATE NULL COMMENT 'Batas penggunaan hari_dibawa',
+    hari_hangus DECIMAL(5,1) NOT NULL DEFAULT 0 COMMENT 'hari_dibawa yang hangus',
+    hari_penyesuaian DECIMAL(5,1) NOT NULL DEFAULT 0 COMMENT 'Total penyesuaian manual HR',
+    sisa_hari DECIMAL(5,1) GENERATED ALWAYS AS (total_hari + hari_dibawa - hari_hangus - hari_terpakai + hari_penyesuaian) STORED,
     dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     diperbarui_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
     UNIQUE KEY unik_pengguna_tahun (pengguna_id, tahun)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- Tabel: mutasi_cuti (Buku besar saldo cuti; jumlah saldo_cuti diturunkan dari tabel ini)
+CREATE TABLE mutasi_cuti (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    pengguna_id INT NOT NULL,
+    tahun YEAR NOT NULL,
+    jenis ENUM('akrual', 'pemakaian', 'pengembalian', 'penyesuaian', 'bawaan', 'hangus') NOT NULL,
+    hari DECIMAL(5,1) NOT NULL COMMENT 'Positif menambah saldo, negatif mengurangi',
+    hari_dibawa DECIMAL(5,1) NOT NULL DEFAULT 0 COMMENT 'Bagian pemakaian/pengembalian dari sisa yang dibawa',
+    saldo_setelah DECIMAL(5,1) NOT NULL COMMENT 'sisa_hari setelah mutasi ini',
+    pengajuan_cuti_id INT NULL,
+    keterangan VARCHAR(255) NULL,
+    dibuat_oleh INT NULL COMMENT 'HR untuk penyesuaian; NULL = sistem',
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pengguna_id) REFERENCES pengguna(id) ON DELETE CASCADE,
+    FOREIGN KEY (pengajuan_cuti_id) REFERENCES pengajuan_cuti(id) ON DELETE SET NULL,
+    FOREIGN KEY (dibuat_oleh) REFERENCES pengguna(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- ============================================================
 -- 7. PENGGAJIAN (Panel HR & Keuangan)
 -- ============================================================
@@ -186,6 +558,7 @@ CREATE TABLE penggajian (
     tahun YEAR NOT NULL,
     gaji_pokok DECIMAL(15,2) NOT NULL,
     total_potongan DECIMAL(15,2) DEFAULT 0,
+    total_tunjangan DECIMAL(15,2) DEFAULT 0 COMMENT 'Tunjangan di luar gaji pokok, mis. uang harian dinas',
     gaji_bersih DECIMAL(15,2) NOT NULL COMMENT 'Gaji bersih',
     status ENUM('draft', 'dikirim_ke_keuangan', 'dibayar') DEFAULT 'draft',
     dihitung_pada DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -209,6 +582,19 @@ CREATE TABLE detail_potongan_gaji (
     FOREIGN KEY (aturan_potongan_id) REFERENCES aturan_potongan(id) ON DELETE RESTRICT
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- Tabel: detail_tunjangan_gaji (Detail tunjangan gaji)
+CREATE TABLE detail_tunjangan_gaji (
+    id INT PRIMARY KEY AUTO_INCREMENT,
+    penggajian_id INT NOT NULL,
+    perjalanan_dinas_id INT NULL,
+    deskripsi VARCHAR(255),
+    jumlah DECIMAL(15,2) NOT NULL,
+    dibuat_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (penggajian_id) REFERENCES penggajian(id) ON DELETE CASCADE,
+    FOREIGN KEY (perjalanan_dinas_id) REFERENCES perjalanan_dinas(id) ON DELETE SET NULL,
+    UNIQUE KEY unik_penggajian_dinas (penggajian_id, perjalanan_dinas_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- Tabel: pembayaran (Pembayaran gaji)
 CREATE TABLE pembayaran (
     id INT PRIMARY KEY AUTO_INCREMENT,
@@ -236,6 +622,16 @@ CREATE INDEX idx_presensi_pengguna ON presensi(pengguna_id);
 CREATE INDEX idx_presensi_tanggal ON presensi(tanggal);
 CREATE INDEX idx_presensi_status ON presensi(status);
 
+CREATE INDEX idx_koreksi_presensi_pengguna ON koreksi_presensi(pengguna_id, tanggal);
+CREATE INDEX idx_koreksi_presensi_status ON koreksi_presensi(status);
+CREATE INDEX idx_riwayat_presensi ON riwayat_perubahan_presensi(presensi_id);
+CREATE INDEX idx_presensi_perangkat ON presensi(perangkat_id, tanggal);
+CREATE INDEX idx_tanda_presensi_status ON tanda_presensi(status);
+CREATE INDEX idx_presensi_offline_status ON presensi_offline(status);
+CREATE INDEX idx_log_mesin_pengguna ON log_mesin_absensi(pengguna_id, waktu);
+CREATE INDEX idx_pengajuan_wfh_pengguna ON pengajuan_wfh(pengguna_id, tanggal_mulai, tanggal_selesai);
+CREATE INDEX idx_perjalanan_dinas_pengguna ON perjalanan_dinas(pengguna_id, tanggal_mulai, tanggal_selesai);
+
 CREATE INDEX idx_pengajuan_cuti_pengguna ON pengajuan_cuti(pengguna_id);
 CREATE INDEX idx_pengajuan_cuti_status ON pengajuan_cuti(status);
 CREATE INDEX idx_pengajuan_cuti_tanggal ON pengajuan_cuti(tanggal_mulai, tanggal_selesai);
@@ -273,6 +669,24 @@ INSERT INTO aturan_potongan (nama, tipe_potongan, nilai_potongan, deskripsi) VAL
 ('Terlambat Presensi', 'tetap', 50000.00, 'Potongan Rp 50,000 per kejadian'),
 ('Tidak Presensi Pulang', 'tetap', 25000.00, 'Potongan Rp 25,000 per kejadian');
 
+-- Insert tingkat keterlambatan default
+INSERT INTO tingkat_keterlambatan (nama, menit_dari, menit_sampai, setengah_hari, aturan_potongan_id, poin_peringatan) VALUES
+('Ringan', 1, 15, FALSE, 2, 1),
+('Sedang', 16, 60, FALSE, 2, 2),
+('Berat', 61, NULL, TRUE, 1, 3);
+
+-- Insert jenis cuti default (hari = hari kerja)
+INSERT INTO jenis_cuti (kode, nama, potong_saldo, dibayar, maks_hari_per_pengajuan, maks_hari_per_tahun, jenis_kelamin, masa_kerja_minimal_bulan, wajib_dokumen, wajib_dokumen_minimal_hari) VALUES
+('cuti', 'Cuti Tahunan', TRUE, TRUE, NULL, NULL, NULL, 0, FALSE, 0),
+('izin', 'Izin', FALSE, TRUE, NULL, NULL, NULL, 0, FALSE, 0),
+('sakit', 'Sakit', FALSE, TRUE, NULL, NULL, NULL, 0, TRUE, 2),
+('melahirkan', 'Cuti Melahirkan (3 bulan)', FALSE, TRUE, 65, 65, 'P', 0, TRUE, 0),
+('ayah', 'Cuti Istri Melahirkan', FALSE, TRUE, 2, NULL, 'L', 0, FALSE, 0),
+('menikah', 'Cuti Menikah', FALSE, TRUE, 3, 3, NULL, 0, TRUE, 0),
+('duka', 'Cuti Duka', FALSE, TRUE, 2, NULL, NULL, 0, FALSE, 0),
+('haji', 'Cuti Ibadah Haji', FALSE, TRUE, 40, 40, NULL, 12, TRUE, 0),
+('tanpa_gaji', 'Cuti di Luar Tanggungan', FALSE, FALSE, NULL, NULL, NULL, 12, FALSE, 0);
+
 -- Insert pengguna admin default (email: admin@gmail.com, password: dsadsadsa)
 INSERT INTO pengguna (username, email, password, nama_lengkap, peran_id, aktif) VALUES
 ('admin', 'admin@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Administrator Sistem', 1, TRUE);
@@ -37,20 +37,20 @@ INSERT INTO divisi (id, nama, deskripsi) VALUES
 -- 4. SEED PENGGUNA UTAMA (Request User)
 -- Password Default: 'dsadsadsa' -> $2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC
 
-INSERT INTO pengguna (username, email, password, nama_lengkap, peran_id, divisi_id, nama_bank, nomor_rekening, nama_pemilik_rekening) VALUES
-('admin', 'admin@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Administrator Utama', 1, NULL, 'BCA', '1234567801', 'Admin'),
-('hr', 'hr@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Staff HR Manager', 2, 4, 'BCA', '1234567802', 'HR Manager'),
-('keuangan', 'keuangan@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Staff Finance', 3, 3, 'BCA', '1234567803', 'Staff Finance'),
-('karyawan', 'karyawan@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Budi Santoso', 4, 1, 'MANDIRI', '1234567804', 'Budi Santoso');
+INSERT INTO pengguna (username, email, password, nama_lengkap, peran_id, divisi_id, nama_bank, nomor_rekening, nama_pemilik_rekening, jenis_kelamin, tanggal_bergabung) VALUES
+('admin', 'admin@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Administrator Utama', 1, NULL, 'BCA', '1234567801', 'Admin', 'L', '2020-01-02'),
+('hr', 'hr@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Staff HR Manager', 2, 4, 'BCA', '1234567802', 'HR Manager', 'P', '2021-03-01'),
+('keuangan', 'keuangan@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Staff Finance', 3, 3, 'BCA', '1234567803', 'Staff Finance', 'P', '2021-06-14'),
+('karyawan', 'karyawan@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Budi Santoso', 4, 1, 'MANDIRI', '1234567804', 'Budi Santoso', 'L', '2022-01-10');
 
 -- 5. SEED KARYAWAN TAMBAHAN (> 5 Data)
-INSERT INTO pengguna (username, email, password, nama_lengkap, peran_id, divisi_id, nama_bank, nomor_rekening, nama_pemilik_rekening) VALUES
-('andi', 'andi@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Andi Wijaya', 4, 1, 'BNI', '8888000001', 'Andi Wijaya'),
-('siti', 'siti@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Siti Aminah', 4, 2, 'BRI', '9999000002', 'Siti Aminah'),
-('reza', 'reza@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Reza Rahardian', 4, 5, 'BCA', '7777000003', 'Reza Rahardian'),
-('maya', 'maya@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Maya Estianty', 4, 2, 'MANDIRI', '6666000004', 'Maya Estianty'),
-('joko', 'joko@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Joko Anwar', 4, 1, 'BSI', '5555000005', 'Joko Anwar'),
-('dina', 'dina@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Dina Lorenza', 4, 5, 'CIMB', '4444000006', 'Dina Lorenza');
+INSERT INTO pengguna (username, email, password, nama_lengkap, peran_id, divisi_id, nama_bank, nomor_rekening, nama_pemilik_rekening, jenis_kelamin, tanggal_bergabung) VALUES
+('andi', 'andi@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Andi Wijaya', 4, 1, 'BNI', '8888000001', 'Andi Wijaya', 'L', '2022-08-01'),
+('siti', 'siti@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Siti Aminah', 4, 2, 'BRI', '9999000002', 'Siti Aminah', 'P', '2023-02-13'),
+('reza', 'reza@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Reza Rahardian', 4, 5, 'BCA', '7777000003', 'Reza Rahardian', 'L', '2023-07-03'),
+('maya', 'maya@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Maya Estianty', 4, 2, 'MANDIRI', '6666000004', 'Maya Estianty', 'P', '2024-01-15'),
+('joko', 'joko@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Joko Anwar', 4, 1, 'BSI', '5555000005', 'Joko Anwar', 'L', '2024-05-06'),
+('dina', 'dina@gmail.com', '$2b$10$rkTrWNs2.55fdbs4vo5PK.HysLSOizsDeHsuT56jaw6PkidjXdCmC', 'Dina Lorenza', 4, 5, 'CIMB', '4444000006', 'Dina Lorenza', 'P', '2024-09-02');
 
 -- 6. SEED KONFIGURASI GAJI
 INSERT INTO konfigurasi_gaji (divisi_id, gaji_pokok, tanggal_berlaku) VALUES
@@ -106,10 +106,10 @@ const LeaveManagement: React.FC = () => {
         method: 'PUT',
         headers: {
           'Content-Type': 'application/json',
+          Authorization: `Bearer ${localStorage.getItem('token')}`,
         },
         body: JSON.stringify({
           status: status,
-          disetujui_oleh: user.id, // ID Admin/HR logged in
           catatan_persetujuan: notes
         }),
       });
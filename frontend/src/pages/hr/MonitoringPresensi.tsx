@@ -23,6 +23,8 @@ import { DataGrid, GridColDef, GridRenderCellParams } from '@mui/x-data-grid';
 import MainLayout from '../../components/MainLayout';
 import Breadcrumbs from '../../components/Breadcrumbs';
 
+const GOLANG_API_URL = import.meta.env.VITE_API_GOLANG_URL || 'http://localhost:8080';
+
 interface PresensiItem {
   id: number;
   pengguna_id: number;
@@ -32,6 +34,7 @@ interface PresensiItem {
   waktu_masuk: string | null;
   waktu_pulang: string | null;
   status: string;
+  nama_libur: string | null;
   catatan: string | null;
 }
 
@@ -40,6 +43,9 @@ const MonitoringPresensi: React.FC = () => {
   const [loading, setLoading] = useState(false);
   const [date, setDate] = useState(new Date().toISOString().split('T')[0]);
   const [searchTerm, setSearchTerm] = useState('');
+  const [rowCount, setRowCount] = useState(0);
+  const [paginationModel, setPaginationModel] = useState({ page: 0, pageSize: 25 });
+  const [liveTick, setLiveTick] = useState(0);
 
   // Snackbar State
   const [snackbar, setSnackbar] = useState({
@@ -51,10 +57,18 @@ const MonitoringPresensi: React.FC = () => {
   const fetchPresensi = async () => {
     setLoading(true);
     try {
-      const response = await fetch(`http://localhost:8080/api/hr/presensi?date=${date}`);
+      const params = new URLSearchParams({
+        date,
+        cari: searchTerm,
+        urutkan: 'nama',
+        halaman: String(paginationModel.page + 1),
+        per_halaman: String(paginationModel.pageSize),
+      });
+      const response = await fetch(`${GOLANG_API_URL}/api/hr/presensi?${params}`);
       const result = await response.json();
       if (result.success) {
         setData(result.data || []);
+        setRowCount(result.meta?.total || 0);
       }
     } catch (error) {
       console.error('Error fetching presensi:', error);
@@ -69,13 +83,38 @@ const MonitoringPresensi: React.FC = () => {
   };
 
   useEffect(() => {
-    fetchPresensi();
-  }, [date]);
+    const timer = setTimeout(fetchPresensi, 300); // Debounce typing in the search box
+    return () => clearTimeout(timer);
+  }, [date, searchTerm, paginationModel, liveTick]);
 
-  const filteredData = data.filter(item => 
-    item.nama_lengkap.toLowerCase().includes(searchTerm.toLowerCase()) ||
-    (item.divisi && item.divisi.toLowerCase().includes(searchTerm.toLowerCase()))
-  );
+  // Live feed: reload today's list whenever someone clocks in or out
+  useEffect(() => {
+    if (date !== new Date().toISOString().split('T')[0]) return;
+    const token = localStorage.getItem('token');
+    if (!token) return;
+    let source: EventSource | null = null;
+    let cancelled = false;
+    const refresh = () => setLiveTick((t) => t + 1);
+
+    // EventSource cannot send the Authorization header, so open it with a single-use ticket
+    fetch(`${GOLANG_API_URL}/api/hr/presensi/stream/tiket`, {
+      method: 'POST',
+      headers: { Authorization: `Bearer ${token}` },
+    })
+      .then((response) => response.json())
+      .then((result) => {
+        if (cancelled || !result.success) return;
+        source = new EventSource(`${GOLANG_API_URL}/api/hr/presensi/stream?tiket=${encodeURIComponent(result.data.tiket)}`);
+        source.addEventListener('masuk', refresh);
+        source.addEventListener('pulang', refresh);
+      })
+      .catch((error) => console.error('Error opening live feed:', error));
+
+    return () => {
+      cancelled = true;
+      source?.close();
+    };
+  }, [date]);
 
   const formatTime = (timeStr: string | null) => {
     if (!timeStr) return '-';
@@ -137,6 +176,14 @@ const MonitoringPresensi: React.FC = () => {
             color = 'info';
             label = params.value === 'izin' ? 'Izin' : 'Cuti';
             break;
+          case 'dinas':
+            color = 'success';
+            label = 'Dinas Luar';
+            break;
+          case 'libur':
+            color = 'default';
+            label = params.row.nama_libur || 'Libur';
+            break;
         }
 
         return (
@@ -185,7 +232,10 @@ const MonitoringPresensi: React.FC = () => {
               type="date"
               size="small"
               value={date}
-              onChange={(e) => setDate(e.target.value)}
+              onChange={(e) => {
+                setDate(e.target.value);
+                setPaginationModel({ ...paginationModel, page: 0 });
+              }}
               InputLabelProps={{ shrink: true }}
               InputProps={{
                 startAdornment: (
@@ -200,7 +250,10 @@ const MonitoringPresensi: React.FC = () => {
               label="Cari Karyawan / Divisi"
               size="small"
               value={searchTerm}
-              onChange={(e) => setSearchTerm(e.target.value)}
+              onChange={(e) => {
+                setSearchTerm(e.target.value);
+                setPaginationModel({ ...paginationModel, page: 0 });
+              }}
               InputProps={{
                 startAdornment: (
                   <InputAdornment position="start">
@@ -215,12 +268,15 @@ const MonitoringPresensi: React.FC = () => {
 
         <Box sx={{ height: 600, width: '100%', bgcolor: 'white', borderRadius: 2, overflow: 'hidden', border: '1px solid #e2e8f0' }}>
           <DataGrid
-            rows={filteredData}
+            rows={data}
             columns={columns}
             loading={loading}
-            getRowId={(row) => row.pengguna_id}
-            initialState={{ pagination: { paginationModel: { page: 0, pageSize: 10 } } }}
-            pageSizeOptions={[10, 25, 50]}
+            getRowId={(row) => `${row.pengguna_id}-${row.tanggal}`}
+            paginationMode="server"
+            rowCount={rowCount}
+            paginationModel={paginationModel}
+            onPaginationModelChange={setPaginationModel}
+            pageSizeOptions={[10, 25, 50, 100]}
             disableRowSelectionOnClick
             sx={{ border: 'none', '& .MuiDataGrid-columnHeaders': { bgcolor: '#f8fafc' } }}
           />